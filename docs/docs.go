@@ -23,18 +23,75 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/": {
+            "post": {
+                "description": "Resolve a list of DIDs and DID URLs at once. Every item is handled as a request of its own and gets the status code, media type and body the request would have returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DID Resolution"
+                ],
+                "summary": "Resolve and dereference several DIDs and DID URLs on did:cheqd",
+                "parameters": [
+                    {
+                        "description": "DIDs and DID URLs, as strings or with their options",
+                        "name": "items",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.BatchRequestItem"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    }
+                }
+            }
+        },
         "/{did}": {
             "get": {
                 "description": "Fetch DID Document (\"DIDDoc\") from cheqd network",
                 "consumes": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "produces": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "tags": [
                     "DID Resolution"
@@ -137,6 +194,12 @@ const docTemplate = `{
                         "description": "Sanity check that Checksum of resource is the same as expected",
                         "name": "checksum",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolve at the given ledger block height",
+                        "name": "blockHeight",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -164,6 +227,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.IdentityError"
                         }
                     },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -175,6 +244,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.IdentityError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
                     }
                 }
             }
@@ -185,12 +266,14 @@ const docTemplate = `{
                 "consumes": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "produces": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "tags": [
                     "Resource Resolution"
@@ -203,6 +286,24 @@ const docTemplate = `{
                         "name": "did",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolve at the given ledger block height",
+                        "name": "blockHeight",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of resources to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of resources to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -242,6 +343,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.IdentityError"
                         }
                     },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -253,6 +360,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.IdentityError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
                     }
                 }
             }
@@ -284,6 +403,24 @@ const docTemplate = `{
                         "name": "resourceId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolve at the given ledger block height",
+                        "name": "blockHeight",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte ranges of the resource data, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag or Last-Modified date of the resource the ranges apply to",
+                        "name": "If-Range",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -296,6 +433,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -314,6 +460,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.IdentityError"
                         }
                     },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -325,6 +477,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.IdentityError"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
                     }
                 }
             }
@@ -335,12 +505,14 @@ const docTemplate = `{
                 "consumes": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "produces": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "tags": [
                     "Resource Resolution"
@@ -360,6 +532,12 @@ const docTemplate = `{
                         "name": "resourceId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolve at the given ledger block height",
+                        "name": "blockHeight",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -387,6 +565,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.IdentityError"
                         }
                     },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -398,6 +582,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.IdentityError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
                     }
                 }
             }
@@ -408,12 +604,14 @@ const docTemplate = `{
                 "consumes": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "produces": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "tags": [
                     "DID Resolution"
@@ -433,6 +631,12 @@ const docTemplate = `{
                         "name": "versionId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolve at the given ledger block height",
+                        "name": "blockHeight",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -460,6 +664,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.IdentityError"
                         }
                     },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -471,6 +681,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.IdentityError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
                     }
                 }
             }
@@ -481,12 +703,14 @@ const docTemplate = `{
                 "consumes": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+jsonww"
+                    "application/did+jsonww",
+                    "application/did+cbor"
                 ],
                 "produces": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "tags": [
                     "DID Resolution"
@@ -506,6 +730,12 @@ const docTemplate = `{
                         "name": "versionId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolve at the given ledger block height",
+                        "name": "blockHeight",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -533,6 +763,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.IdentityError"
                         }
                     },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -544,6 +780,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.IdentityError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
                     }
                 }
             }
@@ -554,12 +802,14 @@ const docTemplate = `{
                 "consumes": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "produces": [
                     "application/did+ld+json",
                     "application/ld+json",
-                    "application/did+json"
+                    "application/did+json",
+                    "application/did+cbor"
                 ],
                 "tags": [
                     "DID Resolution"
@@ -572,6 +822,24 @@ const docTemplate = `{
                         "name": "did",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resolve at the given ledger block height",
+                        "name": "blockHeight",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of versions to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of versions to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -611,6 +879,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.IdentityError"
                         }
                     },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -622,25 +896,104 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.IdentityError"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.IdentityError"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "types.BatchRequestItem": {
+            "type": "object",
+            "properties": {
+                "accept": {
+                    "description": "Media type of the result, as in the Accept header of a single request, */* by default",
+                    "type": "string",
+                    "example": "application/did+ld+json"
+                },
+                "didUrl": {
+                    "type": "string",
+                    "example": "did:cheqd:mainnet:zF7rhDBfUt9d1gJPjx7s1JXfUY7oVWkY"
+                },
+                "options": {
+                    "description": "Query parameters added to the DID URL, e.g. versionId or resourceMetadata",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.BatchResult"
+                    }
+                }
+            }
+        },
+        "types.BatchResult": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Result or error, if it is JSON",
+                    "type": "object"
+                },
+                "contentBase64": {
+                    "description": "Result of any other media type, e.g. of resource data or CBOR",
+                    "type": "string",
+                    "format": "base64"
+                },
+                "contentType": {
+                    "type": "string",
+                    "example": "application/did+ld+json"
+                },
+                "didUrl": {
+                    "type": "string",
+                    "example": "did:cheqd:mainnet:zF7rhDBfUt9d1gJPjx7s1JXfUY7oVWkY"
+                },
+                "location": {
+                    "description": "Target of a redirect, e.g. of a service endpoint",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "HTTP status code of the result",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
         "types.ContentType": {
             "type": "string",
             "enum": [
                 "application/did+json",
                 "application/did+ld+json",
                 "application/ld+json",
-                "application/json"
+                "application/json",
+                "application/did+cbor",
+                "application/cbor"
             ],
             "x-enum-varnames": [
                 "DIDJSON",
                 "DIDJSONLD",
                 "JSONLD",
-                "JSON"
+                "JSON",
+                "DIDCBOR",
+                "CBOR"
             ]
         },
         "types.DereferencedDidVersionsList": {
@@ -706,6 +1059,10 @@ const docTemplate = `{
         "types.DereferencingMetadata": {
             "type": "object",
             "properties": {
+                "blockHeight": {
+                    "type": "integer",
+                    "example": 4109000
+                },
                 "contentType": {
                     "allOf": [
                         {
@@ -869,6 +1226,9 @@ const docTemplate = `{
                 },
                 "message": {
                     "type": "string"
+                },
+                "retryAfter": {
+                    "type": "integer"
                 }
             }
         },
@@ -910,6 +1270,10 @@ const docTemplate = `{
         "types.ResolutionMetadata": {
             "type": "object",
             "properties": {
+                "blockHeight": {
+                    "type": "integer",
+                    "example": 4109000
+                },
                 "contentType": {
                     "allOf": [
                         {
@@ -931,7 +1295,17 @@ const docTemplate = `{
             }
         },
         "types.ResolutionResourceMetadata": {
-            "type": "object"
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "type": "string",
+                    "example": "a95380f460e63ad939541a57aecbfd795fcd37c6d78ee86c885340e33a91b559"
+                },
+                "created": {
+                    "type": "string",
+                    "example": "2021-09-01T12:00:00Z"
+                }
+            }
         },
         "types.ResourceDereferencing": {
             "type": "object",
@@ -1,9 +1,21 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/cheqd/did-resolver/services"
+	batchServices "github.com/cheqd/did-resolver/services/batch"
 	didDocServices "github.com/cheqd/did-resolver/services/diddoc"
+	healthServices "github.com/cheqd/did-resolver/services/health"
+	metricsServices "github.com/cheqd/did-resolver/services/metrics"
 	resourceServices "github.com/cheqd/did-resolver/services/resource"
+	statusServices "github.com/cheqd/did-resolver/services/status"
+	"github.com/cheqd/did-resolver/services/tracing"
 	"github.com/cheqd/did-resolver/types"
 	"github.com/cheqd/did-resolver/utils"
 	"github.com/labstack/echo/v4"
@@ -21,19 +33,49 @@ func serve() {
 	config := types.GetConfig()
 	// Setup logger
 	types.SetupLogger(config)
+	// Setup tracing before the ledger connections, which trace their calls
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing)
+	if err != nil {
+		panic(err)
+	}
+	if config.Tracing.Endpoint != "" {
+		log.Info().Msgf("Exporting traces to %s", config.Tracing.Endpoint)
+	}
 	// Services
 	ledgerService := services.NewLedgerService()
-	didService := services.NewDIDDocService(types.DID_METHOD, ledgerService)
-	resourceService := services.NewResourceService(types.DID_METHOD, ledgerService)
-
+	restLedgerService := services.NewRESTLedgerService()
+	snapshotLedgerService := services.NewSnapshotLedgerService()
+	router := services.NewLedgerRouter()
 	for _, network := range config.Networks {
-		log.Info().Msgf("Registering network: %s.", network.Namespace)
-		err := ledgerService.RegisterLedger(types.DID_METHOD, network)
+		log.Info().Msgf("Registering network: %s via %s API.", network.Namespace, network.Api)
+		var err error
+		switch network.Api {
+		case types.RESTApi:
+			err = restLedgerService.RegisterLedger(types.DID_METHOD, network)
+			router.Register(types.DID_METHOD, network.Namespace, restLedgerService)
+		case types.SnapshotApi:
+			err = snapshotLedgerService.RegisterLedger(types.DID_METHOD, network)
+			router.Register(types.DID_METHOD, network.Namespace, snapshotLedgerService)
+		default:
+			err = ledgerService.RegisterLedger(types.DID_METHOD, network)
+			router.Register(types.DID_METHOD, network.Namespace, ledgerService)
+		}
 		if err != nil {
 			panic(err)
 		}
 	}
 
+	// Concurrent identical queries which miss the cache share a single ledger call
+	coalescingLedger := services.NewCoalescingLedgerService(router)
+	var ledger services.LedgerServiceI = coalescingLedger
+	if config.Cache.Enabled {
+		log.Info().Msgf("Caching up to %d ledger responses", config.Cache.Size)
+		ledger = services.NewCachedLedgerService(coalescingLedger, config.Cache)
+	}
+
+	didService := services.NewDIDDocService(types.DID_METHOD, ledger)
+	resourceService := services.NewResourceService(types.DID_METHOD, ledger, config.ResourceMaxSize)
+
 	// Echo instance
 	e := echo.New()
 	e.HTTPErrorHandler = services.CustomHTTPErrorHandler
@@ -43,14 +85,28 @@ func serve() {
 		return func(c echo.Context) error {
 			cc := services.ResolverContext{
 				Context:         c,
-				LedgerService:   ledgerService,
+				LedgerService:   ledger,
 				DidDocService:   didService,
 				ResourceService: resourceService,
+
+				DidDocumentResponses: config.DidDocumentResponses,
+				HttpCache:            config.HttpCache,
 			}
 			return next(cc)
 		}
 	})
 
+	// Log every line of a request with its ID
+	e.Use(services.RequestIDMiddleware())
+
+	// Trace the requests, continuing the traces of the clients
+	e.Use(tracing.Middleware())
+
+	// Cancel ledger queries of the requests which took too long
+	if config.RequestTimeout > 0 {
+		e.Use(middleware.ContextTimeout(config.RequestTimeout))
+	}
+
 	// Client sends the Accept-Encoding header and
 	// server should respond with the Content-Encoding header
 	// Decompress only if gzip in headers
@@ -61,17 +117,51 @@ func serve() {
 		// If gzip not in Accept-Encoding header, do not compress
 		Skipper: utils.GzipSkipper,
 	}))
-	e.Use(middleware.Logger())
+	e.Use(services.AccessLogMiddleware())
 	e.Use(middleware.Recover())
+	e.Use(services.MetricsMiddleware)
 
 	e.GET(types.SWAGGER_PATH, echoSwagger.WrapHandler)
 
 	didDocServices.SetRoutes(e)
 	resourceServices.SetRoutes(e)
+	statusServices.SetRoutes(e, router)
+	healthServices.SetRoutes(e, services.NewHealthChecker(router, config.Health))
+	batchServices.SetRoutes(e, config.Batch)
+	metricsServices.SetRoutes(e)
 
 	e.Debug = true
-	log.Info().Msg("Starting listener")
-	log.Fatal().Err(e.Start(config.ResolverListener))
+
+	go func() {
+		log.Info().Msg("Starting listener")
+		if err := e.Start(config.ResolverListener); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Listener failed")
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown the server and ledger connections
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	log.Info().Msg("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to shutdown listener")
+	}
+	if err := ledgerService.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close ledger connections")
+	}
+	if err := restLedgerService.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close ledger REST connections")
+	}
+	if err := snapshotLedgerService.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to stop watching ledger snapshots")
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to export the remaining traces")
+	}
 }
 
 //	@title			DID Resolver for cheqd DID method
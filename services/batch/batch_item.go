@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+// resolveItem sends the item through the routes and middleware of the resolver as a GET request
+// of its own, so it's handled exactly like a single request of the same DID URL
+func resolveItem(ctx context.Context, e *echo.Echo, batchRequest *http.Request, item types.BatchRequestItem) types.BatchResult {
+	request, err := newItemRequest(ctx, batchRequest, item)
+	if err != nil {
+		return errorResult(item.DidUrl, err)
+	}
+
+	response := &itemResponse{header: make(http.Header)}
+	e.ServeHTTP(response, request)
+
+	return response.result(item.DidUrl)
+}
+
+// newItemRequest encodes the DID URL like clients do in the path of a single request,
+// with the fragment escaped and the options added to the query
+func newItemRequest(ctx context.Context, batchRequest *http.Request, item types.BatchRequestItem) (*http.Request, *types.IdentityError) {
+	if !strings.HasPrefix(item.DidUrl, "did:") {
+		return nil, types.NewInvalidDidUrlError(item.DidUrl, types.JSON, errors.New("batch items must be DIDs or DID URLs"), true)
+	}
+
+	didUrl, fragment, hasFragment := strings.Cut(item.DidUrl, "#")
+	path, rawQuery, _ := strings.Cut(didUrl, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, types.NewInvalidDidUrlError(item.DidUrl, types.JSON, err, true)
+	}
+	for name, value := range item.Options {
+		query.Set(name, value)
+	}
+
+	target := types.RESOLVER_PATH + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	if hasFragment {
+		target += url.QueryEscape("#" + fragment)
+	}
+
+	// Items aren't counted as requests of their own
+	request, err := http.NewRequestWithContext(services.WithBatchItem(ctx), http.MethodGet, target, nil)
+	if err != nil {
+		return nil, types.NewInvalidDidUrlError(item.DidUrl, types.JSON, err, true)
+	}
+	request.RequestURI = target
+	request.Host = batchRequest.Host
+	request.RemoteAddr = batchRequest.RemoteAddr
+	// Like the Accept header browsers and HTTP tools send by default
+	accept := item.Accept
+	if accept == "" {
+		accept = "*/*"
+	}
+	request.Header.Set(echo.HeaderAccept, accept)
+	// Items are logged with the ID of the batch request
+	request.Header.Set(echo.HeaderXRequestID, batchRequest.Header.Get(echo.HeaderXRequestID))
+
+	return request, nil
+}
+
+func errorResult(didUrl string, identityError *types.IdentityError) types.BatchResult {
+	content, _ := json.Marshal(identityError.DisplayMessage())
+
+	return types.BatchResult{
+		DidUrl:      didUrl,
+		Status:      identityError.Code,
+		ContentType: string(identityError.ContentType),
+		Content:     content,
+	}
+}
+
+// itemResponse keeps the response to a batch item in memory
+type itemResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *itemResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *itemResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *itemResponse) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(data)
+}
+
+func (r *itemResponse) result(didUrl string) types.BatchResult {
+	result := types.BatchResult{
+		DidUrl:      didUrl,
+		Status:      r.status,
+		ContentType: r.header.Get(echo.HeaderContentType),
+		Location:    r.header.Get(echo.HeaderLocation),
+	}
+
+	body := r.body.Bytes()
+	switch {
+	case len(body) == 0:
+	case json.Valid(body):
+		result.Content = body
+	default:
+		result.ContentBase64 = body
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+// BatchEchoHandler godoc
+//
+//	@Summary		Resolve and dereference several DIDs and DID URLs on did:cheqd
+//	@Description	Resolve a list of DIDs and DID URLs at once. Every item is handled as a request of its own and gets the status code, media type and body the request would have returned.
+//	@Tags			DID Resolution
+//	@Accept			json
+//	@Produce		json
+//	@Param			items	body		[]types.BatchRequestItem	true	"DIDs and DID URLs, as strings or with their options"
+//	@Success		200		{object}	types.BatchResponse
+//	@Failure		400		{object}	types.IdentityError
+//	@Failure		413		{object}	types.IdentityError
+//	@Failure		500		{object}	types.IdentityError
+//	@Router			/ [post]
+func BatchEchoHandler(e *echo.Echo, config types.BatchConfig) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// The body is limited after decompression, so it's never read into memory beyond the limit
+		body := http.MaxBytesReader(c.Response(), c.Request().Body, int64(config.MaxBodySize))
+		var items []types.BatchRequestItem
+		if err := json.NewDecoder(body).Decode(&items); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return types.NewRequestTooLargeError("", types.JSON, fmt.Errorf("batch body exceeds the limit of %d bytes", config.MaxBodySize), false)
+			}
+			return types.NewInvalidDidUrlError("", types.JSON, err, false)
+		}
+		if len(items) > config.MaxItems {
+			return types.NewInvalidDidUrlError("", types.JSON, fmt.Errorf("batch of %d items exceeds the limit of %d", len(items), config.MaxItems), false)
+		}
+
+		// Items share the deadline of the batch request and are cancelled with it
+		ctx := c.Request().Context()
+		results := make([]types.BatchResult, len(items))
+		semaphore := make(chan struct{}, config.Concurrency)
+		var wg sync.WaitGroup
+		for i, item := range items {
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(i int, item types.BatchRequestItem) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+				results[i] = resolveItem(ctx, e, c.Request(), item)
+			}(i, item)
+		}
+		wg.Wait()
+
+		return c.JSONPretty(http.StatusOK, types.BatchResponse{Results: results}, "  ")
+	}
+}
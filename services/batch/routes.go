@@ -0,0 +1,10 @@
+package batch
+
+import (
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+func SetRoutes(e *echo.Echo, config types.BatchConfig) {
+	e.POST(types.BATCH_PATH, BatchEchoHandler(e, config))
+}
@@ -0,0 +1,16 @@
+package services
+
+import "context"
+
+type batchItemKey struct{}
+
+// WithBatchItem returns a context which marks the request as an item of a batch request
+func WithBatchItem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, batchItemKey{}, true)
+}
+
+// IsBatchItem reports whether the request is an item of a batch request rather than a request of its own
+func IsBatchItem(ctx context.Context) bool {
+	item, _ := ctx.Value(batchItemKey{}).(bool)
+	return item
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// BlockHeightHeader is the gRPC metadata key Cosmos SDK nodes use to select the height a query runs at
+const BlockHeightHeader = "x-cosmos-block-height"
+
+type blockHeightKey struct{}
+
+// WithBlockHeight returns a context which makes ledger queries run at the given block height
+func WithBlockHeight(ctx context.Context, height int64) context.Context {
+	return context.WithValue(ctx, blockHeightKey{}, height)
+}
+
+// BlockHeightFromContext returns the block height requested for the ledger queries, if any
+func BlockHeightFromContext(ctx context.Context) (int64, bool) {
+	height, ok := ctx.Value(blockHeightKey{}).(int64)
+	return height, ok
+}
+
+// withoutBlockHeight returns a context which doesn't select the block height of the ledger queries,
+// for the queries which select the height themselves
+func withoutBlockHeight(ctx context.Context) context.Context {
+	return context.WithValue(ctx, blockHeightKey{}, nil)
+}
+
+// blockHeightInterceptor passes the block height requested for the ledger queries to the node in the gRPC metadata
+func blockHeightInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if height, ok := BlockHeightFromContext(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, BlockHeightHeader, strconv.FormatInt(height, 10))
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// Messages Cosmos SDK nodes answer with when the state at the requested height is not kept
+// or the height is not reached yet
+var blockHeightNotAvailableMessages = []string{
+	"failed to load state at height",
+	"version does not exist",
+	"cannot query with height in the future",
+	"is not available",
+	"pruned",
+}
+
+// isBlockHeightNotAvailable reports whether the node can't answer at the requested height,
+// while another node, e.g. an archive one, may still do it
+func isBlockHeightNotAvailable(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.NotFound || isFailoverError(err) {
+		return false
+	}
+
+	message := strings.ToLower(st.Message())
+	for _, m := range blockHeightNotAvailableMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
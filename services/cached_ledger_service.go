@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/rs/zerolog"
+)
+
+// CachedLedgerService keeps ledger responses in memory in front of another LedgerServiceI.
+// Successful responses are cached with the mutable or immutable TTL depending on whether
+// they can change on the ledger. NotFound responses are cached with their own TTL,
+// other errors are never cached.
+type CachedLedgerService struct {
+	ledgerService LedgerServiceI
+	config        types.CacheConfig
+	cache         *lruCache
+}
+
+type cachedLedgerResponse struct {
+	value interface{}
+	err   *types.IdentityError
+}
+
+func NewCachedLedgerService(ledgerService LedgerServiceI, config types.CacheConfig) CachedLedgerService {
+	return CachedLedgerService{
+		ledgerService: ledgerService,
+		config:        config,
+		cache:         newLRUCache(config.Size),
+	}
+}
+
+func (cls CachedLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	ttl := cls.config.MutableTTL
+	if version != "" {
+		ttl = cls.config.ImmutableTTL
+	}
+
+	value, err := cls.query(ctx, "diddoc:"+did+":"+version, ttl, func() (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryDIDDoc(ctx, did, version)
+	})
+	didDoc, _ := value.(*didTypes.DidDocWithMetadata)
+
+	return didDoc, err
+}
+
+func (cls CachedLedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
+	value, err := cls.query(ctx, "versions:"+did, cls.config.MutableTTL, func() (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryAllDidDocVersionsMetadata(ctx, did)
+	})
+	versions, _ := value.([]*didTypes.Metadata)
+
+	// Callers may reorder the list, so don't share the cached one
+	return append([]*didTypes.Metadata(nil), versions...), err
+}
+
+func (cls CachedLedgerService) QueryResource(ctx context.Context, did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+	value, err := cls.query(ctx, "resource:"+did+":"+resourceId, cls.config.ImmutableTTL, func() (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryResource(ctx, did, resourceId)
+	})
+	resource, _ := value.(*resourceTypes.ResourceWithMetadata)
+
+	return resource, err
+}
+
+func (cls CachedLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	value, err := cls.query(ctx, "resources:"+did, cls.config.MutableTTL, func() (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryCollectionResources(ctx, did)
+	})
+	resources, _ := value.([]*resourceTypes.Metadata)
+
+	// Callers may reorder the list, so don't share the cached one
+	return append([]*resourceTypes.Metadata(nil), resources...), err
+}
+
+func (cls CachedLedgerService) GetNamespaces() []string {
+	return cls.ledgerService.GetNamespaces()
+}
+
+func (cls CachedLedgerService) IsProven(did string) bool {
+	return isLedgerProven(cls.ledgerService, did)
+}
+
+func (cls CachedLedgerService) PinTrustedRoot(ctx context.Context, did string) (context.Context, *types.IdentityError) {
+	return pinTrustedRoot(ctx, cls.ledgerService, did)
+}
+
+func (cls CachedLedgerService) query(ctx context.Context, key string, ttl time.Duration, query func() (interface{}, *types.IdentityError)) (interface{}, *types.IdentityError) {
+	// State at a past block height never changes
+	if height, ok := BlockHeightFromContext(ctx); ok {
+		key += "@" + strconv.FormatInt(height, 10)
+		ttl = cls.config.ImmutableTTL
+	}
+
+	if cached, ok := cls.cache.Get(key); ok {
+		zerolog.Ctx(ctx).Debug().Msgf("Ledger cache hit: %s", key)
+		response := cached.(cachedLedgerResponse)
+		return response.value, copyIdentityError(response.err)
+	}
+
+	value, err := query()
+	switch {
+	case err == nil:
+		cls.cache.Set(key, cachedLedgerResponse{value: value}, ttl)
+	case err.Code == types.NotFoundHttpCode:
+		cls.cache.Set(key, cachedLedgerResponse{value: value, err: copyIdentityError(err)}, cls.config.NotFoundTTL)
+	}
+
+	return value, err
+}
+
+// Errors are modified by the callers, so each of them gets its own copy
+func copyIdentityError(err *types.IdentityError) *types.IdentityError {
+	if err == nil {
+		return nil
+	}
+	errCopy := *err
+	return &errCopy
+}
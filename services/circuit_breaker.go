@@ -0,0 +1,132 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errCircuitOpen is returned without querying any endpoint when all the endpoints of a namespace keep failing
+var errCircuitOpen = status.Error(codes.Unavailable, "circuit breakers of all the endpoints are open")
+
+type circuitState string
+
+const (
+	// Queries are sent to the endpoint
+	circuitClosed circuitState = "closed"
+	// The endpoint is skipped until the open duration passes
+	circuitOpen circuitState = "open"
+	// A single trial query is sent to the endpoint to find out whether it has recovered
+	circuitHalfOpen circuitState = "half-open"
+)
+
+// circuitBreaker stops sending queries to an endpoint after a number of consecutive failures,
+// so a dead node doesn't cost every request a timeout. After the open duration a trial query
+// is let through: the breaker closes if it succeeds and opens again otherwise.
+type circuitBreaker struct {
+	address string
+	config  types.CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	// When the breaker was opened or the last trial query was let through
+	changedAt time.Time
+}
+
+func newCircuitBreaker(address string, config types.CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{
+		address: address,
+		config:  config,
+		state:   circuitClosed,
+	}
+}
+
+func (b *circuitBreaker) enabled() bool {
+	return b.config.FailureThreshold > 0
+}
+
+// Allow reports whether a query may be sent to the endpoint
+func (b *circuitBreaker) Allow() bool {
+	if !b.enabled() {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.changedAt) < b.config.OpenDuration {
+			return false
+		}
+		b.setState(circuitHalfOpen)
+		return true
+	case circuitHalfOpen:
+		// Another trial is in flight, unless it was abandoned without an answer
+		if time.Since(b.changedAt) < b.config.OpenDuration {
+			return false
+		}
+		b.changedAt = time.Now()
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records that the endpoint answered
+func (b *circuitBreaker) Success() {
+	if !b.enabled() {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state != circuitClosed {
+		b.setState(circuitClosed)
+	}
+}
+
+// Failure records that the endpoint was unreachable or failed to answer in time
+func (b *circuitBreaker) Failure() {
+	if !b.enabled() {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.config.FailureThreshold) {
+		b.setState(circuitOpen)
+	}
+}
+
+// Status returns the state of the breaker and the number of consecutive failures
+func (b *circuitBreaker) Status() (circuitState, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state, b.failures
+}
+
+// setState must be called with the lock held
+func (b *circuitBreaker) setState(state circuitState) {
+	b.state = state
+	b.changedAt = time.Now()
+
+	switch state {
+	case circuitOpen:
+		log.Warn().Msgf("Circuit breaker of ledger endpoint %s is open after %d consecutive failures", b.address, b.failures)
+	case circuitHalfOpen:
+		log.Info().Msgf("Circuit breaker of ledger endpoint %s is half-open, sending a trial query", b.address)
+	default:
+		log.Info().Msgf("Circuit breaker of ledger endpoint %s is closed", b.address)
+	}
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services/metrics"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/rs/zerolog"
+)
+
+// CoalescingLedgerService makes concurrent identical queries share a single call to another LedgerServiceI.
+// Each caller stops waiting for the call when its own context is done, and the call is canceled
+// once no caller waits for it anymore. It's bounded by the timeouts of the ledger endpoints otherwise.
+type CoalescingLedgerService struct {
+	ledgerService LedgerServiceI
+	mu            *sync.Mutex
+	calls         map[string]*coalescedCall
+}
+
+// coalescedCall is a call shared by the queries waiting for it
+type coalescedCall struct {
+	done   chan struct{}
+	value  interface{}
+	err    *types.IdentityError
+	cancel context.CancelFunc
+	// Guarded by the mutex of the service
+	waiters int
+}
+
+func NewCoalescingLedgerService(ledgerService LedgerServiceI) CoalescingLedgerService {
+	return CoalescingLedgerService{
+		ledgerService: ledgerService,
+		mu:            &sync.Mutex{},
+		calls:         make(map[string]*coalescedCall),
+	}
+}
+
+func (cls CoalescingLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	value, err := cls.query(ctx, "QueryDIDDoc", "diddoc:"+did+":"+version, did, false, func(ctx context.Context) (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryDIDDoc(ctx, did, version)
+	})
+	didDoc, _ := value.(*didTypes.DidDocWithMetadata)
+
+	return didDoc, err
+}
+
+func (cls CoalescingLedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
+	value, err := cls.query(ctx, "QueryAllDidDocVersionsMetadata", "versions:"+did, did, false, func(ctx context.Context) (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryAllDidDocVersionsMetadata(ctx, did)
+	})
+	versions, _ := value.([]*didTypes.Metadata)
+
+	// Callers may reorder the list, so don't share it
+	return append([]*didTypes.Metadata(nil), versions...), err
+}
+
+func (cls CoalescingLedgerService) QueryResource(ctx context.Context, did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+	value, err := cls.query(ctx, "QueryResource", "resource:"+did+":"+resourceId, did, true, func(ctx context.Context) (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryResource(ctx, did, resourceId)
+	})
+	resource, _ := value.(*resourceTypes.ResourceWithMetadata)
+
+	return resource, err
+}
+
+func (cls CoalescingLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	value, err := cls.query(ctx, "QueryCollectionResources", "resources:"+did, did, false, func(ctx context.Context) (interface{}, *types.IdentityError) {
+		return cls.ledgerService.QueryCollectionResources(ctx, did)
+	})
+	resources, _ := value.([]*resourceTypes.Metadata)
+
+	// Callers may reorder the list, so don't share it
+	return append([]*resourceTypes.Metadata(nil), resources...), err
+}
+
+func (cls CoalescingLedgerService) GetNamespaces() []string {
+	return cls.ledgerService.GetNamespaces()
+}
+
+func (cls CoalescingLedgerService) IsProven(did string) bool {
+	return isLedgerProven(cls.ledgerService, did)
+}
+
+func (cls CoalescingLedgerService) PinTrustedRoot(ctx context.Context, did string) (context.Context, *types.IdentityError) {
+	return pinTrustedRoot(ctx, cls.ledgerService, did)
+}
+
+func (cls CoalescingLedgerService) query(
+	ctx context.Context,
+	name string,
+	key string,
+	did string,
+	isDereferencing bool,
+	query func(ctx context.Context) (interface{}, *types.IdentityError),
+) (interface{}, *types.IdentityError) {
+	// The same DID may differ at different heights
+	if height, ok := BlockHeightFromContext(ctx); ok {
+		key += "@" + strconv.FormatInt(height, 10)
+	}
+
+	cls.mu.Lock()
+	call, shared := cls.calls[key]
+	if !shared {
+		call = cls.start(ctx, name, key, query)
+	}
+	call.waiters++
+	cls.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		cls.leave(key, call)
+		return nil, newLedgerError(ctx, key, did, ctx.Err(), isDereferencing)
+	case <-call.done:
+		if shared {
+			metrics.ObserveCoalescing(name, true)
+			zerolog.Ctx(ctx).Debug().Msgf("Ledger query coalesced: %s", key)
+		}
+		return call.value, copyIdentityError(call.err)
+	}
+}
+
+// start runs the query in a call of its own, which the callers of the same key share until it completes.
+// The mutex must be held.
+func (cls CoalescingLedgerService) start(
+	ctx context.Context,
+	name string,
+	key string,
+	query func(ctx context.Context) (interface{}, *types.IdentityError),
+) *coalescedCall {
+	callCtx, cancel := context.WithCancel(detachContext(ctx))
+	call := &coalescedCall{done: make(chan struct{}), cancel: cancel}
+	cls.calls[key] = call
+	metrics.ObserveCoalescing(name, false)
+
+	go func() {
+		defer cancel()
+		value, err := query(callCtx)
+
+		cls.mu.Lock()
+		if cls.calls[key] == call {
+			delete(cls.calls, key)
+		}
+		cls.mu.Unlock()
+
+		call.value, call.err = value, err
+		close(call.done)
+	}()
+
+	return call
+}
+
+// leave cancels the call when the last of its callers stops waiting for it.
+// Later queries of the key start a call of their own rather than joining the canceled one.
+func (cls CoalescingLedgerService) leave(key string, call *coalescedCall) {
+	cls.mu.Lock()
+	defer cls.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	call.cancel()
+	if cls.calls[key] == call {
+		delete(cls.calls, key)
+	}
+}
+
+// detachedContext keeps the values of the context, e.g. the block height, but not its deadline and cancellation,
+// so a shared call isn't aborted when the caller which started it goes away. Queries are still bounded
+// by the timeouts of the ledger endpoints.
+type detachedContext struct {
+	context.Context
+}
+
+func detachContext(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
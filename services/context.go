@@ -1,6 +1,7 @@
 package services
 
 import (
+	"github.com/cheqd/did-resolver/types"
 	"github.com/labstack/echo/v4"
 )
 
@@ -9,4 +10,8 @@ type ResolverContext struct {
 	LedgerService   LedgerServiceI
 	DidDocService   DIDDocService
 	ResourceService ResourceService
+	// DID Document media types in Accept return the DID Document alone rather than the DID resolution result
+	DidDocumentResponses bool
+	// Cache-Control of the responses
+	HttpCache types.HttpCacheConfig
 }
@@ -10,6 +10,7 @@ import (
 
 type DIDDocAllVersionMetadataRequestService struct {
 	services.BaseRequestService
+	Page *types.Page
 }
 
 func (dd *DIDDocAllVersionMetadataRequestService) Setup(c services.ResolverContext) error {
@@ -29,18 +30,31 @@ func (dd DIDDocAllVersionMetadataRequestService) Redirect(c services.ResolverCon
 }
 
 func (dd *DIDDocAllVersionMetadataRequestService) SpecificValidation(c services.ResolverContext) error {
-	// We not allow query here
-	if len(dd.Queries) != 0 {
+	// Only paging queries are allowed here
+	if len(types.PagingQueries.DiffWithUrlValues(dd.Queries)) != 0 {
 		return types.NewInvalidDidUrlError(dd.GetDid(), dd.RequestedContentType, nil, dd.IsDereferencing)
 	}
+
+	page, err := types.NewPage(dd.Queries)
+	if err != nil {
+		return types.NewInvalidDidUrlError(dd.GetDid(), dd.RequestedContentType, err, dd.IsDereferencing)
+	}
+	dd.Page = page
+
 	return nil
 }
 
 func (dd *DIDDocAllVersionMetadataRequestService) Query(c services.ResolverContext) error {
-	result, err := c.DidDocService.GetAllDidDocVersionsMetadata(dd.GetDid(), dd.GetContentType())
+	result, err := c.DidDocService.GetAllDidDocVersionsMetadata(c.Request().Context(), dd.GetDid(), dd.GetContentType())
 	if err != nil {
 		err.IsDereferencing = dd.IsDereferencing
 		return err
 	}
+
+	if versions, ok := result.ContentStream.(*types.DereferencedDidVersionsList); ok && dd.Page != nil {
+		start, end := dd.Page.Bounds(len(versions.Versions))
+		versions.Versions = versions.Versions[start:end]
+	}
+
 	return dd.SetResponse(result)
 }
@@ -299,7 +299,7 @@ func (dd *QueryDIDDocRequestService) RegisterResourceQueryHandlers(startHandler
 }
 
 func (dd *QueryDIDDocRequestService) Query(c services.ResolverContext) error {
-	result, err := dd.FirstHandler.Handle(c, dd, nil)
+	result, err := queries.Handle(c, dd.FirstHandler, dd, nil)
 	if err != nil {
 		return err
 	}
@@ -326,5 +326,5 @@ func (dd QueryDIDDocRequestService) Respond(c services.ResolverContext) error {
 	if dd.IsResourceData(dd.Result) {
 		return dd.RespondWithResourceData(c)
 	}
-	return c.JSONPretty(http.StatusOK, dd.Result, "  ")
+	return dd.BaseRequestService.Respond(c)
 }
@@ -44,7 +44,7 @@ func (dd *DIDDocVersionMetadataRequestService) SpecificValidation(c services.Res
 }
 
 func (dd *DIDDocVersionMetadataRequestService) Query(c services.ResolverContext) error {
-	result, err := c.DidDocService.GetDIDDocVersionsMetadata(dd.GetDid(), dd.Version, dd.GetContentType())
+	result, err := c.DidDocService.GetDIDDocVersionsMetadata(c.Request().Context(), dd.GetDid(), dd.Version, dd.GetContentType())
 	if err != nil {
 		err.IsDereferencing = dd.IsDereferencing
 		return err
@@ -14,8 +14,8 @@ import (
 //	@Summary		Resolve DID Document on did:cheqd
 //	@Description	Fetch DID Document ("DIDDoc") from cheqd network
 //	@Tags			DID Resolution
-//	@Accept			application/did+ld+json,application/ld+json,application/did+json
-//	@Produce		application/did+ld+json,application/ld+json,application/did+json
+//	@Accept			application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
+//	@Produce		application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
 //	@Param			did						path		string				true	"Full DID with unique identifier"
 //	@Param			fragmentId				query		string				false	"#Fragment"
 //	@Param			versionId				query		string				false	"Version"
@@ -32,12 +32,16 @@ import (
 //	@Param			resourceVersionTime		query		string				false	"Get the nearest resource by creation time"
 //	@Param			resourceMetadata		query		string				false	"Show only metadata of resources"
 //	@Param			checksum				query		string				false	"Sanity check that Checksum of resource is the same as expected"
+//	@Param			blockHeight				query		integer				false	"Resolve at the given ledger block height"
 //	@success		200						{object}	types.DidResolution	"versionId, versionTime, transformKeys returns Full DID Document"
 //	@Failure		400						{object}	types.IdentityError
 //	@Failure		404						{object}	types.IdentityError
 //	@Failure		406						{object}	types.IdentityError
+//	@Failure		410						{object}	types.IdentityError
 //	@Failure		500						{object}	types.IdentityError
 //	@Failure		501						{object}	types.IdentityError
+//	@Failure		503						{object}	types.IdentityError
+//	@Failure		504						{object}	types.IdentityError
 //	@Router			/{did} [get]
 //
 // We cannot add several responses here because of https://github.com/swaggo/swag/issues/815
@@ -65,16 +69,20 @@ func DidDocEchoHandler(c echo.Context) error {
 //	@Summary		Resolve DID Document Version on did:cheqd
 //	@Description	Fetch specific all version of a DID Document ("DIDDoc") for a given DID and version ID
 //	@Tags			DID Resolution
-//	@Accept			application/did+ld+json,application/ld+json,application/did+json
-//	@Produce		application/did+ld+json,application/ld+json,application/did+json
+//	@Accept			application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
+//	@Produce		application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
 //	@Param			did			path		string	true	"Full DID with unique identifier"
 //	@Param			versionId	path		string	true	"version of a DID document"
+//	@Param			blockHeight	query		integer	false	"Resolve at the given ledger block height"
 //	@Success		200			{object}	types.DidResolution
 //	@Failure		400			{object}	types.IdentityError
 //	@Failure		404			{object}	types.IdentityError
 //	@Failure		406			{object}	types.IdentityError
+//	@Failure		410			{object}	types.IdentityError
 //	@Failure		500			{object}	types.IdentityError
 //	@Failure		501			{object}	types.IdentityError
+//	@Failure		503			{object}	types.IdentityError
+//	@Failure		504			{object}	types.IdentityError
 //	@Router			/{did}/version/{versionId} [get]
 func DidDocVersionEchoHandler(c echo.Context) error {
 	return services.EchoWrapHandler(&DIDDocVersionRequestService{})(c)
@@ -85,16 +93,20 @@ func DidDocVersionEchoHandler(c echo.Context) error {
 //	@Summary		Resolve DID Document Version Metadata on did:cheqd
 //	@Description	Fetch metadata of specific a DID Document ("DIDDoc") version for a given DID and version ID
 //	@Tags			DID Resolution
-//	@Accept			application/did+ld+json,application/ld+json,application/did+jsonww
-//	@Produce		application/did+ld+json,application/ld+json,application/did+json
+//	@Accept			application/did+ld+json,application/ld+json,application/did+jsonww,application/did+cbor
+//	@Produce		application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
 //	@Param			did			path		string	true	"Full DID with unique identifier"
 //	@Param			versionId	path		string	true	"version of a DID document"
+//	@Param			blockHeight	query		integer	false	"Resolve at the given ledger block height"
 //	@Success		200			{object}	types.DidDereferencing
 //	@Failure		400			{object}	types.IdentityError
 //	@Failure		404			{object}	types.IdentityError
 //	@Failure		406			{object}	types.IdentityError
+//	@Failure		410			{object}	types.IdentityError
 //	@Failure		500			{object}	types.IdentityError
 //	@Failure		501			{object}	types.IdentityError
+//	@Failure		503			{object}	types.IdentityError
+//	@Failure		504			{object}	types.IdentityError
 //	@Router			/{did}/version/{versionId}/metadata [get]
 func DidDocVersionMetadataEchoHandler(c echo.Context) error {
 	return services.EchoWrapHandler(&DIDDocVersionMetadataRequestService{})(c)
@@ -105,15 +117,21 @@ func DidDocVersionMetadataEchoHandler(c echo.Context) error {
 //	@Summary		Resolve DID Document Versions on did:cheqd
 //	@Description	Fetch specific all versions of a DID Document ("DIDDoc") for a given DID
 //	@Tags			DID Resolution
-//	@Accept			application/did+ld+json,application/ld+json,application/did+json
-//	@Produce		application/did+ld+json,application/ld+json,application/did+json
-//	@Param			did	path		string	true	"Full DID with unique identifier"
-//	@Success		200	{object}	types.ResourceDereferencing{contentStream=types.DereferencedDidVersionsList}
-//	@Failure		400	{object}	types.IdentityError
-//	@Failure		404	{object}	types.IdentityError
-//	@Failure		406	{object}	types.IdentityError
-//	@Failure		500	{object}	types.IdentityError
-//	@Failure		501	{object}	types.IdentityError
+//	@Accept			application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
+//	@Produce		application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
+//	@Param			did			path		string	true	"Full DID with unique identifier"
+//	@Param			blockHeight	query		integer	false	"Resolve at the given ledger block height"
+//	@Param			limit		query		integer	false	"Maximum number of versions to return"
+//	@Param			offset		query		integer	false	"Number of versions to skip"
+//	@Success		200			{object}	types.ResourceDereferencing{contentStream=types.DereferencedDidVersionsList}
+//	@Failure		400			{object}	types.IdentityError
+//	@Failure		404			{object}	types.IdentityError
+//	@Failure		406			{object}	types.IdentityError
+//	@Failure		410			{object}	types.IdentityError
+//	@Failure		500			{object}	types.IdentityError
+//	@Failure		501			{object}	types.IdentityError
+//	@Failure		503			{object}	types.IdentityError
+//	@Failure		504			{object}	types.IdentityError
 //	@Router			/{did}/versions [get]
 func DidDocAllVersionMetadataEchoHandler(c echo.Context) error {
 	return services.EchoWrapHandler(&DIDDocAllVersionMetadataRequestService{})(c)
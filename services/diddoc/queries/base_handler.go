@@ -1,7 +1,10 @@
 package queries
 
 import (
+	"reflect"
+
 	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/services/tracing"
 	"github.com/cheqd/did-resolver/types"
 )
 
@@ -31,5 +34,16 @@ func (b *BaseQueryHandler) Continue(c services.ResolverContext, service services
 	if b.next == nil {
 		return nil, types.NewInternalError("next handler is nil", types.DIDJSONLD, nil, b.IsDereferencing)
 	}
-	return b.next.Handle(c, service, response)
+	return Handle(c, b.next, service, response)
+}
+
+// Handle passes the response to the handler within a span named after the type of the handler,
+// so each handler of the chain can be told apart in the trace of the request
+func Handle(c services.ResolverContext, handler BaseQueryHandlerI, service services.RequestServiceI, response types.ResolutionResultI) (types.ResolutionResultI, error) {
+	var result types.ResolutionResultI
+	err := tracing.Step(c, reflect.Indirect(reflect.ValueOf(handler)).Type().Name(), func() (err error) {
+		result, err = handler.Handle(c, service, response)
+		return err
+	})
+	return result, err
 }
@@ -36,7 +36,7 @@ func (dd *DidDocMetadataHandler) Handle(c services.ResolverContext, service serv
 
 	// Filter in descending order
 	sort.Sort(filteredResources)
-	result, _err := c.DidDocService.GetDIDDocVersionsMetadata(service.GetDid(), versionId, service.GetContentType())
+	result, _err := c.DidDocService.GetDIDDocVersionsMetadata(c.Request().Context(), service.GetDid(), versionId, service.GetContentType())
 	if _err != nil {
 		_err.IsDereferencing = dd.IsDereferencing
 		return nil, _err
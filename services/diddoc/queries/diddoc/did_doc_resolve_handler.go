@@ -36,7 +36,7 @@ func (dd *DidDocResolveHandler) Handle(c services.ResolverContext, service servi
 	// Filter in descending order
 	sort.Sort(filteredResources)
 
-	result, _err := c.DidDocService.Resolve(service.GetDid(), versionId, service.GetContentType())
+	result, _err := c.DidDocService.Resolve(c.Request().Context(), service.GetDid(), versionId, service.GetContentType())
 	if _err != nil {
 		_err.IsDereferencing = dd.IsDereferencing
 		return nil, _err
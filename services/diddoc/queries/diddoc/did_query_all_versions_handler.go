@@ -15,7 +15,7 @@ func (d *DidQueryAllVersionsHandler) Handle(c services.ResolverContext, service
 	did := service.GetDid()
 	contentType := service.GetContentType()
 
-	result, err := c.DidDocService.GetAllDidDocVersionsMetadata(did, contentType)
+	result, err := c.DidDocService.GetAllDidDocVersionsMetadata(c.Request().Context(), did, contentType)
 	if err != nil {
 		err.IsDereferencing = d.IsDereferencing
 		return nil, err
@@ -13,7 +13,7 @@ type ResourceQueryHandler struct {
 func (d *ResourceQueryHandler) Handle(c services.ResolverContext, service services.RequestServiceI, response types.ResolutionResultI) (types.ResolutionResultI, error) {
 	// If response is nil, then we need to dereference the resource from the beginning
 	if response == nil {
-		resolutionResult, err := c.ResourceService.DereferenceCollectionResources(service.GetDid(), service.GetContentType())
+		resolutionResult, err := c.ResourceService.DereferenceCollectionResources(c.Request().Context(), service.GetDid(), service.GetContentType())
 		if err != nil {
 			return nil, err
 		}
@@ -27,7 +27,7 @@ func (d *ResourceMetadataHandler) Handle(c services.ResolverContext, service ser
 	// If it's not a metadata query let's just get the latest Resource.
 	// They are sorted in descending order by default
 	resource := resourceCollection.Resources[0]
-	dereferenceResult, _err := c.ResourceService.DereferenceResourceData(service.GetDid(), resource.ResourceId, service.GetContentType())
+	dereferenceResult, _err := c.ResourceService.DereferenceResourceData(c.Request().Context(), service.GetDid(), resource.ResourceId, service.GetContentType())
 	if _err != nil {
 		return nil, _err
 	}
@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"strings"
 
 	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
 
 	"github.com/cheqd/did-resolver/types"
 )
@@ -35,22 +37,38 @@ func (DIDDocService) GetDIDFragment(fragmentId string, didDoc types.DidDoc) type
 	return nil
 }
 
-func (dds DIDDocService) Resolve(did string, version string, contentType types.ContentType) (*types.DidResolution, *types.IdentityError) {
+func (dds DIDDocService) Resolve(ctx context.Context, did string, version string, contentType types.ContentType) (*types.DidResolution, *types.IdentityError) {
 	didResolutionMetadata := types.NewResolutionMetadata(did, contentType, "")
+	didResolutionMetadata.BlockHeight, _ = BlockHeightFromContext(ctx)
+	didResolutionMetadata.Proven = isLedgerProven(dds.ledgerService, did)
 
-	protoDidDocWithMetadata, err := dds.ledgerService.QueryDIDDoc(did, version)
+	// The queries are verified against the same state
+	ctx, err := pinTrustedRoot(ctx, dds.ledgerService, did)
 	if err != nil {
 		err.ContentType = contentType
 		return nil, err
 	}
 
-	resolvedMetadata, mErr := dds.resolveMetadata(did, protoDidDocWithMetadata.Metadata, contentType)
-	if mErr != nil {
-		mErr.ContentType = contentType
-		return nil, mErr
+	var protoDidDocWithMetadata *didTypes.DidDocWithMetadata
+	var resources []*resourceTypes.Metadata
+	err = queryLedgerConcurrently(ctx,
+		func(ctx context.Context) (err *types.IdentityError) {
+			protoDidDocWithMetadata, err = dds.ledgerService.QueryDIDDoc(ctx, did, version)
+			return err
+		},
+		func(ctx context.Context) (err *types.IdentityError) {
+			resources, err = dds.ledgerService.QueryCollectionResources(ctx, did)
+			return err
+		},
+	)
+	if err != nil {
+		err.ContentType = contentType
+		return nil, err
 	}
+
+	resolvedMetadata := types.NewResolutionDidDocMetadata(did, protoDidDocWithMetadata.Metadata, resources)
 	didDoc := types.NewDidDoc(protoDidDocWithMetadata.DidDoc)
-	result := types.DidResolution{Did: &didDoc, Metadata: *resolvedMetadata, ResolutionMetadata: didResolutionMetadata}
+	result := types.DidResolution{Did: &didDoc, Metadata: resolvedMetadata, ResolutionMetadata: didResolutionMetadata}
 	if didResolutionMetadata.ContentType == types.DIDJSONLD || didResolutionMetadata.ContentType == types.JSONLD {
 		didDoc.AddContext(types.DIDSchemaJSONLD)
 		for _, method := range didDoc.VerificationMethod {
@@ -71,16 +89,30 @@ func (dds DIDDocService) Resolve(did string, version string, contentType types.C
 	return &result, nil
 }
 
-func (dds DIDDocService) GetDIDDocVersionsMetadata(did string, version string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
+func (dds DIDDocService) GetDIDDocVersionsMetadata(ctx context.Context, did string, version string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
 	dereferenceMetadata := types.NewDereferencingMetadata(did, contentType, "")
+	dereferenceMetadata.BlockHeight, _ = BlockHeightFromContext(ctx)
+	dereferenceMetadata.Proven = isLedgerProven(dds.ledgerService, did)
 
-	protoDidDocWithMetadata, err := dds.ledgerService.QueryDIDDoc(did, version)
+	// The queries are verified against the same state
+	ctx, err := pinTrustedRoot(ctx, dds.ledgerService, did)
 	if err != nil {
 		err.ContentType = contentType
 		return nil, err
 	}
 
-	resources, err := dds.ledgerService.QueryCollectionResources(did)
+	var protoDidDocWithMetadata *didTypes.DidDocWithMetadata
+	var resources []*resourceTypes.Metadata
+	err = queryLedgerConcurrently(ctx,
+		func(ctx context.Context) (err *types.IdentityError) {
+			protoDidDocWithMetadata, err = dds.ledgerService.QueryDIDDoc(ctx, did, version)
+			return err
+		},
+		func(ctx context.Context) (err *types.IdentityError) {
+			resources, err = dds.ledgerService.QueryCollectionResources(ctx, did)
+			return err
+		},
+	)
 	if err != nil {
 		err.ContentType = contentType
 		return nil, err
@@ -96,17 +128,34 @@ func (dds DIDDocService) GetDIDDocVersionsMetadata(did string, version string, c
 	return &types.ResourceDereferencing{Context: context, ContentStream: &contentStream, DereferencingMetadata: dereferenceMetadata}, nil
 }
 
-func (dds DIDDocService) GetAllDidDocVersionsMetadata(did string, contentType types.ContentType) (*types.DidDereferencing, *types.IdentityError) {
+func (dds DIDDocService) GetAllDidDocVersionsMetadata(ctx context.Context, did string, contentType types.ContentType) (*types.DidDereferencing, *types.IdentityError) {
 	dereferenceMetadata := types.NewDereferencingMetadata(did, contentType, "")
+	dereferenceMetadata.BlockHeight, _ = BlockHeightFromContext(ctx)
+	dereferenceMetadata.Proven = isLedgerProven(dds.ledgerService, did)
 
-	versions, err := dds.ledgerService.QueryAllDidDocVersionsMetadata(did)
+	// The queries are verified against the same state
+	ctx, err := pinTrustedRoot(ctx, dds.ledgerService, did)
 	if err != nil {
+		err.ContentType = contentType
 		return nil, err
 	}
 
-	resources, err := dds.ledgerService.QueryCollectionResources(did)
+	var versions []*didTypes.Metadata
+	var resources []*resourceTypes.Metadata
+	err = queryLedgerConcurrently(ctx,
+		func(ctx context.Context) (err *types.IdentityError) {
+			versions, err = dds.ledgerService.QueryAllDidDocVersionsMetadata(ctx, did)
+			return err
+		},
+		func(ctx context.Context) (err *types.IdentityError) {
+			resources, err = dds.ledgerService.QueryCollectionResources(ctx, did)
+			if err != nil {
+				err.ContentType = contentType
+			}
+			return err
+		},
+	)
 	if err != nil {
-		err.ContentType = contentType
 		return nil, err
 	}
 
@@ -128,8 +177,8 @@ func (dds DIDDocService) GetAllDidDocVersionsMetadata(did string, contentType ty
 	return &types.DidDereferencing{Context: context, ContentStream: contentStream, DereferencingMetadata: dereferenceMetadata}, nil
 }
 
-func (dds DIDDocService) DereferenceSecondary(did string, version string, fragmentId string, contentType types.ContentType) (*types.DidDereferencing, *types.IdentityError) {
-	didResolution, err := dds.Resolve(did, version, contentType)
+func (dds DIDDocService) DereferenceSecondary(ctx context.Context, did string, version string, fragmentId string, contentType types.ContentType) (*types.DidDereferencing, *types.IdentityError) {
+	didResolution, err := dds.Resolve(ctx, did, version, contentType)
 	if err != nil {
 		return nil, err
 	}
@@ -163,14 +212,3 @@ func (dds DIDDocService) DereferenceSecondary(did string, version string, fragme
 
 	return &result, nil
 }
-
-func (dds DIDDocService) resolveMetadata(did string, metadata *didTypes.Metadata, contentType types.ContentType) (*types.ResolutionDidDocMetadata, *types.IdentityError) {
-	resources, err := dds.ledgerService.QueryCollectionResources(did)
-	if err != nil {
-		return nil, err
-	}
-
-	resolvedMetadata := types.NewResolutionDidDocMetadata(did, metadata, resources)
-
-	return &resolvedMetadata, nil
-}
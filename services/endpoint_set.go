@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// endpointTransport connects an endpoint to its node, e.g. over gRPC or the REST API.
+// C is the client the queries are sent with.
+type endpointTransport[C any] interface {
+	// Client returns the client for the next query
+	Client() (C, error)
+	// Probe asks the node whether it is reachable and not catching up with the chain
+	Probe(ctx context.Context) error
+	Close() error
+}
+
+// ledgerEndpoint is a single node serving a namespace
+type ledgerEndpoint[C any] struct {
+	config    types.Endpoint
+	transport endpointTransport[C]
+	healthy   atomic.Bool
+	breaker   *circuitBreaker
+	// Name of the endpoint in the logs
+	kind string
+}
+
+func (e *ledgerEndpoint[C]) setHealthy(healthy bool) {
+	if e.healthy.Swap(healthy) != healthy {
+		if healthy {
+			log.Info().Msgf("%s %s is healthy", e.kind, e.config.Address)
+		} else {
+			log.Warn().Msgf("%s %s is unhealthy", e.kind, e.config.Address)
+		}
+	}
+}
+
+// endpointSet picks the endpoint for every query of a namespace and fails over
+// to the next endpoint when the picked one is unreachable.
+// Health of the endpoints may be probed in background.
+type endpointSet[C any] struct {
+	network   types.Network
+	endpoints []*ledgerEndpoint[C]
+	next      uint32
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newEndpointSet creates an endpoint for every node of the network, connected with the transport returned by newTransport.
+// Kind names the endpoints in the logs.
+func newEndpointSet[C any](network types.Network, kind string, newTransport func(config types.Endpoint) endpointTransport[C]) *endpointSet[C] {
+	set := &endpointSet[C]{
+		network: network,
+		stop:    make(chan struct{}),
+	}
+
+	for _, config := range network.Endpoints {
+		endpoint := &ledgerEndpoint[C]{
+			config:    config,
+			transport: newTransport(config),
+			breaker:   newCircuitBreaker(config.Address, network.CircuitBreaker),
+			kind:      kind,
+		}
+		// Endpoints are considered healthy until the first failure
+		endpoint.healthy.Store(true)
+		set.endpoints = append(set.endpoints, endpoint)
+	}
+
+	return set
+}
+
+// Call runs the query against the endpoints of the namespace until one of them answers
+// or ctx is done, and retries it according to the retry policy of the network if all of them failed.
+// Description is used for logging along with the address of the endpoint.
+func (s *endpointSet[C]) Call(ctx context.Context, description string, query func(ctx context.Context, client C) error) error {
+	return retryLedgerCall(ctx, s.network.Retry, description, func() error {
+		return s.callOnce(ctx, description, query)
+	})
+}
+
+func (s *endpointSet[C]) callOnce(ctx context.Context, description string, query func(ctx context.Context, client C) error) error {
+	var lastErr error
+	for _, endpoint := range s.candidates() {
+		// The request was abandoned or ran out of time, don't bother other endpoints
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !endpoint.breaker.Allow() {
+			continue
+		}
+
+		client, err := endpoint.transport.Client()
+		if err != nil {
+			if errors.Is(err, ErrConnectionPoolClosed) {
+				return err
+			}
+			endpoint.setHealthy(false)
+			endpoint.breaker.Failure()
+			lastErr = err
+			continue
+		}
+
+		zerolog.Ctx(ctx).Info().Msgf("%s via %s", description, endpoint.config.Address)
+
+		queryCtx, cancel := context.WithTimeout(ctx, endpoint.config.Timeout)
+		err = query(queryCtx, client)
+		cancel()
+
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// Nodes may prune different heights, so the next one may still have the requested state
+		if isBlockHeightNotAvailable(err) {
+			zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s via %s failed, trying the next endpoint", description, endpoint.config.Address)
+			endpoint.setHealthy(true)
+			endpoint.breaker.Success()
+			lastErr = err
+			continue
+		}
+		if err == nil || !isFailoverError(err) {
+			endpoint.setHealthy(true)
+			endpoint.breaker.Success()
+			return err
+		}
+
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s via %s failed, trying the next endpoint", description, endpoint.config.Address)
+		endpoint.setHealthy(false)
+		endpoint.breaker.Failure()
+		lastErr = err
+	}
+
+	// Every endpoint was skipped
+	if lastErr == nil {
+		zerolog.Ctx(ctx).Warn().Msgf("%s: %s", description, errCircuitOpen)
+		return errCircuitOpen
+	}
+
+	return lastErr
+}
+
+// candidates returns healthy endpoints ordered according to the strategy
+// followed by unhealthy ones, which are used only as the last resort
+func (s *endpointSet[C]) candidates() []*ledgerEndpoint[C] {
+	healthy := make([]*ledgerEndpoint[C], 0, len(s.endpoints))
+	unhealthy := make([]*ledgerEndpoint[C], 0, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		if endpoint.healthy.Load() {
+			healthy = append(healthy, endpoint)
+		} else {
+			unhealthy = append(unhealthy, endpoint)
+		}
+	}
+
+	if s.network.EndpointStrategy == types.RoundRobinStrategy && len(healthy) > 1 {
+		shift := int(atomic.AddUint32(&s.next, 1)) % len(healthy)
+		healthy = append(healthy[shift:], healthy[:shift]...)
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// Status reports the health and the circuit breaker state of every endpoint
+func (s *endpointSet[C]) Status() []types.EndpointStatus {
+	statuses := make([]types.EndpointStatus, 0, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		state, failures := endpoint.breaker.Status()
+		statuses = append(statuses, types.EndpointStatus{
+			Address:             endpoint.config.Address,
+			Healthy:             endpoint.healthy.Load(),
+			CircuitBreaker:      string(state),
+			ConsecutiveFailures: failures,
+		})
+	}
+
+	return statuses
+}
+
+// runHealthChecks probes the endpoints at the health check interval of the network until the set is closed
+func (s *endpointSet[C]) runHealthChecks() {
+	ticker := time.NewTicker(s.network.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.probeAll()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *endpointSet[C]) probeAll() {
+	_ = s.Probe(context.Background())
+}
+
+// Probe checks all the endpoints at once and updates their health. It fails unless enough of them
+// are healthy to answer queries: one endpoint, or the quorum if the network has one.
+func (s *endpointSet[C]) Probe(ctx context.Context) error {
+	addresses := make([]string, len(s.endpoints))
+	for i, endpoint := range s.endpoints {
+		addresses[i] = endpoint.config.Address
+	}
+
+	return probeConcurrently(ctx, addresses, s.network.Quorum, func(ctx context.Context, i int) error {
+		endpoint := s.endpoints[i]
+		ctx, cancel := context.WithTimeout(ctx, endpoint.config.Timeout)
+		defer cancel()
+
+		err := endpoint.transport.Probe(ctx)
+		endpoint.setHealthy(err == nil)
+		return err
+	})
+}
+
+// probeConcurrently probes all the endpoints at once and fails unless at least needed of them, and at least one, are healthy
+func probeConcurrently(ctx context.Context, addresses []string, needed int, probe func(ctx context.Context, i int) error) error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		healthy int
+		lastErr error
+	)
+	for i := range addresses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := probe(ctx, i)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				lastErr = fmt.Errorf("%s: %w", addresses[i], err)
+			} else {
+				healthy++
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if needed < 1 {
+		needed = 1
+	}
+	if healthy < needed {
+		return fmt.Errorf("%d of %d endpoints are healthy, %d needed: %v", healthy, len(addresses), needed, lastErr)
+	}
+	return nil
+}
+
+// Close stops the health checks and closes the transports of all the endpoints
+func (s *endpointSet[C]) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	var errs []error
+	for _, endpoint := range s.endpoints {
+		if err := endpoint.transport.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isFailoverError reports whether the query may succeed on another endpoint.
+// Data which fails verification may be forged by the node, while another one may answer honestly.
+func isFailoverError(err error) bool {
+	if isMessageTooLarge(err) {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// isMessageTooLarge reports whether the answer was refused for exceeding the receive limit of the client.
+// The limit is checked against the size in the message header, so the data isn't received at all,
+// and every node would send the same message.
+func isMessageTooLarge(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.ResourceExhausted && strings.Contains(st.Message(), "larger than max")
+}
@@ -1,27 +1,34 @@
 package services
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/cheqd/did-resolver/types"
 	"github.com/labstack/echo/v4"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 )
 
 func CustomHTTPErrorHandler(err error, c echo.Context) {
-	if err == nil {
+	// The error may be handled by a middleware already
+	if err == nil || c.Response().Committed {
 		return
 	}
 	identityError := generateIdentityError(err)
+	logger := zerolog.Ctx(c.Request().Context())
 	if identityError.Code == http.StatusInternalServerError {
-		log.Error().Err(identityError.Internal)
+		logger.Error().Err(identityError.Internal).Msg(identityError.Message)
 	} else {
-		log.Warn().Err(identityError.Internal)
+		logger.Warn().Err(identityError.Internal).Msg(identityError.Message)
 	}
 	c.Response().Header().Set(echo.HeaderContentType, string(identityError.ContentType))
-	err = c.JSONPretty(identityError.Code, identityError.DisplayMessage(), "  ")
+	if identityError.RetryAfter > 0 {
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(identityError.RetryAfter.Seconds()))))
+	}
+	err = RespondWithRepresentation(c, identityError.Code, identityError.ContentType, identityError.DisplayMessage())
 	if err != nil {
-		log.Error().Err(err)
+		logger.Error().Err(err).Msg("Failed to respond with the error")
 	}
 }
 
@@ -31,8 +38,16 @@ func generateIdentityError(err error) *types.IdentityError {
 		return identityError
 	}
 	he, ok := err.(*echo.HTTPError)
-	if !ok || he.Code != http.StatusNotFound {
+	if !ok {
+		return types.NewInternalError("", types.JSON, err, false)
+	}
+	switch he.Code {
+	case http.StatusNotFound:
+		return types.NewInvalidDidUrlError("", types.JSON, err, true)
+	case http.StatusServiceUnavailable:
+		// The request ran out of time
+		return types.NewTemporarilyUnavailableError("", types.JSON, err, false)
+	default:
 		return types.NewInternalError("", types.JSON, err, false)
 	}
-	return types.NewInvalidDidUrlError("", types.JSON, err, true)
 }
@@ -0,0 +1,26 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+// LivenessEchoHandler reports that the resolver is running, without querying the ledger
+func LivenessEchoHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, types.LivenessStatus{Status: "alive"})
+}
+
+// ReadinessEchoHandler reports whether the ledger of every network can be queried.
+// It answers 503 if one of the required networks is unreachable.
+func ReadinessEchoHandler(checker *services.HealthChecker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		status := checker.Readiness(c.Request().Context())
+		if !status.Ready {
+			return c.JSON(http.StatusServiceUnavailable, status)
+		}
+		return c.JSON(http.StatusOK, status)
+	}
+}
@@ -0,0 +1,12 @@
+package health
+
+import (
+	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+func SetRoutes(e *echo.Echo, checker *services.HealthChecker) {
+	e.GET(types.HEALTH_LIVE_PATH, LivenessEchoHandler)
+	e.GET(types.HEALTH_READY_PATH, ReadinessEchoHandler(checker))
+}
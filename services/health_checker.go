@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// HealthChecker answers readiness probes by probing the ledger of every namespace registered in the router.
+// Probe results are reused for the cache TTL, so frequent readiness checks don't load the nodes.
+type HealthChecker struct {
+	router LedgerRouter
+	config types.HealthConfig
+
+	// Concurrent readiness checks share the probes of the networks
+	probes *singleflight.Group
+
+	mu      sync.Mutex
+	results map[string]types.NetworkReadiness // method:namespace -> last result
+}
+
+func NewHealthChecker(router LedgerRouter, config types.HealthConfig) *HealthChecker {
+	return &HealthChecker{
+		router:  router,
+		config:  config,
+		probes:  &singleflight.Group{},
+		results: make(map[string]types.NetworkReadiness),
+	}
+}
+
+// Readiness probes at once the networks whose last results are outdated and reports the resolver ready
+// if all the required networks are reachable. Networks are ordered by method and namespace.
+func (h *HealthChecker) Readiness(ctx context.Context) types.ReadinessStatus {
+	keys := h.router.sortedKeys()
+	networks := make([]types.NetworkReadiness, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		if result, ok := h.cachedResult(key); ok {
+			networks[i] = result
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			networks[i] = h.probe(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+
+	status := types.ReadinessStatus{Ready: true, Networks: networks}
+	for _, network := range networks {
+		if network.Required && !network.Reachable {
+			status.Ready = false
+		}
+	}
+
+	return status
+}
+
+func (h *HealthChecker) cachedResult(key string) (types.NetworkReadiness, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result, ok := h.results[key]
+	return result, ok && time.Since(result.CheckedAt) < h.config.CacheTTL
+}
+
+// probe probes the network, or waits for the probe started by another readiness check.
+// The probe runs until it completes, bounded by the timeouts of the endpoints, so its result can be cached,
+// while each check stops waiting for it when its own context is done.
+func (h *HealthChecker) probe(ctx context.Context, key string) types.NetworkReadiness {
+	method, namespace, _ := strings.Cut(key, DELIMITER)
+	required := h.isRequired(namespace)
+
+	probed := h.probes.DoChan(key, func() (interface{}, error) {
+		result := types.NetworkReadiness{
+			Method:    method,
+			Namespace: namespace,
+			Required:  required,
+			Reachable: true,
+			CheckedAt: time.Now(),
+		}
+		if err := h.router.Probe(detachContext(ctx), method, namespace); err != nil {
+			log.Warn().Err(err).Msgf("Readiness: %s is unreachable", key)
+			result.Reachable = false
+			result.Error = err.Error()
+		}
+
+		h.mu.Lock()
+		h.results[key] = result
+		h.mu.Unlock()
+
+		return result, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		// Checks cut short by the client or the request timeout tell nothing about the ledger, so they aren't cached
+		return types.NetworkReadiness{
+			Method:    method,
+			Namespace: namespace,
+			Required:  required,
+			Error:     ctx.Err().Error(),
+			CheckedAt: time.Now(),
+		}
+	case r := <-probed:
+		return r.Val.(types.NetworkReadiness)
+	}
+}
+
+func (h *HealthChecker) isRequired(namespace string) bool {
+	if len(h.config.RequiredNamespaces) == 0 {
+		return true
+	}
+	for _, required := range h.config.RequiredNamespaces {
+		if required == namespace {
+			return true
+		}
+	}
+	return false
+}
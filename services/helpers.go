@@ -1,28 +1,94 @@
 package services
 
 import (
+	"mime"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/cheqd/did-resolver/types"
 	"github.com/labstack/echo/v4"
 )
 
-func GetContentType(accept string) types.ContentType {
-	// It returns supported ContentType or "" otherwise
-	typeList := strings.Split(accept, ",")
-	for _, cType := range typeList {
-		result := types.ContentType(strings.Split(cType, ";")[0])
-		if result == "*/*" || result == types.JSONLD {
-			return types.DIDJSONLD
+// AcceptedMediaType is the media range of the Accept header the response is made for
+type AcceptedMediaType struct {
+	// Supported content type the media range stands for
+	ContentType types.ContentType
+	// Profiles of the media range, if any
+	Profiles []string
+	// The media range is */* or application/*, which leaves the representation up to the resolver
+	Wildcard bool
+}
+
+// IsDocumentRequested reports whether the client asked for a DID Document media type
+// rather than the DID resolution result
+func (m AcceptedMediaType) IsDocumentRequested() bool {
+	if m.Wildcard {
+		return false
+	}
+	for _, profile := range m.Profiles {
+		if profile == types.DIDResolutionProfile {
+			return false
 		}
-		// Make this place more clearly
-		if result.IsSupported() {
-			return result
+	}
+	return true
+}
+
+// NegotiateContentType picks the supported media range of the Accept header with the highest q-value,
+// or the first listed one among those with the same q-value. Media ranges with q=0 are not acceptable.
+func NegotiateContentType(accept string) (AcceptedMediaType, bool) {
+	type mediaRange struct {
+		AcceptedMediaType
+		q float64
+	}
+
+	var ranges []mediaRange
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
 		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil || q <= 0 || q > 1 {
+				continue
+			}
+		}
+
+		accepted := AcceptedMediaType{Profiles: strings.Fields(params["profile"])}
+		switch mediaType {
+		case "*/*", "application/*":
+			accepted.ContentType = types.DIDJSONLD
+			accepted.Wildcard = true
+		case string(types.JSONLD):
+			accepted.ContentType = types.DIDJSONLD
+		case string(types.CBOR):
+			accepted.ContentType = types.DIDCBOR
+		default:
+			accepted.ContentType = types.ContentType(mediaType)
+		}
+		if accepted.ContentType.IsSupported() {
+			ranges = append(ranges, mediaRange{AcceptedMediaType: accepted, q: q})
+		}
+	}
+	if len(ranges) == 0 {
+		return AcceptedMediaType{}, false
 	}
 
-	return ""
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	return ranges[0].AcceptedMediaType, true
+}
+
+// GetContentType returns the supported content type preferred by the Accept header or "" otherwise
+func GetContentType(accept string) types.ContentType {
+	accepted, _ := NegotiateContentType(accept)
+	return accepted.ContentType
 }
 
 func PrepareQueries(c echo.Context) (rawQuery string, flag *string) {
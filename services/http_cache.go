@@ -0,0 +1,130 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+// cacheValidators identify the ledger data a result was built from: versions of DIDDocs and checksums of resources.
+// The resolution metadata, such as the time of retrieval, is left out, so that the same data gets the same ETag.
+type cacheValidators struct {
+	parts        []string
+	lastModified time.Time
+}
+
+func newCacheValidators(result types.ResolutionResultI) *cacheValidators {
+	v := &cacheValidators{}
+	switch result := result.(type) {
+	case *types.DidResolution:
+		v.addDidDocMetadata(result.Metadata)
+	case *types.DidDereferencing:
+		v.addDidDocMetadata(result.Metadata)
+		v.addContent(result.ContentStream)
+	case *types.ResourceDereferencing:
+		if result.Metadata.Checksum != "" {
+			v.add(result.Metadata.Checksum, result.Metadata.Created)
+		}
+		v.addContent(result.ContentStream)
+	}
+	if len(v.parts) == 0 {
+		return nil
+	}
+
+	return v
+}
+
+func (v *cacheValidators) add(part string, modified *time.Time) {
+	v.parts = append(v.parts, part)
+	if modified != nil && modified.After(v.lastModified) {
+		v.lastModified = *modified
+	}
+}
+
+func (v *cacheValidators) addDidDocMetadata(metadata types.ResolutionDidDocMetadata) {
+	if metadata.VersionId == "" {
+		return
+	}
+
+	modified := metadata.Updated
+	if modified == nil {
+		modified = metadata.Created
+	}
+	// The metadata of a version changes when the next version is created or the DIDDoc is deactivated
+	v.add(metadata.VersionId+"/"+metadata.NextVersionId+"/"+strconv.FormatBool(metadata.Deactivated), modified)
+	v.addResources(metadata.Resources)
+}
+
+func (v *cacheValidators) addResources(resources types.DereferencedResourceList) {
+	for _, resource := range resources {
+		part := resource.ResourceId + "/" + resource.Checksum
+		if resource.NextVersionId != nil {
+			part += "/" + *resource.NextVersionId
+		}
+		v.add(part, resource.Created)
+	}
+}
+
+func (v *cacheValidators) addContent(content types.ContentStreamI) {
+	switch content := content.(type) {
+	case *types.ResolutionDidDocMetadata:
+		v.addDidDocMetadata(*content)
+	case *types.DereferencedDidVersionsList:
+		for _, version := range content.Versions {
+			v.addDidDocMetadata(version)
+		}
+	case *types.DereferencedResourceListStruct:
+		v.addResources(content.Resources)
+	}
+}
+
+// ETag is a strong validator of the data in the given representation, as the same URL has several ones
+func (v *cacheValidators) ETag(representation string) string {
+	hash := sha256.Sum256([]byte(strings.Join(append(v.parts, representation), "\n")))
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// isNotModified evaluates the conditional headers of a GET request, with If-None-Match taking precedence
+// over If-Modified-Since as RFC 9110 requires
+func isNotModified(request *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := request.Header.Values("If-None-Match"); len(ifNoneMatch) > 0 {
+		for _, candidate := range strings.Split(strings.Join(ifNoneMatch, ","), ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ifModifiedSince := request.Header.Get(echo.HeaderIfModifiedSince)
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	return err == nil && !lastModified.Truncate(time.Second).After(since)
+}
+
+// cacheControl allows caching the data pinned by version, resource or block height for long, as it never changes
+// on the ledger, and the latest data for a short while
+func cacheControl(config types.HttpCacheConfig, pinned bool) string {
+	maxAge := config.MaxAge
+	if pinned {
+		maxAge = config.ImmutableMaxAge
+	}
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+
+	value := "public, max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	if pinned {
+		value += ", immutable"
+	}
+	return value
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+var ErrConnectionPoolClosed = errors.New("ledger connection pool is closed")
+
+const (
+	// Receive limit of gRPC clients unless it's set
+	defaultMaxRecvMsgSize = 4 << 20
+	// Room for the metadata sent along with the resource data
+	resourceMetadataAllowance = 64 << 10
+)
+
+// grpcConnectionPool keeps a small set of long-lived client connections to a single ledger endpoint.
+// Connections are dialed lazily on first use and re-dialed if they were shut down.
+type grpcConnectionPool struct {
+	network  types.Network
+	endpoint types.Endpoint
+
+	mu     sync.Mutex
+	conns  []*grpc.ClientConn
+	closed bool
+
+	next uint32
+}
+
+func newGRPCConnectionPool(network types.Network, endpoint types.Endpoint) *grpcConnectionPool {
+	size := network.PoolSize
+	if size < 1 {
+		size = types.DefaultLedgerPoolSize
+	}
+
+	return &grpcConnectionPool{
+		network:  network,
+		endpoint: endpoint,
+		conns:    make([]*grpc.ClientConn, size),
+	}
+}
+
+// Get returns the next connection from the pool in round-robin order
+func (p *grpcConnectionPool) Get() (*grpc.ClientConn, error) {
+	index := int(atomic.AddUint32(&p.next, 1)) % len(p.conns)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrConnectionPoolClosed
+	}
+
+	conn := p.conns[index]
+	if conn != nil && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+
+	conn, err := dialGRPCConnection(p.network, p.endpoint)
+	if err != nil {
+		log.Error().Err(err).Msgf("grpcConnectionPool: failed to dial %s", p.endpoint.Address)
+		return nil, err
+	}
+	p.conns[index] = conn
+
+	return conn, nil
+}
+
+// Close shuts down all the connections. The pool cannot be used afterwards.
+func (p *grpcConnectionPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	var errs []error
+	for i, conn := range p.conns {
+		if conn == nil {
+			continue
+		}
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		p.conns[i] = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// maxRecvMsgSize fits the largest resource accepted by the network, without going below the gRPC default
+func maxRecvMsgSize(network types.Network) int {
+	size := network.ResourceMaxSize + resourceMetadataAllowance
+	if size < defaultMaxRecvMsgSize {
+		return defaultMaxRecvMsgSize
+	}
+	return size
+}
+
+func dialGRPCConnection(network types.Network, endpoint types.Endpoint) (*grpc.ClientConn, error) {
+	keepAlive := network.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = types.DefaultLedgerKeepAlive
+	}
+
+	// Calls are left fail-fast: they wait while the connection is being (re)established,
+	// but fail immediately if the endpoint is known to be unreachable so we can fail over
+	opts := []grpc.DialOption{
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    keepAlive,
+			Timeout: endpoint.Timeout,
+		}),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxRecvMsgSize(network))),
+		// Every call is traced, with the trace context of the request sent to the node
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithUnaryInterceptor(blockHeightInterceptor),
+	}
+
+	if endpoint.UseTls {
+		tlsConfig, err := types.NewTLSConfig(network.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	conn, err := grpc.Dial(endpoint.Address, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("dialGRPCConnection: opened connection to %s", endpoint.Address)
+
+	return conn, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cheqd/did-resolver/types"
+	tmservice "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ledgerEndpointSet sends the queries of a namespace to the gRPC endpoints of its nodes.
+// Health of the endpoints is probed in background.
+type ledgerEndpointSet = endpointSet[*grpc.ClientConn]
+
+func newLedgerEndpointSet(network types.Network) *ledgerEndpointSet {
+	set := newEndpointSet(network, "Ledger endpoint", func(config types.Endpoint) endpointTransport[*grpc.ClientConn] {
+		return &grpcTransport{
+			address: config.Address,
+			pool:    newGRPCConnectionPool(network, config),
+		}
+	})
+
+	if network.HealthCheckInterval > 0 {
+		go set.runHealthChecks()
+	}
+
+	return set
+}
+
+// grpcTransport sends the queries to a node over the connections of the pool
+type grpcTransport struct {
+	address string
+	pool    *grpcConnectionPool
+}
+
+func (t *grpcTransport) Client() (*grpc.ClientConn, error) {
+	return t.pool.Get()
+}
+
+// Probe asks the tendermint service of the node whether it is catching up with the chain
+func (t *grpcTransport) Probe(ctx context.Context) error {
+	conn, err := t.pool.Get()
+	if err != nil {
+		return err
+	}
+
+	response, err := tmservice.NewServiceClient(conn).GetSyncing(ctx, &tmservice.GetSyncingRequest{})
+	// Node answered, but doesn't expose the tendermint service
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		zerolog.Ctx(ctx).Debug().Err(err).Msgf("grpcTransport: %s is unreachable", t.address)
+		return err
+	}
+	if response.Syncing {
+		return errors.New("node is catching up")
+	}
+
+	return nil
+}
+
+func (t *grpcTransport) Close() error {
+	return t.pool.Close()
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cheqd/did-resolver/types"
+	"golang.org/x/sync/errgroup"
+)
+
+// queryLedgerConcurrently runs independent ledger queries at the same time. As soon as one of them fails,
+// the others are cancelled. The error of the first query in the list which failed on its own is returned,
+// so callers get the same error as if the queries were made one after another.
+func queryLedgerConcurrently(ctx context.Context, queries ...func(ctx context.Context) *types.IdentityError) *types.IdentityError {
+	errs := make([]*types.IdentityError, len(queries))
+	group, groupCtx := errgroup.WithContext(ctx)
+	for i, query := range queries {
+		i, query := i, query
+		group.Go(func() error {
+			if err := query(groupCtx); err != nil {
+				errs[i] = err
+				return err
+			}
+			return nil
+		})
+	}
+	if group.Wait() == nil {
+		return nil
+	}
+
+	var canceled *types.IdentityError
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		// Cancelled because another query failed, unless the request itself is done
+		if ctx.Err() == nil && errors.Is(err.Internal, context.Canceled) {
+			canceled = err
+			continue
+		}
+		return err
+	}
+
+	return canceled
+}
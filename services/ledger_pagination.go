@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cheqd/did-resolver/types"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+)
+
+// ErrTooManyPages is returned instead of a truncated list when a paginated query
+// doesn't complete within the configured number of pages
+var ErrTooManyPages = errors.New("too many pages in the ledger response")
+
+// walkPages requests pages one after another until the node reports there are no more of them.
+// Pages are requested from the same node, as next keys are only meaningful to the node which issued them.
+func walkPages(network types.Network, queryPage func(page *queryTypes.PageRequest) (*queryTypes.PageResponse, error)) error {
+	pageSize := network.PageSize
+	if pageSize < 1 {
+		pageSize = types.DefaultLedgerPageSize
+	}
+	maxPages := network.MaxPages
+	if maxPages < 1 {
+		maxPages = types.DefaultLedgerMaxPages
+	}
+
+	var nextKey []byte
+	for pages := 0; pages < maxPages; pages++ {
+		response, err := queryPage(&queryTypes.PageRequest{Key: nextKey, Limit: uint64(pageSize)})
+		if err != nil {
+			return err
+		}
+
+		nextKey = response.GetNextKey()
+		if len(nextKey) == 0 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: more than %d pages of %d items", ErrTooManyPages, maxPages, pageSize)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/cheqd/did-resolver/utils"
+	abci "github.com/cosmos/cosmos-sdk/api/tendermint/abci"
+	tmcrypto "github.com/cosmos/cosmos-sdk/api/tendermint/crypto"
+	ics23 "github.com/cosmos/ics23/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store names and key layout of the cheqd-node modules
+const (
+	didStoreName              = "cheqd"
+	didLatestVersionKeyPrefix = "did-latest:"
+	didVersionKeyPrefix       = "did-version:"
+
+	resourceStoreName         = "resource"
+	resourceMetadataKeyPrefix = "resource-metadata:"
+	resourceDataKeyPrefix     = "resource-data:"
+)
+
+// ABCIQuery of the tendermint service takes and returns messages with the same fields as the ABCI ones
+const abciQueryMethod = "/cosmos.base.tendermint.v1beta1.Service/ABCIQuery"
+
+const (
+	iavlProofOpType   = "ics23:iavl"
+	simpleProofOpType = "ics23:simple"
+)
+
+// trustedRoot is a verified app hash and the height of the state it commits to
+type trustedRoot struct {
+	Height  int64
+	AppHash []byte
+}
+
+// provenStore reads the module stores of a node at the height of the root and verifies
+// every value against the root with the Merkle proofs the node returns along with it
+type provenStore struct {
+	conn *grpc.ClientConn
+	root trustedRoot
+}
+
+// Get returns the value of the key, or nil if the key is proven to be absent
+func (s provenStore) Get(ctx context.Context, storeName string, key string) ([]byte, error) {
+	request := &abci.RequestQuery{
+		Path:   "/store/" + storeName + "/key",
+		Data:   []byte(key),
+		Height: s.root.Height,
+		Prove:  true,
+	}
+	response := &abci.ResponseQuery{}
+	if err := s.conn.Invoke(ctx, abciQueryMethod, request, response); err != nil {
+		return nil, err
+	}
+	if response.Code != 0 {
+		return nil, status.Error(codes.Unknown, response.Log)
+	}
+	if response.Height != s.root.Height {
+		return nil, status.Errorf(codes.DataLoss, "%s was read at height %d instead of %d", key, response.Height, s.root.Height)
+	}
+
+	if err := verifyStoreProof(s.root.AppHash, storeName, []byte(key), response.Value, response.ProofOps); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "proof of %s in store %s at height %d is invalid: %s", key, storeName, s.root.Height, err)
+	}
+	if len(response.Value) == 0 {
+		return nil, nil
+	}
+
+	return response.Value, nil
+}
+
+// verifyStoreProof checks that the key has the value in the store, or is absent if the value is empty,
+// and that the root of the store is committed to by the app hash
+func verifyStoreProof(appHash []byte, storeName string, key []byte, value []byte, proofOps *tmcrypto.ProofOps) error {
+	ops := proofOps.GetOps()
+	if len(ops) != 2 {
+		return fmt.Errorf("expected 2 proof operations, got %d", len(ops))
+	}
+	storeOp, rootOp := ops[0], ops[1]
+	if storeOp.Type_ != iavlProofOpType || !bytes.Equal(storeOp.Key, key) {
+		return fmt.Errorf("first proof operation is not the %s proof of the key", iavlProofOpType)
+	}
+	if rootOp.Type_ != simpleProofOpType || string(rootOp.Key) != storeName {
+		return fmt.Errorf("second proof operation is not the %s proof of the store", simpleProofOpType)
+	}
+
+	storeProof := &ics23.CommitmentProof{}
+	if err := storeProof.Unmarshal(storeOp.Data); err != nil {
+		return fmt.Errorf("store proof can't be decoded: %w", err)
+	}
+	rootProof := &ics23.CommitmentProof{}
+	if err := rootProof.Unmarshal(rootOp.Data); err != nil {
+		return fmt.Errorf("root proof can't be decoded: %w", err)
+	}
+
+	storeRoot, err := storeProof.Calculate()
+	if err != nil {
+		return fmt.Errorf("store root can't be calculated: %w", err)
+	}
+	if len(value) == 0 {
+		if !ics23.VerifyNonMembership(ics23.IavlSpec, storeRoot, storeProof, key) {
+			return fmt.Errorf("key is not proven to be absent")
+		}
+	} else if !ics23.VerifyMembership(ics23.IavlSpec, storeRoot, storeProof, key, value) {
+		return fmt.Errorf("value doesn't match the store root")
+	}
+
+	if !ics23.VerifyMembership(ics23.TendermintSpec, appHash, rootProof, []byte(storeName), storeRoot) {
+		return fmt.Errorf("store root doesn't match the app hash")
+	}
+
+	return nil
+}
+
+// normalizeStoreId returns the unique identifier of a DID the way the ledger stores it
+func normalizeStoreId(id string) string {
+	if utils.IsValidUUID(id) {
+		return utils.NormalizeUUID(id)
+	}
+	return id
+}
+
+func didLatestVersionKey(did string) string {
+	return didLatestVersionKeyPrefix + normalizeStoreDID(did)
+}
+
+func didVersionKey(did string, version string) string {
+	return didVersionKeyPrefix + normalizeStoreDID(did) + DELIMITER + utils.NormalizeUUID(version)
+}
+
+func resourceMetadataKey(collectionId string, resourceId string) string {
+	return resourceMetadataKeyPrefix + normalizeStoreId(collectionId) + DELIMITER + utils.NormalizeUUID(resourceId)
+}
+
+func resourceDataKey(collectionId string, resourceId string) string {
+	return resourceDataKeyPrefix + normalizeStoreId(collectionId) + DELIMITER + utils.NormalizeUUID(resourceId)
+}
+
+func normalizeStoreDID(did string) string {
+	method, namespace, id, err := utils.TrySplitDID(did)
+	if err != nil {
+		return did
+	}
+	return utils.JoinDID(method, namespace, normalizeStoreId(id))
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// quorumAnswer is what a single endpoint answered to a query sent to the quorum
+type quorumAnswer struct {
+	address  string
+	response proto.Message
+	err      error
+	// Answers with the same key are the same: either equal responses or errors with the same code
+	key string
+}
+
+// Query runs the query like Call does and returns its response. If the network has a quorum, the query is sent
+// to all the endpoints instead, and the response is returned only if at least the quorum of them agree on it.
+func (s *endpointSet[C]) Query(ctx context.Context, description string, query func(ctx context.Context, client C) (proto.Message, error)) (proto.Message, error) {
+	var response proto.Message
+	if s.network.Quorum > 1 {
+		err := retryLedgerCall(ctx, s.network.Retry, description, func() (err error) {
+			response, err = s.queryQuorum(ctx, description, query)
+			return err
+		})
+		return response, err
+	}
+
+	err := s.Call(ctx, description, func(ctx context.Context, client C) (err error) {
+		response, err = query(ctx, client)
+		return err
+	})
+
+	return response, err
+}
+
+// queryQuorum sends the query to all the endpoints at once and waits for every one of them to answer,
+// so that the endpoints which diverge from the others are logged even when the quorum agrees.
+// Endpoints which are unavailable or can't serve the requested height don't count as answers.
+func (s *endpointSet[C]) queryQuorum(ctx context.Context, description string, query func(ctx context.Context, client C) (proto.Message, error)) (proto.Message, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		lastErr  error
+		poolDone bool
+	)
+	// Indexed like the endpoints, so the answers are grouped and logged in the configured order
+	answered := make([]*quorumAnswer, len(s.endpoints))
+	for i, endpoint := range s.endpoints {
+		if !endpoint.breaker.Allow() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, endpoint *ledgerEndpoint[C]) {
+			defer wg.Done()
+
+			client, err := endpoint.transport.Client()
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if errors.Is(err, ErrConnectionPoolClosed) {
+					poolDone = true
+				} else {
+					endpoint.setHealthy(false)
+					endpoint.breaker.Failure()
+				}
+				lastErr = err
+				return
+			}
+
+			zerolog.Ctx(ctx).Info().Msgf("%s via %s", description, endpoint.config.Address)
+
+			queryCtx, cancel := context.WithTimeout(ctx, endpoint.config.Timeout)
+			response, err := query(queryCtx, client)
+			cancel()
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil && ctx.Err() != nil:
+				lastErr = ctx.Err()
+			// Nodes may prune different heights, the ones which still have the requested state decide
+			case isBlockHeightNotAvailable(err):
+				endpoint.setHealthy(true)
+				endpoint.breaker.Success()
+				lastErr = err
+			case err != nil && isFailoverError(err):
+				zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s via %s failed", description, endpoint.config.Address)
+				endpoint.setHealthy(false)
+				endpoint.breaker.Failure()
+				lastErr = err
+			default:
+				endpoint.setHealthy(true)
+				endpoint.breaker.Success()
+				answered[i] = &quorumAnswer{address: endpoint.config.Address, response: response, err: err, key: answerKey(response, err)}
+			}
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if poolDone {
+		return nil, ErrConnectionPoolClosed
+	}
+
+	var answers []*quorumAnswer
+	groups := make(map[string][]*quorumAnswer)
+	var keys []string
+	for _, answer := range answered {
+		if answer == nil {
+			continue
+		}
+		answers = append(answers, answer)
+		if _, ok := groups[answer.key]; !ok {
+			keys = append(keys, answer.key)
+		}
+		groups[answer.key] = append(groups[answer.key], answer)
+	}
+
+	// The largest group decides, unless another one is as large
+	var agreed []*quorumAnswer
+	tie := false
+	for _, key := range keys {
+		switch {
+		case len(groups[key]) > len(agreed):
+			agreed, tie = groups[key], false
+		case len(groups[key]) == len(agreed):
+			tie = true
+		}
+	}
+
+	if len(agreed) >= s.network.Quorum && !tie {
+		for _, answer := range answers {
+			if answer.key != agreed[0].key {
+				zerolog.Ctx(ctx).Warn().Msgf("%s via %s diverges from %d other endpoints: %s", description, answer.address, len(agreed), answer.key)
+			}
+		}
+		return agreed[0].response, agreed[0].err
+	}
+
+	if len(groups) > 1 {
+		summary := make([]string, 0, len(answers))
+		for _, answer := range answers {
+			summary = append(summary, answer.address+": "+answer.key)
+		}
+		zerolog.Ctx(ctx).Error().Msgf("%s: endpoints don't agree (%s)", description, strings.Join(summary, ", "))
+		return nil, status.Errorf(codes.Aborted, "%d of %d endpoints agree, %d needed", len(agreed), len(s.endpoints), s.network.Quorum)
+	}
+
+	// Too few endpoints answered, the failure of the others tells why
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	if len(answers) == 0 {
+		zerolog.Ctx(ctx).Warn().Msgf("%s: %s", description, errCircuitOpen)
+		return nil, errCircuitOpen
+	}
+	zerolog.Ctx(ctx).Warn().Msgf("%s: only %d endpoints were queried, %d needed", description, len(answers), s.network.Quorum)
+	return nil, status.Errorf(codes.Unavailable, "%d of %d endpoints answered, %d needed", len(answers), len(s.endpoints), s.network.Quorum)
+}
+
+// answerKey identifies a response by the hash of its deterministic encoding and an error by its code,
+// as the messages of the same errors may differ between nodes
+func answerKey(response proto.Message, err error) string {
+	if err != nil {
+		return status.Code(err).String()
+	}
+
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(response)
+	if err != nil {
+		return "unencodable response: " + err.Error()
+	}
+	hash := sha256.Sum256(encoded)
+
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
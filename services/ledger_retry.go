@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/status"
+)
+
+// retryLedgerCall runs the call again after a backoff while it fails with one of the retryable codes
+// of the policy. The last error is returned once the attempts are used up or the deadline of ctx
+// doesn't leave time for another attempt.
+func retryLedgerCall(ctx context.Context, policy types.RetryPolicy, description string, call func() error) error {
+	err := call()
+	for attempt := 1; attempt < policy.MaxAttempts && isRetryableError(err, policy); attempt++ {
+		delay := retryBackoff(policy, attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return err
+		}
+
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s failed, retrying in %s (attempt %d of %d)", description, delay, attempt+1, policy.MaxAttempts)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		err = call()
+	}
+
+	return err
+}
+
+func isRetryableError(err error, policy types.RetryPolicy) bool {
+	// There is no point in retrying before the breakers let queries through again
+	if err == nil || errors.Is(err, errCircuitOpen) {
+		return false
+	}
+	// The answer would be just as large next time
+	if isMessageTooLarge(err) {
+		return false
+	}
+
+	code := status.Code(err)
+	for _, retryableCode := range policy.RetryableCodes {
+		if code == retryableCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryBackoff doubles the initial backoff for every attempt up to the max backoff
+// and takes up to a half of it off at random, so the clients don't retry in lockstep
+func retryBackoff(policy types.RetryPolicy, attempt int) time.Duration {
+	delay := policy.InitialBackoff
+	for i := 1; i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services/metrics"
+	"github.com/cheqd/did-resolver/services/tracing"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/cheqd/did-resolver/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// LedgerRouter passes every query to the ledger service registered for the namespace of the DID,
+// so each network can be queried through a different API. The queries of every namespace are measured and traced here.
+type LedgerRouter struct {
+	ledgers map[string]LedgerServiceI // method:namespace -> ledger service
+}
+
+func NewLedgerRouter() LedgerRouter {
+	return LedgerRouter{ledgers: make(map[string]LedgerServiceI)}
+}
+
+// Register routes the queries of the namespace to the ledger service
+func (lr LedgerRouter) Register(method string, namespace string, ledgerService LedgerServiceI) {
+	lr.ledgers[method+DELIMITER+namespace] = ledgerService
+}
+
+func (lr LedgerRouter) route(did string) (LedgerServiceI, string, bool) {
+	method, namespace, _, _ := utils.TrySplitDID(did)
+	ledgerService, ok := lr.ledgers[method+DELIMITER+namespace]
+	return ledgerService, namespace, ok
+}
+
+// startQuery starts the span of a query of the namespace of a registered ledger service.
+// The returned function ends it and records the outcome and the duration of the query.
+func startQuery(ctx context.Context, namespace string, query string) (context.Context, func(err *types.IdentityError)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, query, attribute.String("ledger.namespace", namespace))
+
+	return ctx, func(err *types.IdentityError) {
+		outcome := metrics.Success
+		if err != nil {
+			outcome = err.Message
+			tracing.End(span, err)
+		} else {
+			tracing.End(span, nil)
+		}
+		metrics.ObserveLedgerQuery(namespace, query, outcome, time.Since(start))
+	}
+}
+
+func (lr LedgerRouter) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	ledgerService, namespace, ok := lr.route(did)
+	if !ok {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
+	}
+	ctx, end := startQuery(ctx, namespace, "QueryDIDDoc")
+	didDoc, err := ledgerService.QueryDIDDoc(ctx, did, version)
+	end(err)
+	return didDoc, err
+}
+
+func (lr LedgerRouter) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
+	ledgerService, namespace, ok := lr.route(did)
+	if !ok {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
+	}
+	ctx, end := startQuery(ctx, namespace, "QueryAllDidDocVersionsMetadata")
+	versions, err := ledgerService.QueryAllDidDocVersionsMetadata(ctx, did)
+	end(err)
+	return versions, err
+}
+
+func (lr LedgerRouter) QueryResource(ctx context.Context, collectionDid string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+	ledgerService, namespace, ok := lr.route(collectionDid)
+	if !ok {
+		return nil, types.NewInvalidDidError(collectionDid, types.JSON, nil, true)
+	}
+	ctx, end := startQuery(ctx, namespace, "QueryResource")
+	resource, err := ledgerService.QueryResource(ctx, collectionDid, resourceId)
+	end(err)
+	return resource, err
+}
+
+func (lr LedgerRouter) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	ledgerService, namespace, ok := lr.route(did)
+	if !ok {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
+	}
+	ctx, end := startQuery(ctx, namespace, "QueryCollectionResources")
+	resources, err := ledgerService.QueryCollectionResources(ctx, did)
+	end(err)
+	return resources, err
+}
+
+// IsProven reports whether the ledger service of the namespace of the DID verifies its data with Merkle proofs
+func (lr LedgerRouter) IsProven(did string) bool {
+	ledgerService, _, ok := lr.route(did)
+	return ok && isLedgerProven(ledgerService, did)
+}
+
+// PinTrustedRoot pins the trusted root of the ledger service of the namespace of the DID, if it verifies its data
+func (lr LedgerRouter) PinTrustedRoot(ctx context.Context, did string) (context.Context, *types.IdentityError) {
+	ledgerService, _, ok := lr.route(did)
+	if !ok {
+		return ctx, nil
+	}
+
+	return pinTrustedRoot(ctx, ledgerService, did)
+}
+
+// Status reports the state of the nodes of every registered namespace, ordered by method and namespace
+func (lr LedgerRouter) Status() types.ResolverStatus {
+	keys := lr.sortedKeys()
+
+	status := types.ResolverStatus{Networks: make([]types.NetworkStatus, 0, len(keys))}
+	for _, key := range keys {
+		method, namespace, _ := strings.Cut(key, DELIMITER)
+		networkStatus := types.NetworkStatus{Method: method, Namespace: namespace, Endpoints: []types.EndpointStatus{}}
+		if reporter, ok := lr.ledgers[key].(LedgerStatusReporter); ok {
+			if reported, ok := reporter.NetworkStatus(method, namespace); ok {
+				networkStatus = reported
+			}
+		}
+		status.Networks = append(status.Networks, networkStatus)
+	}
+
+	return status
+}
+
+// Probe checks whether the ledger service of the namespace can answer queries.
+// Ledger services which don't depend on remote nodes are always ready.
+func (lr LedgerRouter) Probe(ctx context.Context, method string, namespace string) error {
+	ledgerService, ok := lr.ledgers[method+DELIMITER+namespace]
+	if !ok {
+		return fmt.Errorf("namespace %s is not registered", namespace)
+	}
+	if prober, ok := ledgerService.(LedgerProber); ok {
+		return prober.Probe(ctx, method, namespace)
+	}
+	return nil
+}
+
+// sortedKeys returns the method:namespace keys of the registered ledger services in order
+func (lr LedgerRouter) sortedKeys() []string {
+	keys := make([]string, 0, len(lr.ledgers))
+	for key := range lr.ledgers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (lr LedgerRouter) GetNamespaces() []string {
+	namespaces := make([]string, 0, len(lr.ledgers))
+	for key := range lr.ledgers {
+		_, namespace, _ := strings.Cut(key, DELIMITER)
+		namespaces = append(namespaces, namespace)
+	}
+
+	return namespaces
+}
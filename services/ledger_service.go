@@ -2,19 +2,21 @@ package services
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
+	"fmt"
 	"strings"
 
-	"google.golang.org/grpc/credentials"
-
 	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
 	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
 	"github.com/cheqd/did-resolver/types"
 	"github.com/cheqd/did-resolver/utils"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -22,133 +24,284 @@ const (
 )
 
 type LedgerServiceI interface {
-	QueryDIDDoc(did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError)
-	QueryAllDidDocVersionsMetadata(did string) ([]*didTypes.Metadata, *types.IdentityError)
-	QueryResource(collectionDid string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError)
-	QueryCollectionResources(did string) ([]*resourceTypes.Metadata, *types.IdentityError)
+	QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError)
+	QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError)
+	QueryResource(ctx context.Context, collectionDid string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError)
+	QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError)
 	GetNamespaces() []string
 }
 
+// LedgerProofReporter is implemented by the ledger services which may verify the ledger data with Merkle proofs
+type LedgerProofReporter interface {
+	IsProven(did string) bool
+}
+
+// isLedgerProven reports whether the ledger data of the DID is verified with Merkle proofs
+func isLedgerProven(ledgerService LedgerServiceI, did string) bool {
+	reporter, ok := ledgerService.(LedgerProofReporter)
+	return ok && reporter.IsProven(did)
+}
+
+// LedgerRootPinner is implemented by the ledger services which verify the ledger data against a trusted root
+type LedgerRootPinner interface {
+	// PinTrustedRoot returns a context which makes the queries of the DID read the state of a single trusted root
+	PinTrustedRoot(ctx context.Context, did string) (context.Context, *types.IdentityError)
+}
+
+// pinTrustedRoot makes the queries of the DID with the returned context verify their data against the same state,
+// so that the results of several queries are consistent. The context is returned as it is if the data isn't verified.
+func pinTrustedRoot(ctx context.Context, ledgerService LedgerServiceI, did string) (context.Context, *types.IdentityError) {
+	pinner, ok := ledgerService.(LedgerRootPinner)
+	if !ok {
+		return ctx, nil
+	}
+
+	return pinner.PinTrustedRoot(ctx, did)
+}
+
+// LedgerStatusReporter is implemented by the ledger services which can tell the state of the nodes of a namespace
+type LedgerStatusReporter interface {
+	NetworkStatus(method string, namespace string) (types.NetworkStatus, bool)
+}
+
+// LedgerProber is implemented by the ledger services which can check whether the nodes of a namespace can answer queries
+type LedgerProber interface {
+	Probe(ctx context.Context, method string, namespace string) error
+}
+
 type LedgerService struct {
-	ledgers map[string]types.Network // namespace -> endpoint with configs
+	ledgers   map[string]types.Network      // namespace -> endpoints with configs
+	endpoints map[string]*ledgerEndpointSet // namespace -> shared connections to the endpoints
+	// namespace -> light client verifying the ledger data, for the namespaces with verification
+	lightClients map[string]*lightClient
 }
 
 func NewLedgerService() LedgerService {
 	ls := LedgerService{}
 	ls.ledgers = make(map[string]types.Network)
+	ls.endpoints = make(map[string]*ledgerEndpointSet)
+	ls.lightClients = make(map[string]*lightClient)
 
 	return ls
 }
 
-func (ls LedgerService) QueryDIDDoc(did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+func (ls LedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
 	method, namespace, _, _ := utils.TrySplitDID(did)
-	serverAddr, namespaceFound := ls.ledgers[method+DELIMITER+namespace]
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
 	if !namespaceFound {
 		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
 	}
 
-	conn, err := ls.openGRPCConnection(serverAddr)
-	if err != nil {
-		log.Error().Err(err).Msg("QueryDIDDoc: failed connection")
-		return nil, types.NewInternalError(did, types.JSON, err, false)
+	if lightClient, ok := ls.lightClients[method+DELIMITER+namespace]; ok {
+		didDoc, err := queryProvenDIDDoc(ctx, lightClient, did, version)
+		if err != nil {
+			return nil, newLedgerError(ctx, "QueryDIDDoc", did, err, false)
+		}
+		return didDoc, nil
 	}
 
-	defer mustCloseGRPCConnection(conn)
-
-	log.Info().Msgf("Querying DIDDoc: %s", did)
-	client := didTypes.NewQueryClient(conn)
+	response, err := endpoints.Query(ctx, "Querying DIDDoc: "+did, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		client := didTypes.NewQueryClient(conn)
 
-	if version == "" {
-		didDocResponse, err := client.DidDoc(context.Background(), &didTypes.QueryDidDocRequest{Id: did})
-		if err != nil {
-			return nil, types.NewNotFoundError(did, types.JSON, err, false)
+		if version == "" {
+			didDocResponse, err := client.DidDoc(ctx, &didTypes.QueryDidDocRequest{Id: did})
+			if err != nil {
+				return nil, err
+			}
+			return didDocResponse.Value, nil
 		}
 
-		return didDocResponse.Value, nil
-	} else {
-		didDocResponse, err := client.DidDocVersion(context.Background(), &didTypes.QueryDidDocVersionRequest{Id: did, Version: version})
+		didDocResponse, err := client.DidDocVersion(ctx, &didTypes.QueryDidDocVersionRequest{Id: did, Version: version})
 		if err != nil {
-			return nil, types.NewNotFoundError(did, types.JSON, err, false)
+			return nil, err
 		}
-
 		return didDocResponse.Value, nil
+	})
+	if err != nil {
+		return nil, newLedgerError(ctx, "QueryDIDDoc", did, err, false)
 	}
+
+	return response.(*didTypes.DidDocWithMetadata), nil
 }
 
-func (ls LedgerService) QueryAllDidDocVersionsMetadata(did string) ([]*didTypes.Metadata, *types.IdentityError) {
+func (ls LedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
 	method, namespace, _, _ := utils.TrySplitDID(did)
-	serverAddr, namespaceFound := ls.ledgers[method+DELIMITER+namespace]
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
 	if !namespaceFound {
 		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
 	}
 
-	conn, err := ls.openGRPCConnection(serverAddr)
-	if err != nil {
-		log.Error().Err(err).Msg("QueryAllDidDocVersionsMetadata: failed connection")
-		return nil, types.NewInternalError(did, types.JSON, err, false)
+	if lightClient, ok := ls.lightClients[method+DELIMITER+namespace]; ok {
+		versions, err := queryProvenAllDidDocVersionsMetadata(ctx, lightClient, did)
+		if err != nil {
+			return nil, newLedgerError(ctx, "QueryAllDidDocVersionsMetadata", did, err, false)
+		}
+		return versions, nil
 	}
-	defer mustCloseGRPCConnection(conn)
 
-	log.Info().Msgf("Querying all DIDDoc versions metadata: %s", did)
-	client := didTypes.NewQueryClient(conn)
+	// The pages are collected into a single response
+	response, err := endpoints.Query(ctx, "Querying all DIDDoc versions metadata: "+did, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		client := didTypes.NewQueryClient(conn)
 
-	response, err := client.AllDidDocVersionsMetadata(context.Background(), &didTypes.QueryAllDidDocVersionsMetadataRequest{Id: did})
+		versions := &didTypes.QueryAllDidDocVersionsMetadataResponse{}
+		err := walkPages(endpoints.network, func(page *queryTypes.PageRequest) (*queryTypes.PageResponse, error) {
+			response, err := client.AllDidDocVersionsMetadata(ctx, &didTypes.QueryAllDidDocVersionsMetadataRequest{Id: did, Pagination: page})
+			if err != nil {
+				return nil, err
+			}
+			versions.Versions = append(versions.Versions, response.Versions...)
+
+			return response.Pagination, nil
+		})
+
+		return versions, err
+	})
 	if err != nil {
-		return nil, types.NewNotFoundError(did, types.JSON, err, false)
+		return nil, newLedgerError(ctx, "QueryAllDidDocVersionsMetadata", did, err, false)
 	}
 
-	return response.Versions, nil
+	return response.(*didTypes.QueryAllDidDocVersionsMetadataResponse).Versions, nil
 }
 
-func (ls LedgerService) QueryResource(did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+func (ls LedgerService) QueryResource(ctx context.Context, did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
 	method, namespace, collectionId, _ := utils.TrySplitDID(did)
-	serverAddr, namespaceFound := ls.ledgers[method+DELIMITER+namespace]
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
 	if !namespaceFound {
 		return nil, types.NewInvalidDidError(did, types.JSON, nil, true)
 	}
 
-	conn, err := ls.openGRPCConnection(serverAddr)
-	if err != nil {
-		log.Error().Err(err).Msg("QueryResource: failed connection")
-		return nil, types.NewInternalError(did, types.JSON, err, true)
+	if lightClient, ok := ls.lightClients[method+DELIMITER+namespace]; ok {
+		resource, err := queryProvenResource(ctx, lightClient, collectionId, resourceId)
+		if err != nil {
+			return nil, newResourceLedgerError(ctx, did, err)
+		}
+		return resource, nil
 	}
 
-	defer mustCloseGRPCConnection(conn)
+	response, err := endpoints.Query(ctx, "Querying DID resource: "+collectionId+", "+resourceId, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		client := resourceTypes.NewQueryClient(conn)
 
-	log.Info().Msgf("Querying DID resource: %s, %s", collectionId, resourceId)
-
-	client := resourceTypes.NewQueryClient(conn)
-	resourceResponse, err := client.Resource(context.Background(), &resourceTypes.QueryResourceRequest{CollectionId: collectionId, Id: resourceId})
+		resourceResponse, err := client.Resource(ctx, &resourceTypes.QueryResourceRequest{CollectionId: collectionId, Id: resourceId})
+		if err != nil {
+			return nil, err
+		}
+		return resourceResponse.Resource, nil
+	})
 	if err != nil {
-		log.Info().Msgf("Resource not found %s", err.Error())
-		return nil, types.NewNotFoundError(did, types.JSON, err, true)
+		return nil, newResourceLedgerError(ctx, did, err)
 	}
 
-	return resourceResponse.Resource, nil
+	return response.(*resourceTypes.ResourceWithMetadata), nil
 }
 
-func (ls LedgerService) QueryCollectionResources(did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+func (ls LedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
 	method, namespace, collectionId, _ := utils.TrySplitDID(did)
-	serverAddr, namespaceFound := ls.ledgers[method+DELIMITER+namespace]
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
 	if !namespaceFound {
 		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
 	}
 
-	conn, err := ls.openGRPCConnection(serverAddr)
-	if err != nil {
-		log.Error().Err(err).Msg("QueryResource: failed connection")
-		return nil, types.NewInternalError(did, types.JSON, err, false)
+	if lightClient, ok := ls.lightClients[method+DELIMITER+namespace]; ok {
+		resources, err := queryProvenCollectionResources(ctx, lightClient, collectionId)
+		if err != nil {
+			return nil, newLedgerError(ctx, "QueryCollectionResources", did, err, false)
+		}
+		return resources, nil
 	}
 
-	log.Info().Msgf("Querying DID resources: %s", did)
+	// The pages are collected into a single response
+	response, err := endpoints.Query(ctx, "Querying DID resources: "+did, func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		client := resourceTypes.NewQueryClient(conn)
+
+		resources := &resourceTypes.QueryCollectionResourcesResponse{}
+		err := walkPages(endpoints.network, func(page *queryTypes.PageRequest) (*queryTypes.PageResponse, error) {
+			resourceResponse, err := client.CollectionResources(ctx, &resourceTypes.QueryCollectionResourcesRequest{CollectionId: collectionId, Pagination: page})
+			if err != nil {
+				return nil, err
+			}
+			resources.Resources = append(resources.Resources, resourceResponse.Resources...)
 
-	client := resourceTypes.NewQueryClient(conn)
-	resourceResponse, err := client.CollectionResources(context.Background(), &resourceTypes.QueryCollectionResourcesRequest{CollectionId: collectionId})
+			return resourceResponse.Pagination, nil
+		})
+
+		return resources, err
+	})
 	if err != nil {
-		return nil, types.NewNotFoundError(did, types.JSON, err, false)
+		return nil, newLedgerError(ctx, "QueryCollectionResources", did, err, false)
 	}
 
-	return resourceResponse.Resources, nil
+	return response.(*resourceTypes.QueryCollectionResourcesResponse).Resources, nil
+}
+
+// newLedgerError classifies the error of a ledger query, so clients can tell
+// a DID which doesn't exist from a ledger which can't be reached at the moment.
+// The error is logged with the logger of the request in the context.
+func newLedgerError(ctx context.Context, query string, did string, err error, isDereferencing bool) *types.IdentityError {
+	st, ok := status.FromError(err)
+	if !ok {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s: request timed out", query)
+			return types.NewLedgerTimeoutError(did, types.JSON, err, isDereferencing)
+		case errors.Is(err, ErrTooManyPages):
+			zerolog.Ctx(ctx).Error().Err(err).Msgf("%s: %s has too many entries", query, did)
+			return types.NewInternalError(did, types.JSON, err, isDereferencing)
+		case errors.Is(err, context.Canceled):
+			zerolog.Ctx(ctx).Info().Msgf("%s: request canceled", query)
+			return types.NewInternalError(did, types.JSON, err, isDereferencing)
+		default:
+			zerolog.Ctx(ctx).Error().Err(err).Msgf("%s: failed connection", query)
+			return types.NewTemporarilyUnavailableError(did, types.JSON, err, isDereferencing)
+		}
+	}
+
+	switch {
+	case isBlockHeightNotAvailable(err):
+		zerolog.Ctx(ctx).Info().Msgf("%s: requested block height is not available: %s", query, st.Message())
+		return types.NewBlockHeightNotAvailableError(did, types.JSON, err, isDereferencing)
+	case isNotFoundStatus(st):
+		zerolog.Ctx(ctx).Info().Msgf("%s: %s not found: %s", query, did, st.Message())
+		return types.NewNotFoundError(did, types.JSON, err, isDereferencing)
+	case st.Code() == codes.InvalidArgument:
+		zerolog.Ctx(ctx).Info().Msgf("%s: %s rejected by the ledger: %s", query, did, st.Message())
+		if isDereferencing {
+			return types.NewInvalidDidUrlError(did, types.JSON, err, isDereferencing)
+		}
+		return types.NewInvalidDidError(did, types.JSON, err, isDereferencing)
+	case isMessageTooLarge(err):
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("%s: answer for %s is larger than the receive limit", query, did)
+		return types.NewInternalError(did, types.JSON, err, isDereferencing)
+	case st.Code() == codes.DeadlineExceeded:
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s: ledger timed out", query)
+		return types.NewLedgerTimeoutError(did, types.JSON, err, isDereferencing)
+	case st.Code() == codes.Unavailable, st.Code() == codes.ResourceExhausted, st.Code() == codes.Aborted:
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("%s: ledger unavailable", query)
+		return types.NewTemporarilyUnavailableError(did, types.JSON, err, isDereferencing)
+	default:
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("%s: ledger failed", query)
+		return types.NewInternalError(did, types.JSON, err, isDereferencing)
+	}
+}
+
+// newResourceLedgerError classifies the error of a resource query. Only resource data is expected
+// to exceed the receive limit, which is sized to fit the maximum resource size.
+func newResourceLedgerError(ctx context.Context, did string, err error) *types.IdentityError {
+	if isMessageTooLarge(err) {
+		zerolog.Ctx(ctx).Info().Msgf("QueryResource: resource of %s is too large: %s", did, status.Convert(err).Message())
+		return types.NewResourceTooLargeError(did, types.JSON, err, true)
+	}
+
+	return newLedgerError(ctx, "QueryResource", did, err, true)
+}
+
+// isNotFoundStatus reports whether the node says the requested entity doesn't exist.
+// Cosmos SDK module errors registered without a gRPC code reach clients as codes.Unknown,
+// so those are recognized by the message.
+func isNotFoundStatus(st *status.Status) bool {
+	if st.Code() == codes.NotFound {
+		return true
+	}
+	return st.Code() == codes.Unknown && strings.Contains(strings.ToLower(st.Message()), "not found")
 }
 
 func (ls *LedgerService) RegisterLedger(method string, endpoint types.Network) error {
@@ -158,50 +311,97 @@ func (ls *LedgerService) RegisterLedger(method string, endpoint types.Network) e
 		return err
 	}
 
-	if endpoint.Endpoint == "" {
+	if len(endpoint.Endpoints) == 0 {
 		return errors.New("ledger node URL cannot be empty")
 	}
+	for _, e := range endpoint.Endpoints {
+		if e.Address == "" {
+			return errors.New("ledger node URL cannot be empty")
+		}
+	}
 
-	ls.ledgers[method+DELIMITER+endpoint.Namespace] = endpoint
+	// Certificates are loaded again on every dial, so fail early if they can't be loaded at all
+	if _, err := types.NewTLSConfig(endpoint.TLS); err != nil {
+		return fmt.Errorf("TLS options for %s are invalid: %w", endpoint.Namespace, err)
+	}
+
+	key := method + DELIMITER + endpoint.Namespace
+	if endpoints, ok := ls.endpoints[key]; ok {
+		_ = endpoints.Close()
+	}
+
+	endpoints := newLedgerEndpointSet(endpoint)
+	ls.ledgers[key] = endpoint
+	ls.endpoints[key] = endpoints
+	if endpoint.Verification != nil {
+		ls.lightClients[key] = newLightClient(*endpoint.Verification, endpoints)
+	} else {
+		delete(ls.lightClients, key)
+	}
 
 	return nil
 }
 
-func (ls LedgerService) openGRPCConnection(endpoint types.Network) (conn *grpc.ClientConn, err error) {
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
+// Close stops health checks and shuts down all the pooled ledger connections
+func (ls LedgerService) Close() error {
+	var errs []error
+	for key, endpoints := range ls.endpoints {
+		if err := endpoints.Close(); err != nil {
+			log.Error().Err(err).Msgf("Close: failed to close connections for %s", key)
+			errs = append(errs, err)
+		}
 	}
 
-	if endpoint.UseTls {
-		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
-	} else {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return errors.Join(errs...)
+}
+
+func (ls LedgerService) NetworkStatus(method string, namespace string) (types.NetworkStatus, bool) {
+	key := method + DELIMITER + namespace
+	endpoints, ok := ls.endpoints[key]
+	if !ok {
+		return types.NetworkStatus{}, false
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), endpoint.Timeout)
-	defer cancel()
+	return types.NetworkStatus{
+		Method:    method,
+		Namespace: namespace,
+		Api:       types.GRPCApi,
+		Quorum:    endpoints.network.Quorum,
+		Endpoints: endpoints.Status(),
+	}, true
+}
 
-	conn, err = grpc.DialContext(ctx, endpoint.Endpoint, opts...)
-	if err != nil {
-		log.Error().Err(err).Msgf("openGRPCConnection: context failed")
-		return nil, err
+// Probe checks the endpoints of the namespace, it fails unless enough of them are healthy to answer queries
+func (ls LedgerService) Probe(ctx context.Context, method string, namespace string) error {
+	endpoints, ok := ls.endpoints[method+DELIMITER+namespace]
+	if !ok {
+		return fmt.Errorf("namespace %s is not registered", namespace)
 	}
 
-	log.Info().Msg("openGRPCConnection: opened")
+	return endpoints.Probe(ctx)
+}
 
-	return conn, nil
+// IsProven reports whether the data of the namespace of the DID is verified with Merkle proofs
+func (ls LedgerService) IsProven(did string) bool {
+	method, namespace, _, _ := utils.TrySplitDID(did)
+	_, ok := ls.lightClients[method+DELIMITER+namespace]
+	return ok
 }
 
-func mustCloseGRPCConnection(conn *grpc.ClientConn) {
-	if conn == nil {
-		return
+// PinTrustedRoot selects the block height of the latest trusted root, unless a block height is requested already
+func (ls LedgerService) PinTrustedRoot(ctx context.Context, did string) (context.Context, *types.IdentityError) {
+	method, namespace, _, _ := utils.TrySplitDID(did)
+	lightClient, ok := ls.lightClients[method+DELIMITER+namespace]
+	if _, pinned := BlockHeightFromContext(ctx); pinned || !ok {
+		return ctx, nil
 	}
-	err := conn.Close()
+
+	root, err := lightClient.TrustedRoot(ctx)
 	if err != nil {
-		log.Panic().Err(err).Msg("QueryDIDDoc: failed to close connection")
-		panic(err)
+		return nil, newLedgerError(ctx, "PinTrustedRoot", did, err, false)
 	}
+
+	return WithBlockHeight(ctx, root.Height), nil
 }
 
 func (ls LedgerService) GetNamespaces() []string {
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/cheqd/did-resolver/utils"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Queries of the namespaces with verification read the module stores directly, as module queries return no proofs
+
+func queryProvenDIDDoc(ctx context.Context, lightClient *lightClient, did string, version string) (*didTypes.DidDocWithMetadata, error) {
+	var didDoc *didTypes.DidDocWithMetadata
+	err := lightClient.Read(ctx, "Querying proven DIDDoc: "+did, func(ctx context.Context, store provenStore) (err error) {
+		didDoc, err = readProvenDIDDoc(ctx, store, did, version)
+		return err
+	})
+
+	return didDoc, err
+}
+
+// Versions are found by following the previous version IDs from the latest one
+func queryProvenAllDidDocVersionsMetadata(ctx context.Context, lightClient *lightClient, did string) ([]*didTypes.Metadata, error) {
+	maxVersions := lightClient.endpoints.network.PageSize * lightClient.endpoints.network.MaxPages
+	if maxVersions < 1 {
+		maxVersions = types.DefaultLedgerPageSize * types.DefaultLedgerMaxPages
+	}
+
+	var versions []*didTypes.Metadata
+	err := lightClient.Read(ctx, "Querying all proven DIDDoc versions metadata: "+did, func(ctx context.Context, store provenStore) error {
+		// Start over if the previous endpoint failed in the middle of the chain
+		versions = nil
+		didDoc, err := readProvenDIDDoc(ctx, store, did, "")
+		for err == nil {
+			versions = append(versions, didDoc.Metadata)
+			previous := didDoc.Metadata.GetPreviousVersionId()
+			if previous == "" {
+				return nil
+			}
+			if len(versions) >= maxVersions {
+				return fmt.Errorf("%w: more than %d versions", ErrTooManyPages, maxVersions)
+			}
+			didDoc, err = readProvenDIDDoc(ctx, store, did, previous)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// In the order of the version keys, as the module query returns them
+	sort.SliceStable(versions, func(i, j int) bool {
+		return utils.NormalizeUUID(versions[i].VersionId) < utils.NormalizeUUID(versions[j].VersionId)
+	})
+
+	return versions, nil
+}
+
+func queryProvenResource(ctx context.Context, lightClient *lightClient, collectionId string, resourceId string) (*resourceTypes.ResourceWithMetadata, error) {
+	var resource *resourceTypes.ResourceWithMetadata
+	err := lightClient.Read(ctx, "Querying proven DID resource: "+collectionId+", "+resourceId, func(ctx context.Context, store provenStore) error {
+		resourceMetadata, err := readProvenResourceMetadata(ctx, store, collectionId, resourceId)
+		if err != nil {
+			return err
+		}
+		data, err := store.Get(ctx, resourceStoreName, resourceDataKey(collectionId, resourceId))
+		if err != nil {
+			return err
+		}
+
+		resource = &resourceTypes.ResourceWithMetadata{
+			Resource: &resourceTypes.Resource{Data: data},
+			Metadata: resourceMetadata,
+		}
+
+		return nil
+	})
+
+	return resource, err
+}
+
+// The resources are listed by the module query and each of them is verified against the store.
+// The proofs show that every listed resource is on the ledger, but not that the list is complete,
+// so the proven links between the versions of each listed resource must lead to listed resources only.
+// A node can't hide the latest version of a resource this way, while it can still leave out
+// all the versions of a resource at once, as if the resource was never created.
+func queryProvenCollectionResources(ctx context.Context, lightClient *lightClient, collectionId string) ([]*resourceTypes.Metadata, error) {
+	var resources []*resourceTypes.Metadata
+	err := lightClient.Read(ctx, "Querying proven DID resources: "+collectionId, func(ctx context.Context, store provenStore) error {
+		client := resourceTypes.NewQueryClient(store.conn)
+		// List the resources at the height of the proofs
+		listCtx := metadata.AppendToOutgoingContext(ctx, BlockHeightHeader, strconv.FormatInt(store.root.Height, 10))
+
+		// Start over if the previous endpoint failed in the middle of the list
+		resources = nil
+		err := walkPages(lightClient.endpoints.network, func(page *queryTypes.PageRequest) (*queryTypes.PageResponse, error) {
+			response, err := client.CollectionResources(listCtx, &resourceTypes.QueryCollectionResourcesRequest{CollectionId: collectionId, Pagination: page})
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, response.Resources...)
+
+			return response.Pagination, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, listed := range resources {
+			proven, err := readProvenResourceMetadata(ctx, store, collectionId, listed.Id)
+			if status.Code(err) == codes.NotFound || (err == nil && !proto.Equal(proven, listed)) {
+				return status.Errorf(codes.DataLoss, "resource %s listed in collection %s doesn't match the ledger state", listed.Id, collectionId)
+			}
+			if err != nil {
+				return err
+			}
+			resources[i] = proven
+		}
+
+		return checkVersionLinks(collectionId, resources)
+	})
+
+	return resources, err
+}
+
+// checkVersionLinks fails with DataLoss if a previous or next version of a resource is missing from the list
+func checkVersionLinks(collectionId string, resources []*resourceTypes.Metadata) error {
+	listed := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		listed[utils.NormalizeUUID(resource.Id)] = true
+	}
+
+	for _, resource := range resources {
+		for _, linked := range []string{resource.PreviousVersionId, resource.NextVersionId} {
+			if linked != "" && !listed[utils.NormalizeUUID(linked)] {
+				return status.Errorf(codes.DataLoss, "version %s of resource %s is missing from the listing of collection %s", linked, resource.Id, collectionId)
+			}
+		}
+	}
+
+	return nil
+}
+
+func readProvenDIDDoc(ctx context.Context, store provenStore, did string, version string) (*didTypes.DidDocWithMetadata, error) {
+	if version == "" {
+		latest, err := store.Get(ctx, didStoreName, didLatestVersionKey(did))
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil {
+			return nil, status.Errorf(codes.NotFound, "DID Doc %s not found", did)
+		}
+		version = string(latest)
+	}
+
+	value, err := store.Get(ctx, didStoreName, didVersionKey(did, version))
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, status.Errorf(codes.NotFound, "DID Doc %s version %s not found", did, version)
+	}
+
+	didDoc := &didTypes.DidDocWithMetadata{}
+	if err := proto.Unmarshal(value, didDoc); err != nil {
+		return nil, status.Errorf(codes.Internal, "DID Doc %s version %s can't be decoded: %s", did, version, err)
+	}
+
+	return didDoc, nil
+}
+
+func readProvenResourceMetadata(ctx context.Context, store provenStore, collectionId string, resourceId string) (*resourceTypes.Metadata, error) {
+	value, err := store.Get(ctx, resourceStoreName, resourceMetadataKey(collectionId, resourceId))
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, status.Errorf(codes.NotFound, "resource %s of collection %s not found", resourceId, collectionId)
+	}
+
+	resourceMetadata := &resourceTypes.Metadata{}
+	if err := proto.Unmarshal(value, resourceMetadata); err != nil {
+		return nil, status.Errorf(codes.Internal, "resource %s of collection %s can't be decoded: %s", resourceId, collectionId, err)
+	}
+
+	return resourceMetadata, nil
+}
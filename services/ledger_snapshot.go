@@ -0,0 +1,208 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/utils"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Snapshot subdirectories with exported DidDocWithMetadata and ResourceWithMetadata messages
+	SnapshotDidDocsDir   = "diddocs"
+	SnapshotResourcesDir = "resources"
+)
+
+// ledgerSnapshot is the ledger data of a namespace exported to files.
+// It is never modified once loaded, reloading replaces the whole snapshot.
+type ledgerSnapshot struct {
+	didDocs   map[string][]*didTypes.DidDocWithMetadata        // did -> all the versions
+	resources map[string][]*resourceTypes.ResourceWithMetadata // collection did -> resources
+}
+
+// loadLedgerSnapshot reads the snapshot of the namespace from a directory or a .tar, .tar.gz or .tgz archive.
+// DIDDoc versions are read from the diddocs subdirectory and resources from the resources subdirectory,
+// one message per .json (protobuf JSON) or .pb (protobuf binary) file.
+func loadLedgerSnapshot(snapshotPath string, method string, namespace string) (*ledgerSnapshot, error) {
+	snapshot := &ledgerSnapshot{
+		didDocs:   make(map[string][]*didTypes.DidDocWithMetadata),
+		resources: make(map[string][]*resourceTypes.ResourceWithMetadata),
+	}
+	add := func(name string, data []byte) error {
+		return snapshot.add(name, data, method, namespace)
+	}
+
+	info, err := os.Stat(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		err = readSnapshotDir(snapshotPath, add)
+	} else {
+		err = readSnapshotArchive(snapshotPath, add)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, versions := range snapshot.didDocs {
+		sort.SliceStable(versions, func(i, j int) bool {
+			return versions[i].Metadata.Created.AsTime().Before(versions[j].Metadata.Created.AsTime())
+		})
+	}
+
+	return snapshot, nil
+}
+
+// latestDidDoc returns the version which has no next version, the same as the ledger does
+func (s *ledgerSnapshot) latestDidDoc(did string) *didTypes.DidDocWithMetadata {
+	versions := s.didDocs[did]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Metadata.NextVersionId == "" {
+			return versions[i]
+		}
+	}
+	if len(versions) != 0 {
+		return versions[len(versions)-1]
+	}
+
+	return nil
+}
+
+func readSnapshotDir(root string, add func(name string, data []byte) error) error {
+	return filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		return add(filepath.ToSlash(name), data)
+	})
+}
+
+func readSnapshotArchive(archivePath string, add func(name string, data []byte) error) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	switch {
+	case strings.HasSuffix(archivePath, ".tar.gz"), strings.HasSuffix(archivePath, ".tgz"):
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case strings.HasSuffix(archivePath, ".tar"):
+	default:
+		return fmt.Errorf("snapshot %s is neither a directory nor a .tar, .tar.gz or .tgz archive", archivePath)
+	}
+
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return err
+		}
+		if err := add(path.Clean(strings.TrimPrefix(header.Name, "./")), data); err != nil {
+			return err
+		}
+	}
+}
+
+// add decodes the file according to the subdirectory it's in. Files in other places are ignored.
+func (s *ledgerSnapshot) add(name string, data []byte, method string, namespace string) error {
+	dir, _, _ := strings.Cut(name, "/")
+
+	switch dir {
+	case SnapshotDidDocsDir:
+		didDoc := &didTypes.DidDocWithMetadata{}
+		if ok, err := unmarshalSnapshotFile(name, data, didDoc); !ok || err != nil {
+			return err
+		}
+		if didDoc.DidDoc == nil || didDoc.Metadata == nil || didDoc.Metadata.VersionId == "" {
+			return fmt.Errorf("snapshot file %s: DIDDoc and its version metadata are required", name)
+		}
+		if err := checkSnapshotNamespace(name, didDoc.DidDoc.Id, method, namespace); err != nil {
+			return err
+		}
+		s.didDocs[didDoc.DidDoc.Id] = append(s.didDocs[didDoc.DidDoc.Id], didDoc)
+	case SnapshotResourcesDir:
+		resource := &resourceTypes.ResourceWithMetadata{}
+		if ok, err := unmarshalSnapshotFile(name, data, resource); !ok || err != nil {
+			return err
+		}
+		if resource.Metadata == nil || resource.Metadata.Id == "" || resource.Metadata.CollectionId == "" {
+			return fmt.Errorf("snapshot file %s: resource metadata with id and collection id is required", name)
+		}
+		collectionDid := utils.JoinDID(method, namespace, resource.Metadata.CollectionId)
+		s.resources[collectionDid] = append(s.resources[collectionDid], resource)
+	}
+
+	return nil
+}
+
+// unmarshalSnapshotFile decodes the file by its extension. It returns false for files of other types.
+func unmarshalSnapshotFile(name string, data []byte, message proto.Message) (bool, error) {
+	var err error
+	switch path.Ext(name) {
+	case ".json":
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, message)
+	case ".pb":
+		err = proto.Unmarshal(data, message)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("snapshot file %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+func checkSnapshotNamespace(name string, did string, method string, namespace string) error {
+	didMethod, didNamespace, _, err := utils.TrySplitDID(did)
+	if err != nil {
+		return fmt.Errorf("snapshot file %s: %w", name, err)
+	}
+	if didMethod != method || didNamespace != namespace {
+		return fmt.Errorf("snapshot file %s: %s doesn't belong to %s:%s", name, did, method, namespace)
+	}
+
+	return nil
+}
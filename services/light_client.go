@@ -0,0 +1,481 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cheqd/did-resolver/types"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	tmservice "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	ed25519types "github.com/cosmos/cosmos-sdk/api/cosmos/crypto/ed25519"
+	tmtypes "github.com/cosmos/cosmos-sdk/api/tendermint/types"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Headers may be ahead of the clock of the resolver by this much
+	lightClientMaxClockDrift = 10 * time.Second
+	// Number of verified headers kept besides the trusted one
+	lightClientCacheSize = 100
+	// Headers older than the trusted one are verified one block at a time, so don't go back too far
+	lightClientMaxBackwardHeaders = 1000
+	// Max page size of the validator sets served by CometBFT
+	lightClientValidatorsPageSize = 100
+
+	ed25519PubKeyTypeUrl = "/cosmos.crypto.ed25519.PubKey"
+)
+
+// errNotEnoughTrust means the validators of the trusted header didn't sign enough of the new one,
+// so a header in between has to be verified first
+var errNotEnoughTrust = errors.New("validators of the trusted header signed less than 1/3 of the voting power")
+
+// lightBlock is a header verified by the light client
+type lightBlock struct {
+	header *tmtypes.Header
+	// Commit which signs the header, headers verified through the hash chain have none
+	commit     *tmtypes.Commit
+	validators []lightValidator
+	// Validators of the next height, fetched when a later header is verified against this one
+	nextValidators []lightValidator
+}
+
+// lightClient verifies the headers of a network the way CometBFT light clients do. Starting from
+// a header trusted out of band, a later header is trusted if it is signed by more than 2/3 of its validators
+// and by more than 1/3 of the voting power trusted already, verifying a header in between otherwise.
+// An earlier header is trusted if it's linked to a trusted one by the hashes of the previous blocks.
+type lightClient struct {
+	config    types.VerificationConfig
+	endpoints *ledgerEndpointSet
+
+	// The lock is held only around the access to the map, concurrent queries may verify the same header
+	mu      sync.Mutex
+	trusted map[int64]*lightBlock
+}
+
+func newLightClient(config types.VerificationConfig, endpoints *ledgerEndpointSet) *lightClient {
+	return &lightClient{
+		config:    config,
+		endpoints: endpoints,
+		trusted:   make(map[int64]*lightBlock),
+	}
+}
+
+// TrustedRoot returns the height the ledger state has to be read at and the verified app hash of that state.
+// The state after block H is committed to by the app hash of header H+1, which is signed in block H+2,
+// so the latest state which can be verified is the one two blocks before the latest block.
+func (c *lightClient) TrustedRoot(ctx context.Context) (trustedRoot, error) {
+	height, ok := BlockHeightFromContext(ctx)
+	ctx = withoutBlockHeight(ctx)
+	if !ok {
+		latest, err := c.latestHeight(ctx)
+		if err != nil {
+			return trustedRoot{}, err
+		}
+		height = latest - 2
+		if height < 1 {
+			return trustedRoot{}, status.Errorf(codes.Unavailable, "chain has %d blocks, too few to verify its state", latest)
+		}
+	}
+
+	block, err := c.VerifyHeader(ctx, height+1)
+	if err != nil {
+		return trustedRoot{}, err
+	}
+
+	return trustedRoot{Height: height, AppHash: block.header.AppHash}, nil
+}
+
+// Read runs the reads of the proven store against one of the endpoints at the height of the trusted root
+func (c *lightClient) Read(ctx context.Context, description string, read func(ctx context.Context, store provenStore) error) error {
+	root, err := c.TrustedRoot(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.endpoints.Call(withoutBlockHeight(ctx), description, func(ctx context.Context, conn *grpc.ClientConn) error {
+		return read(ctx, provenStore{conn: conn, root: root})
+	})
+}
+
+// VerifyHeader returns the header at the height once it is verified
+func (c *lightClient) VerifyHeader(ctx context.Context, height int64) (*lightBlock, error) {
+	if err := c.seed(ctx); err != nil {
+		return nil, err
+	}
+
+	below, exact, above := c.closestTrusted(height)
+	if exact != nil {
+		return exact, nil
+	}
+	if below == nil {
+		return c.verifyBackward(ctx, above, height)
+	}
+
+	untrusted, err := c.fetchSignedHeader(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.verifyForward(ctx, below, untrusted); err != nil {
+		return nil, err
+	}
+
+	return untrusted, nil
+}
+
+// seed fetches the trusted header the first time the client is used
+func (c *lightClient) seed(ctx context.Context) error {
+	c.mu.Lock()
+	seeded := len(c.trusted) > 0
+	c.mu.Unlock()
+	if seeded {
+		return nil
+	}
+
+	block, err := c.fetchBlock(ctx, c.config.TrustedHeight)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(headerHash(block.Header), c.config.TrustedHash) {
+		return status.Errorf(codes.DataLoss, "header %d doesn't match the trusted hash %X", c.config.TrustedHeight, c.config.TrustedHash)
+	}
+	if block.Header.ChainId != c.config.ChainId {
+		return status.Errorf(codes.DataLoss, "trusted header %d is from chain %s, not %s", c.config.TrustedHeight, block.Header.ChainId, c.config.ChainId)
+	}
+
+	log.Info().Msgf("Light client of %s is seeded with header %d", c.endpoints.network.Namespace, c.config.TrustedHeight)
+	c.store(&lightBlock{header: block.Header})
+
+	return nil
+}
+
+// verifyForward trusts the untrusted header if it is signed by enough of the validators trusted already,
+// bisecting the range when the validators changed too much since the trusted header
+func (c *lightClient) verifyForward(ctx context.Context, trusted *lightBlock, untrusted *lightBlock) error {
+	err := c.verifySkipping(ctx, trusted, untrusted)
+	if errors.Is(err, errNotEnoughTrust) {
+		pivotHeight := trusted.header.Height + (untrusted.header.Height-trusted.header.Height)/2
+		zerolog.Ctx(ctx).Debug().Msgf("Light client of %s verifies header %d before %d", c.endpoints.network.Namespace, pivotHeight, untrusted.header.Height)
+
+		pivot, err := c.fetchSignedHeader(ctx, pivotHeight)
+		if err != nil {
+			return err
+		}
+		if err := c.verifyForward(ctx, trusted, pivot); err != nil {
+			return err
+		}
+		return c.verifyForward(ctx, pivot, untrusted)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.store(untrusted)
+	return nil
+}
+
+func (c *lightClient) verifySkipping(ctx context.Context, trusted *lightBlock, untrusted *lightBlock) error {
+	header := untrusted.header
+	now := time.Now()
+
+	trustedTime := trusted.header.Time.AsTime()
+	if trustedTime.Add(c.config.TrustingPeriod).Before(now) {
+		return status.Errorf(codes.FailedPrecondition, "trusted header %d from %s is older than the trusting period %s",
+			trusted.header.Height, trustedTime.Format(time.RFC3339), c.config.TrustingPeriod)
+	}
+	if !header.Time.AsTime().After(trustedTime) {
+		return status.Errorf(codes.DataLoss, "header %d is not newer than the trusted header %d", header.Height, trusted.header.Height)
+	}
+	if header.Time.AsTime().After(now.Add(lightClientMaxClockDrift)) {
+		return status.Errorf(codes.DataLoss, "header %d is from the future", header.Height)
+	}
+
+	if header.Height == trusted.header.Height+1 {
+		if !bytes.Equal(header.ValidatorsHash, trusted.header.NextValidatorsHash) {
+			return status.Errorf(codes.DataLoss, "validators of header %d are not the next validators of header %d", header.Height, trusted.header.Height)
+		}
+	} else {
+		trustedValidators, err := c.nextValidators(ctx, trusted)
+		if err != nil {
+			return err
+		}
+		signed, total, err := tallyCommit(c.config.ChainId, untrusted.commit, trustedValidators)
+		if err != nil {
+			return err
+		}
+		if signed*3 <= total {
+			return errNotEnoughTrust
+		}
+	}
+
+	signed, total, err := tallyCommit(c.config.ChainId, untrusted.commit, untrusted.validators)
+	if err != nil {
+		return err
+	}
+	if signed*3 <= total*2 {
+		return status.Errorf(codes.DataLoss, "header %d is signed by %d of %d voting power, less than 2/3", header.Height, signed, total)
+	}
+
+	return nil
+}
+
+// verifyBackward trusts the header if the hashes of the previous blocks link it to the trusted one
+func (c *lightClient) verifyBackward(ctx context.Context, trusted *lightBlock, height int64) (*lightBlock, error) {
+	if trusted.header.Height-height > lightClientMaxBackwardHeaders {
+		return nil, status.Errorf(codes.FailedPrecondition, "header %d is not available: it is more than %d blocks older than the trusted header %d",
+			height, lightClientMaxBackwardHeaders, trusted.header.Height)
+	}
+
+	current := trusted.header
+	for current.Height > height {
+		block, err := c.fetchBlock(ctx, current.Height-1)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(headerHash(block.Header), current.LastBlockId.GetHash()) {
+			return nil, status.Errorf(codes.DataLoss, "header %d doesn't match the hash of the previous block in header %d", block.Header.Height, current.Height)
+		}
+		current = block.Header
+	}
+
+	verified := &lightBlock{header: current}
+	c.store(verified)
+
+	return verified, nil
+}
+
+// fetchSignedHeader fetches the header at the height with its commit and validators
+// and checks they match each other. It doesn't tell whether the header can be trusted.
+func (c *lightClient) fetchSignedHeader(ctx context.Context, height int64) (*lightBlock, error) {
+	block, err := c.fetchBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	// The commit of a block is included in the next one
+	next, err := c.fetchBlock(ctx, height+1)
+	if err != nil {
+		return nil, err
+	}
+	validators, err := c.fetchValidators(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	header, commit := block.Header, next.LastCommit
+	switch {
+	case header.ChainId != c.config.ChainId:
+		return nil, status.Errorf(codes.DataLoss, "header %d is from chain %s, not %s", height, header.ChainId, c.config.ChainId)
+	case header.Height != height:
+		return nil, status.Errorf(codes.DataLoss, "header %d was returned for height %d", header.Height, height)
+	case commit.GetHeight() != height:
+		return nil, status.Errorf(codes.DataLoss, "commit of header %d is missing", height)
+	case !bytes.Equal(commit.GetBlockId().GetHash(), headerHash(header)):
+		return nil, status.Errorf(codes.DataLoss, "commit of header %d signs another block", height)
+	case !bytes.Equal(validatorSetHash(validators), header.ValidatorsHash):
+		return nil, status.Errorf(codes.DataLoss, "validators of header %d don't match its validators hash", height)
+	}
+
+	return &lightBlock{header: header, commit: commit, validators: validators}, nil
+}
+
+// nextValidators returns the validators the trusted header committed to for the next height
+func (c *lightClient) nextValidators(ctx context.Context, trusted *lightBlock) ([]lightValidator, error) {
+	c.mu.Lock()
+	validators := trusted.nextValidators
+	c.mu.Unlock()
+	if validators != nil {
+		return validators, nil
+	}
+
+	validators, err := c.fetchValidators(ctx, trusted.header.Height+1)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(validatorSetHash(validators), trusted.header.NextValidatorsHash) {
+		return nil, status.Errorf(codes.DataLoss, "validators of height %d don't match the next validators hash of header %d", trusted.header.Height+1, trusted.header.Height)
+	}
+
+	c.mu.Lock()
+	trusted.nextValidators = validators
+	c.mu.Unlock()
+
+	return validators, nil
+}
+
+func (c *lightClient) latestHeight(ctx context.Context) (int64, error) {
+	var height int64
+	err := c.endpoints.Call(ctx, "Fetching the latest block", func(ctx context.Context, conn *grpc.ClientConn) error {
+		response, err := tmservice.NewServiceClient(conn).GetLatestBlock(ctx, &tmservice.GetLatestBlockRequest{})
+		if err != nil {
+			return err
+		}
+		height = response.GetBlock().GetHeader().GetHeight()
+
+		return nil
+	})
+
+	return height, err
+}
+
+func (c *lightClient) fetchBlock(ctx context.Context, height int64) (*tmtypes.Block, error) {
+	var block *tmtypes.Block
+	err := c.endpoints.Call(ctx, fmt.Sprintf("Fetching block %d", height), func(ctx context.Context, conn *grpc.ClientConn) error {
+		response, err := tmservice.NewServiceClient(conn).GetBlockByHeight(ctx, &tmservice.GetBlockByHeightRequest{Height: height})
+		// The block is not produced yet or is pruned
+		if status.Code(err) == codes.InvalidArgument {
+			return status.Errorf(codes.FailedPrecondition, "block %d is not available: %s", height, status.Convert(err).Message())
+		}
+		if err != nil {
+			return err
+		}
+		if response.GetBlock().GetHeader() == nil {
+			return status.Errorf(codes.DataLoss, "block %d has no header", height)
+		}
+		block = response.Block
+
+		return nil
+	})
+
+	return block, err
+}
+
+// fetchValidators fetches the validator set of the height in the order of the set.
+// Validator sets are paginated by offset rather than by key.
+func (c *lightClient) fetchValidators(ctx context.Context, height int64) ([]lightValidator, error) {
+	var validators []lightValidator
+	err := c.endpoints.Call(ctx, fmt.Sprintf("Fetching validators of block %d", height), func(ctx context.Context, conn *grpc.ClientConn) error {
+		client := tmservice.NewServiceClient(conn)
+
+		// Start over if the previous endpoint failed in the middle of the set
+		validators = nil
+		for {
+			response, err := client.GetValidatorSetByHeight(ctx, &tmservice.GetValidatorSetByHeightRequest{
+				Height:     height,
+				Pagination: &queryTypes.PageRequest{Offset: uint64(len(validators)), Limit: lightClientValidatorsPageSize},
+			})
+			if err != nil {
+				return err
+			}
+			for _, validator := range response.Validators {
+				parsed, err := newLightValidator(validator)
+				if err != nil {
+					return err
+				}
+				validators = append(validators, parsed)
+			}
+			if len(response.Validators) == 0 || uint64(len(validators)) >= response.GetPagination().GetTotal() {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(validators, func(i, j int) bool {
+		if validators[i].VotingPower != validators[j].VotingPower {
+			return validators[i].VotingPower > validators[j].VotingPower
+		}
+		return bytes.Compare(validators[i].Address, validators[j].Address) < 0
+	})
+
+	return validators, nil
+}
+
+func newLightValidator(validator *tmservice.Validator) (lightValidator, error) {
+	if validator.GetPubKey().GetTypeUrl() != ed25519PubKeyTypeUrl {
+		return lightValidator{}, status.Errorf(codes.DataLoss, "key type %s of validator %s is not supported", validator.GetPubKey().GetTypeUrl(), validator.Address)
+	}
+
+	pubKey := &ed25519types.PubKey{}
+	if err := proto.Unmarshal(validator.PubKey.Value, pubKey); err != nil || len(pubKey.Key) != ed25519.PublicKeySize {
+		return lightValidator{}, status.Errorf(codes.DataLoss, "key of validator %s is invalid", validator.Address)
+	}
+
+	address := sha256.Sum256(pubKey.Key)
+	return lightValidator{
+		Address:     address[:20],
+		PubKey:      pubKey.Key,
+		VotingPower: validator.VotingPower,
+	}, nil
+}
+
+// tallyCommit returns the voting power of the validators who signed the block of the commit
+// and the total voting power of the validators. Signatures of unknown validators are ignored.
+func tallyCommit(chainId string, commit *tmtypes.Commit, validators []lightValidator) (signed int64, total int64, err error) {
+	byAddress := make(map[string]lightValidator, len(validators))
+	for _, validator := range validators {
+		byAddress[string(validator.Address)] = validator
+		total += validator.VotingPower
+	}
+
+	counted := make(map[string]bool, len(validators))
+	for i, sig := range commit.Signatures {
+		if sig.BlockIdFlag != tmtypes.BlockIDFlag_BLOCK_ID_FLAG_COMMIT {
+			continue
+		}
+		validator, ok := byAddress[string(sig.ValidatorAddress)]
+		if !ok {
+			continue
+		}
+		if counted[string(sig.ValidatorAddress)] {
+			return 0, 0, status.Errorf(codes.DataLoss, "validator %X signed commit %d twice", sig.ValidatorAddress, commit.Height)
+		}
+		if !ed25519.Verify(validator.PubKey, voteSignBytes(chainId, commit, i), sig.Signature) {
+			return 0, 0, status.Errorf(codes.DataLoss, "signature of validator %X in commit %d is invalid", sig.ValidatorAddress, commit.Height)
+		}
+		counted[string(sig.ValidatorAddress)] = true
+		signed += validator.VotingPower
+	}
+
+	return signed, total, nil
+}
+
+// closestTrusted returns the trusted header at the height if there is one,
+// or the closest trusted headers below and above it otherwise
+func (c *lightClient) closestTrusted(height int64) (below *lightBlock, exact *lightBlock, above *lightBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if block, ok := c.trusted[height]; ok {
+		return nil, block, nil
+	}
+	for h, block := range c.trusted {
+		if h < height && (below == nil || h > below.header.Height) {
+			below = block
+		}
+		if h > height && (above == nil || h < above.header.Height) {
+			above = block
+		}
+	}
+
+	return below, nil, above
+}
+
+// store keeps the verified header, dropping the oldest ones except for the trusted one
+func (c *lightClient) store(block *lightBlock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.trusted[block.header.Height] = block
+	for len(c.trusted) > lightClientCacheSize+1 {
+		oldest := int64(-1)
+		for h := range c.trusted {
+			if h != c.config.TrustedHeight && (oldest == -1 || h < oldest) {
+				oldest = h
+			}
+		}
+		delete(c.trusted, oldest)
+	}
+}
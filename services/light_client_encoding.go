@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"math/bits"
+
+	tmtypes "github.com/cosmos/cosmos-sdk/api/tendermint/types"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Hashes and sign bytes of headers, validator sets and votes are encoded by hand the way CometBFT v0.37 does,
+// since they are compared with the hashes signed by the validators byte for byte.
+
+// lightValidator is a validator of the set which signs a header
+type lightValidator struct {
+	Address     []byte
+	PubKey      ed25519.PublicKey
+	VotingPower int64
+}
+
+// merkleRoot is the RFC 6962 Merkle root CometBFT uses for headers and validator sets
+func merkleRoot(items [][]byte) []byte {
+	switch len(items) {
+	case 0:
+		empty := sha256.Sum256(nil)
+		return empty[:]
+	case 1:
+		leaf := sha256.Sum256(append([]byte{0}, items[0]...))
+		return leaf[:]
+	default:
+		k := merkleSplitPoint(len(items))
+		inner := sha256.Sum256(append(append([]byte{1}, merkleRoot(items[:k])...), merkleRoot(items[k:])...))
+		return inner[:]
+	}
+}
+
+// merkleSplitPoint returns the largest power of 2 less than n
+func merkleSplitPoint(n int) int {
+	k := 1 << (bits.Len(uint(n)) - 1)
+	if k == n {
+		k >>= 1
+	}
+	return k
+}
+
+// headerHash is the hash of the header, which is the hash of its block
+func headerHash(header *tmtypes.Header) []byte {
+	if header == nil || len(header.ValidatorsHash) == 0 {
+		return nil
+	}
+
+	var version []byte
+	if header.Version != nil {
+		version = appendVarintField(version, 1, header.Version.Block)
+		version = appendVarintField(version, 2, header.Version.App)
+	}
+
+	return merkleRoot([][]byte{
+		version,
+		appendStringField(nil, 1, header.ChainId),
+		appendVarintField(nil, 1, uint64(header.Height)),
+		encodeTimestamp(header.Time),
+		encodeBlockID(header.LastBlockId),
+		appendBytesField(nil, 1, header.LastCommitHash),
+		appendBytesField(nil, 1, header.DataHash),
+		appendBytesField(nil, 1, header.ValidatorsHash),
+		appendBytesField(nil, 1, header.NextValidatorsHash),
+		appendBytesField(nil, 1, header.ConsensusHash),
+		appendBytesField(nil, 1, header.AppHash),
+		appendBytesField(nil, 1, header.LastResultsHash),
+		appendBytesField(nil, 1, header.EvidenceHash),
+		appendBytesField(nil, 1, header.ProposerAddress),
+	})
+}
+
+// validatorSetHash is the hash headers commit to in ValidatorsHash and NextValidatorsHash.
+// Validators must be in the order of the set: by voting power, then by address.
+func validatorSetHash(validators []lightValidator) []byte {
+	items := make([][]byte, 0, len(validators))
+	for _, validator := range validators {
+		// SimpleValidator{PubKey: PublicKey{Ed25519}, VotingPower}
+		pubKey := protowire.AppendTag(nil, 1, protowire.BytesType)
+		pubKey = protowire.AppendBytes(pubKey, validator.PubKey)
+		item := appendMessageField(nil, 1, pubKey)
+		item = appendVarintField(item, 2, uint64(validator.VotingPower))
+		items = append(items, item)
+	}
+
+	return merkleRoot(items)
+}
+
+// voteSignBytes returns the bytes the validator at the index of the commit signed: the length-delimited CanonicalVote
+func voteSignBytes(chainId string, commit *tmtypes.Commit, index int) []byte {
+	sig := commit.Signatures[index]
+
+	// Precommit
+	vote := appendVarintField(nil, 1, 2)
+	if commit.Height != 0 {
+		vote = protowire.AppendTag(vote, 2, protowire.Fixed64Type)
+		vote = protowire.AppendFixed64(vote, uint64(commit.Height))
+	}
+	if commit.Round != 0 {
+		vote = protowire.AppendTag(vote, 3, protowire.Fixed64Type)
+		vote = protowire.AppendFixed64(vote, uint64(int64(commit.Round)))
+	}
+	// Validators which voted nil sign no block ID
+	if sig.BlockIdFlag == tmtypes.BlockIDFlag_BLOCK_ID_FLAG_COMMIT && commit.BlockId != nil {
+		vote = appendMessageField(vote, 4, encodeBlockID(commit.BlockId))
+	}
+	vote = appendMessageField(vote, 5, encodeTimestamp(sig.Timestamp))
+	vote = appendStringField(vote, 6, chainId)
+
+	return protowire.AppendBytes(nil, vote)
+}
+
+func encodeBlockID(blockID *tmtypes.BlockID) []byte {
+	if blockID == nil {
+		blockID = &tmtypes.BlockID{}
+	}
+
+	var partSetHeader []byte
+	if blockID.PartSetHeader != nil {
+		partSetHeader = appendVarintField(partSetHeader, 1, uint64(blockID.PartSetHeader.Total))
+		partSetHeader = appendBytesField(partSetHeader, 2, blockID.PartSetHeader.Hash)
+	}
+
+	encoded := appendBytesField(nil, 1, blockID.Hash)
+	return appendMessageField(encoded, 2, partSetHeader)
+}
+
+func encodeTimestamp(timestamp *timestamppb.Timestamp) []byte {
+	var encoded []byte
+	encoded = appendVarintField(encoded, 1, uint64(timestamp.GetSeconds()))
+	return appendVarintField(encoded, 2, uint64(int64(timestamp.GetNanos())))
+}
+
+// Fields with default values are omitted, as proto3 does
+
+func appendVarintField(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBytesField(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendStringField(b []byte, num protowire.Number, v string) []byte {
+	return appendBytesField(b, num, []byte(v))
+}
+
+// appendMessageField appends an embedded message even if it's empty, as gogoproto does for non-nullable fields
+func appendMessageField(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
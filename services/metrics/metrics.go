@@ -0,0 +1,134 @@
+// Package metrics keeps the Prometheus metrics of the resolver. Labels take values from small fixed sets only,
+// such as route patterns, namespaces of the configured networks and error names, never DIDs or other request input.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Outcome of successful requests and ledger queries, failures are labeled with the IdentityError message
+const Success = "success"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "did_resolver_http_requests_total",
+		Help: "HTTP requests by route pattern, status code and outcome.",
+	}, []string{"route", "code", "outcome"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "did_resolver_http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests by route pattern and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "outcome"})
+
+	batchItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "did_resolver_batch_items_total",
+		Help: "Items of batch requests by route pattern, status code and outcome.",
+	}, []string{"route", "code", "outcome"})
+
+	responseEncodings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "did_resolver_http_response_encodings_total",
+		Help: "Successful HTTP responses by content encoding, gzip or identity.",
+	}, []string{"encoding"})
+
+	ledgerQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "did_resolver_ledger_queries_total",
+		Help: "Ledger queries by namespace, query and outcome, including the retries and failover between endpoints.",
+	}, []string{"namespace", "query", "outcome"})
+
+	ledgerQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "did_resolver_ledger_query_duration_seconds",
+		Help:    "Time taken by ledger queries by namespace and query.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"namespace", "query"})
+
+	coalescingCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "did_resolver_ledger_coalescing_calls_total",
+		Help: "Ledger queries sent on by query, each of them shared by the identical queries made while it runs.",
+	}, []string{"query"})
+
+	coalescedQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "did_resolver_ledger_coalesced_queries_total",
+		Help: "Ledger queries by query which got the response of an identical query running at the same time.",
+	}, []string{"query"})
+
+	resourceBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "did_resolver_resource_bytes_served_total",
+		Help: "Bytes of resource data served by namespace, before compression.",
+	}, []string{"namespace"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		batchItems,
+		responseEncodings,
+		ledgerQueries,
+		ledgerQueryDuration,
+		coalescingCalls,
+		coalescedQueries,
+		resourceBytes,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// ObserveRequest records a handled HTTP request. Requests which matched no route have an empty route.
+func ObserveRequest(route string, code int, outcome string, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	requests.WithLabelValues(route, strconv.Itoa(code), outcome).Inc()
+	requestDuration.WithLabelValues(route, outcome).Observe(duration.Seconds())
+}
+
+// ObserveBatchItem records a handled item of a batch request, which isn't counted as a request of its own
+func ObserveBatchItem(route string, code int, outcome string) {
+	if route == "" {
+		route = "unmatched"
+	}
+	batchItems.WithLabelValues(route, strconv.Itoa(code), outcome).Inc()
+}
+
+// ObserveResponseEncoding records whether a successful response was compressed
+func ObserveResponseEncoding(gzipped bool) {
+	encoding := "identity"
+	if gzipped {
+		encoding = "gzip"
+	}
+	responseEncodings.WithLabelValues(encoding).Inc()
+}
+
+// ObserveLedgerQuery records a query sent to the ledger of a configured namespace
+func ObserveLedgerQuery(namespace string, query string, outcome string, duration time.Duration) {
+	ledgerQueries.WithLabelValues(namespace, query, outcome).Inc()
+	ledgerQueryDuration.WithLabelValues(namespace, query).Observe(duration.Seconds())
+}
+
+// ObserveCoalescing records a ledger query which was either sent on or coalesced with an identical one
+func ObserveCoalescing(query string, coalesced bool) {
+	if coalesced {
+		coalescedQueries.WithLabelValues(query).Inc()
+	} else {
+		coalescingCalls.WithLabelValues(query).Inc()
+	}
+}
+
+// AddResourceBytes records resource data served from a configured namespace
+func AddResourceBytes(namespace string, size int64) {
+	resourceBytes.WithLabelValues(namespace).Add(float64(size))
+}
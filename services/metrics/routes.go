@@ -0,0 +1,10 @@
+package metrics
+
+import (
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+func SetRoutes(e *echo.Echo) {
+	e.GET(types.METRICS_PATH, echo.WrapHandler(Handler()))
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"time"
+
+	"github.com/cheqd/did-resolver/services/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsMiddleware records the outcome and the duration of every request by route pattern,
+// so that the labels never contain the DIDs of the requests. It must run after the handler
+// errors are known, inside the Logger and Recover middleware. Items of batch requests are
+// counted apart from the requests.
+func MetricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		code := c.Response().Status
+		outcome := metrics.Success
+		if err != nil {
+			identityError := generateIdentityError(err)
+			code = identityError.Code
+			outcome = identityError.Message
+		}
+
+		if IsBatchItem(c.Request().Context()) {
+			metrics.ObserveBatchItem(c.Path(), code, outcome)
+			return err
+		}
+		if err == nil {
+			metrics.ObserveResponseEncoding(c.Response().Header().Get(echo.HeaderContentEncoding) == "gzip")
+		}
+		metrics.ObserveRequest(c.Path(), code, outcome, time.Since(start))
+
+		return err
+	}
+}
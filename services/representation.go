@@ -0,0 +1,36 @@
+package services
+
+import (
+	"github.com/cheqd/did-resolver/types"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/labstack/echo/v4"
+)
+
+// cborEncoding represents the results with the same data model as JSON: map keys are the names
+// of the JSON fields, the fields omitted from JSON are omitted as well and times are RFC 3339 strings.
+// Map keys are sorted as required by the CBOR core deterministic encoding.
+var cborEncoding = func() cbor.EncMode {
+	encoding, err := cbor.EncOptions{
+		Sort:      cbor.SortCoreDeterministic,
+		Time:      cbor.TimeRFC3339Nano,
+		OmitEmpty: cbor.OmitEmptyGoValue,
+	}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return encoding
+}()
+
+// RespondWithRepresentation writes the value in CBOR if the content type is a CBOR one, or in indented JSON otherwise
+func RespondWithRepresentation(c echo.Context, code int, contentType types.ContentType, value interface{}) error {
+	if !contentType.IsCBOR() {
+		return c.JSONPretty(code, value, "  ")
+	}
+
+	data, err := cborEncoding.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(code, string(contentType), data)
+}
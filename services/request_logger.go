@@ -0,0 +1,61 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDMiddleware takes the ID of the request from X-Request-ID, or generates one, and returns it
+// in the same header. The request context gets a logger with the ID, so the ledger queries,
+// the errors and the access log of the request can be told apart from the others.
+func RequestIDMiddleware() echo.MiddlewareFunc {
+	return middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, requestID string) {
+			// Generated IDs are added to the request too, to be passed on to the items of batch requests
+			c.Request().Header.Set(echo.HeaderXRequestID, requestID)
+			logger := log.With().Str("request_id", requestID).Logger()
+			c.SetRequest(c.Request().WithContext(logger.WithContext(c.Request().Context())))
+		},
+	})
+}
+
+// AccessLogMiddleware logs every request once it's handled. Errors are handled by the HTTP error handler first,
+// so the status they are answered with is logged. Items of batch requests are logged as well, marked with batch_item.
+func AccessLogMiddleware() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		HandleError:     true,
+		LogLatency:      true,
+		LogRemoteIP:     true,
+		LogMethod:       true,
+		LogURI:          true,
+		LogRoutePath:    true,
+		LogStatus:       true,
+		LogResponseSize: true,
+		LogUserAgent:    true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			logger := zerolog.Ctx(c.Request().Context())
+			event := logger.Info()
+			if v.Status >= http.StatusInternalServerError {
+				event = logger.Error()
+			}
+			event.
+				Str("method", v.Method).
+				Str("uri", v.URI).
+				Str("route", v.RoutePath).
+				Int("status", v.Status).
+				Int64("bytes_out", v.ResponseSize).
+				Dur("latency", v.Latency).
+				Str("remote_ip", v.RemoteIP).
+				Str("user_agent", v.UserAgent)
+			if IsBatchItem(c.Request().Context()) {
+				event.Bool("batch_item", true)
+			}
+			event.Msg("request")
+			return nil
+		},
+	})
+}
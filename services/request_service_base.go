@@ -1,11 +1,15 @@
 package services
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/cheqd/did-resolver/migrations"
+	"github.com/cheqd/did-resolver/services/metrics"
 	"github.com/cheqd/did-resolver/types"
 	"github.com/cheqd/did-resolver/utils"
 	"github.com/labstack/echo/v4"
@@ -20,6 +24,8 @@ type (
 		Queries              url.Values
 		Result               types.ResolutionResultI
 		RequestedContentType types.ContentType
+		// Media range of the Accept header RequestedContentType was negotiated from
+		AcceptedMediaType AcceptedMediaType
 	}
 )
 
@@ -45,7 +51,8 @@ func (dd *BaseRequestService) BasicPrepare(c ResolverContext) error {
 	// Here we raise errors even they were caught while getting the data from context
 
 	// Get Accept header
-	dd.RequestedContentType = GetContentType(c.Request().Header.Get(echo.HeaderAccept))
+	dd.AcceptedMediaType, _ = NegotiateContentType(c.Request().Header.Get(echo.HeaderAccept))
+	dd.RequestedContentType = dd.AcceptedMediaType.ContentType
 	if !dd.GetContentType().IsSupported() {
 		return types.NewRepresentationNotSupportedError(dd.GetDid(), types.JSON, nil, dd.IsDereferencing)
 	}
@@ -71,6 +78,16 @@ func (dd *BaseRequestService) BasicPrepare(c ResolverContext) error {
 	}
 	dd.Queries = queries
 
+	// blockHeight is a resolution option applicable to every route, so it's not left among the queries
+	if dd.Queries.Has(types.BlockHeight) {
+		blockHeight, err := strconv.ParseInt(dd.Queries.Get(types.BlockHeight), 10, 64)
+		if err != nil || blockHeight <= 0 {
+			return types.NewInvalidDidUrlError(dd.GetDid(), dd.GetContentType(), err, dd.IsDereferencing)
+		}
+		dd.Queries.Del(types.BlockHeight)
+		c.SetRequest(c.Request().WithContext(WithBlockHeight(c.Request().Context(), blockHeight)))
+	}
+
 	return nil
 }
 
@@ -108,7 +125,7 @@ func (dd BaseRequestService) Redirect(c ResolverContext) error {
 }
 
 func (dd *BaseRequestService) Query(c ResolverContext) error {
-	result, err := c.DidDocService.Resolve(dd.GetDid(), dd.Version, dd.GetContentType())
+	result, err := c.DidDocService.Resolve(c.Request().Context(), dd.GetDid(), dd.Version, dd.GetContentType())
 	if err != nil {
 		err.IsDereferencing = false
 		return err
@@ -117,15 +134,22 @@ func (dd *BaseRequestService) Query(c ResolverContext) error {
 }
 
 func (dd BaseRequestService) SetupResponse(c ResolverContext) error {
+	if dd.SetupCaching(c) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	c.Response().Header().Set(echo.HeaderContentType, dd.Result.GetContentType())
-	if utils.IsGzipAccepted(c) {
+	if !utils.GzipSkipper(c) {
 		c.Response().Header().Set(echo.HeaderContentEncoding, "gzip")
 	}
 	return nil
 }
 
 func (dd BaseRequestService) Respond(c ResolverContext) error {
-	return c.JSONPretty(http.StatusOK, dd.Result, "  ")
+	if resolution, ok := dd.Result.(*types.DidResolution); ok && c.DidDocumentResponses {
+		return dd.RespondWithResolution(c, resolution)
+	}
+	return RespondWithRepresentation(c, http.StatusOK, dd.GetContentType(), dd.Result)
 }
 
 // Setters
@@ -137,8 +161,82 @@ func (dd *BaseRequestService) SetResponse(response types.ResolutionResultI) erro
 
 // Helpers
 
+// IsPinned reports whether the request addresses data which never changes on the ledger:
+// a DIDDoc version, a resource or anything at a block height
+func (dd BaseRequestService) IsPinned(c ResolverContext) bool {
+	if _, ok := BlockHeightFromContext(c.Request().Context()); ok {
+		return true
+	}
+	return dd.Version != "" || c.Param("resource") != "" || dd.Queries.Has(types.VersionId) || dd.Queries.Has(types.ResourceId)
+}
+
+// SetupCaching sets the HTTP caching headers of the result and reports whether the client has it already,
+// in which case it's not serialized at all
+func (dd BaseRequestService) SetupCaching(c ResolverContext) bool {
+	if dd.Result.IsRedirect() {
+		return false
+	}
+	validators := newCacheValidators(dd.Result)
+	if validators == nil {
+		return false
+	}
+
+	// Media types, the bare DID Documents and the compressed responses are different representations of the same data.
+	// Range requests are never compressed, but they get the ETag of the whole response, so it matches their If-Range.
+	representation := string(dd.GetContentType()) + ";" + strconv.FormatBool(c.DidDocumentResponses && dd.AcceptedMediaType.IsDocumentRequested()) +
+		";" + strconv.FormatBool(utils.IsGzipAccepted(c))
+	etag := validators.ETag(representation)
+
+	header := c.Response().Header()
+	header.Add(echo.HeaderVary, echo.HeaderAccept)
+	header.Set(echo.HeaderCacheControl, cacheControl(c.HttpCache, dd.IsPinned(c)))
+	header.Set("ETag", etag)
+	if !validators.lastModified.IsZero() {
+		header.Set(echo.HeaderLastModified, validators.lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	return isNotModified(c.Request(), etag, validators.lastModified)
+}
+
+// RespondWithResolution returns the DID Document alone if a DID Document media type was requested.
+// Its metadata and the resolution metadata are returned in headers then, without the linked resources,
+// which are listed by the metadata route. Otherwise the DID resolution result is returned.
+func (dd BaseRequestService) RespondWithResolution(c ResolverContext, resolution *types.DidResolution) error {
+	if !dd.AcceptedMediaType.IsDocumentRequested() {
+		if dd.GetContentType().IsCBOR() {
+			c.Response().Header().Set(echo.HeaderContentType, types.DIDResolutionResultCBOR)
+		} else {
+			c.Response().Header().Set(echo.HeaderContentType, types.DIDResolutionResult)
+		}
+		return RespondWithRepresentation(c, http.StatusOK, dd.GetContentType(), resolution)
+	}
+
+	documentMetadata := resolution.Metadata
+	documentMetadata.Resources = nil
+	for header, metadata := range map[string]interface{}{
+		types.DidResolutionMetadataHeader: resolution.ResolutionMetadata,
+		types.DidDocumentMetadataHeader:   documentMetadata,
+	} {
+		value, err := json.Marshal(metadata)
+		if err != nil {
+			return types.NewInternalError(dd.GetDid(), dd.GetContentType(), err, dd.IsDereferencing)
+		}
+		c.Response().Header().Set(header, string(value))
+	}
+
+	return RespondWithRepresentation(c, http.StatusOK, dd.GetContentType(), resolution.Did)
+}
+
+// RespondWithResourceData streams the resource data and answers Range requests with the requested parts of it,
+// so that large resources can be downloaded in pieces. If-Range is checked against the validators set by SetupCaching.
 func (dd *BaseRequestService) RespondWithResourceData(c ResolverContext) error {
 	c.Response().Header().Set(echo.HeaderContentType, dd.Result.GetContentType())
 
-	return c.Blob(http.StatusOK, dd.Result.GetContentType(), dd.Result.GetBytes())
+	lastModified, _ := http.ParseTime(c.Response().Header().Get(echo.HeaderLastModified))
+	size := c.Response().Size
+	http.ServeContent(c.Response(), c.Request(), "", lastModified, bytes.NewReader(dd.Result.GetBytes()))
+
+	_, namespace, _, _ := utils.TrySplitDID(dd.Did)
+	metrics.AddResourceBytes(namespace, c.Response().Size-size)
+	return nil
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"github.com/cheqd/did-resolver/services/tracing"
 	"github.com/cheqd/did-resolver/types"
 	echo "github.com/labstack/echo/v4"
 )
@@ -41,19 +42,21 @@ type RequestServiceI interface {
 	Respond(c ResolverContext) error
 }
 
-// The main flow for all the requests
+// The main flow for all the requests. The preparations, validation, query and response are traced as separate steps.
 func EchoWrapHandler(controller RequestServiceI) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		rc := c.(ResolverContext)
-		// Setup
-		if err := controller.Setup(rc); err != nil {
-			return err
-		}
-		// Preparations, like get parameters from context and others
-		if err := controller.BasicPrepare(rc); err != nil {
-			return err
-		}
-		if err := controller.SpecificPrepare(rc); err != nil {
+		// Setup and preparations, like get parameters from context and others
+		err := tracing.Step(rc, "prepare", func() error {
+			if err := controller.Setup(rc); err != nil {
+				return err
+			}
+			if err := controller.BasicPrepare(rc); err != nil {
+				return err
+			}
+			return controller.SpecificPrepare(rc)
+		})
+		if err != nil {
 			return err
 		}
 		// Redirect if needed
@@ -61,20 +64,29 @@ func EchoWrapHandler(controller RequestServiceI) echo.HandlerFunc {
 			return controller.Redirect(rc)
 		}
 		// Validation
-		if err := controller.BasicValidation(rc); err != nil {
-			return err
-		}
-		if err := controller.SpecificValidation(rc); err != nil {
+		err = tracing.Step(rc, "validate", func() error {
+			if err := controller.BasicValidation(rc); err != nil {
+				return err
+			}
+			return controller.SpecificValidation(rc)
+		})
+		if err != nil {
 			return err
 		}
 		// Query
-		if err := controller.Query(rc); err != nil {
-			return err
-		}
-		// Make response. Set specific headers, etc.
-		if err := controller.SetupResponse(rc); err != nil {
+		if err = tracing.Step(rc, "query", func() error { return controller.Query(rc) }); err != nil {
 			return err
 		}
-		return controller.Respond(rc)
+		return tracing.Step(rc, "respond", func() error {
+			// Make response. Set specific headers, etc.
+			if err := controller.SetupResponse(rc); err != nil {
+				return err
+			}
+			// Conditional requests may be answered already, with 304 Not Modified
+			if rc.Response().Committed {
+				return nil
+			}
+			return controller.Respond(rc)
+		})
 	}
 }
@@ -14,12 +14,20 @@ import (
 //	@Produce		*/*
 //	@Param			did			path		string	true	"Full DID with unique identifier"
 //	@Param			resourceId	path		string	true	"Resource-specific unique-identifier"
+//	@Param			blockHeight	query		integer	false	"Resolve at the given ledger block height"
+//	@Param			Range		header		string	false	"Byte ranges of the resource data, e.g. bytes=0-1023"
+//	@Param			If-Range	header		string	false	"ETag or Last-Modified date of the resource the ranges apply to"
 //	@Success		200			{object}	[]byte
+//	@Success		206			{object}	[]byte
 //	@Failure		400			{object}	types.IdentityError
 //	@Failure		404			{object}	types.IdentityError
 //	@Failure		406			{object}	types.IdentityError
+//	@Failure		410			{object}	types.IdentityError
 //	@Failure		500			{object}	types.IdentityError
 //	@Failure		501			{object}	types.IdentityError
+//	@Failure		502			{object}	types.IdentityError
+//	@Failure		503			{object}	types.IdentityError
+//	@Failure		504			{object}	types.IdentityError
 //	@Router			/{did}/resources/{resourceId} [get]
 func ResourceDataEchoHandler(c echo.Context) error {
 	return services.EchoWrapHandler(&ResourceDataDereferencingService{})(c)
@@ -30,16 +38,20 @@ func ResourceDataEchoHandler(c echo.Context) error {
 //	@Summary		Fetch Resource-specific metadata
 //	@Description	Get metadata for a specific Resource within a DID Resource Collection
 //	@Tags			Resource Resolution
-//	@Accept			application/did+ld+json,application/ld+json,application/did+json
-//	@Produce		application/did+ld+json,application/ld+json,application/did+json
+//	@Accept			application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
+//	@Produce		application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
 //	@Param			did			path		string	true	"Full DID with unique identifier"
 //	@Param			resourceId	path		string	true	"Resource-specific unique identifier"
+//	@Param			blockHeight	query		integer	false	"Resolve at the given ledger block height"
 //	@Success		200			{object}	types.DidDereferencing
 //	@Failure		400			{object}	types.IdentityError
 //	@Failure		404			{object}	types.IdentityError
 //	@Failure		406			{object}	types.IdentityError
+//	@Failure		410			{object}	types.IdentityError
 //	@Failure		500			{object}	types.IdentityError
 //	@Failure		501			{object}	types.IdentityError
+//	@Failure		503			{object}	types.IdentityError
+//	@Failure		504			{object}	types.IdentityError
 //	@Router			/{did}/resources/{resourceId}/metadata [get]
 func ResourceMetadataEchoHandler(c echo.Context) error {
 	return services.EchoWrapHandler(&ResourceMetadataDereferencingService{})(c)
@@ -50,15 +62,21 @@ func ResourceMetadataEchoHandler(c echo.Context) error {
 //	@Summary		Fetch metadata for all Resources
 //	@Description	Get metadata for all Resources within a DID Resource Collection
 //	@Tags			Resource Resolution
-//	@Accept			application/did+ld+json,application/ld+json,application/did+json
-//	@Produce		application/did+ld+json,application/ld+json,application/did+json
-//	@Param			did	path		string	true	"Full DID with unique identifier"
-//	@Success		200	{object}	types.ResourceDereferencing{contentStream=types.ResolutionDidDocMetadata}
-//	@Failure		400	{object}	types.IdentityError
-//	@Failure		404	{object}	types.IdentityError
-//	@Failure		406	{object}	types.IdentityError
-//	@Failure		500	{object}	types.IdentityError
-//	@Failure		501	{object}	types.IdentityError
+//	@Accept			application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
+//	@Produce		application/did+ld+json,application/ld+json,application/did+json,application/did+cbor
+//	@Param			did			path		string	true	"Full DID with unique identifier"
+//	@Param			blockHeight	query		integer	false	"Resolve at the given ledger block height"
+//	@Param			limit		query		integer	false	"Maximum number of resources to return"
+//	@Param			offset		query		integer	false	"Number of resources to skip"
+//	@Success		200			{object}	types.ResourceDereferencing{contentStream=types.ResolutionDidDocMetadata}
+//	@Failure		400			{object}	types.IdentityError
+//	@Failure		404			{object}	types.IdentityError
+//	@Failure		406			{object}	types.IdentityError
+//	@Failure		410			{object}	types.IdentityError
+//	@Failure		500			{object}	types.IdentityError
+//	@Failure		501			{object}	types.IdentityError
+//	@Failure		503			{object}	types.IdentityError
+//	@Failure		504			{object}	types.IdentityError
 //	@Router			/{did}/metadata [get]
 func ResourceCollectionEchoHandler(c echo.Context) error {
 	return services.EchoWrapHandler(&ResourceCollectionDereferencingService{})(c)
@@ -10,6 +10,7 @@ import (
 
 type ResourceCollectionDereferencingService struct {
 	services.BaseRequestService
+	Page       *types.Page
 	ResourceId string
 }
 
@@ -30,18 +31,31 @@ func (dr ResourceCollectionDereferencingService) Redirect(c services.ResolverCon
 }
 
 func (dr *ResourceCollectionDereferencingService) SpecificValidation(c services.ResolverContext) error {
-	// We not allow query here
-	if len(dr.Queries) != 0 {
+	// Only paging queries are allowed here
+	if len(types.PagingQueries.DiffWithUrlValues(dr.Queries)) != 0 {
 		return types.NewInvalidDidUrlError(dr.GetDid(), dr.RequestedContentType, nil, dr.IsDereferencing)
 	}
+
+	page, err := types.NewPage(dr.Queries)
+	if err != nil {
+		return types.NewInvalidDidUrlError(dr.GetDid(), dr.RequestedContentType, err, dr.IsDereferencing)
+	}
+	dr.Page = page
+
 	return nil
 }
 
 func (dr *ResourceCollectionDereferencingService) Query(c services.ResolverContext) error {
-	result, err := c.ResourceService.DereferenceCollectionResources(dr.GetDid(), dr.GetContentType())
+	result, err := c.ResourceService.DereferenceCollectionResources(c.Request().Context(), dr.GetDid(), dr.GetContentType())
 	if err != nil {
 		err.IsDereferencing = dr.IsDereferencing
 		return err
 	}
+
+	if metadata, ok := result.ContentStream.(*types.ResolutionDidDocMetadata); ok && dr.Page != nil {
+		start, end := dr.Page.Bounds(len(metadata.Resources))
+		metadata.Resources = metadata.Resources[start:end]
+	}
+
 	return dr.SetResponse(result)
 }
@@ -44,7 +44,7 @@ func (dr *ResourceDataDereferencingService) SpecificValidation(c services.Resolv
 }
 
 func (dr *ResourceDataDereferencingService) Query(c services.ResolverContext) error {
-	result, err := c.ResourceService.DereferenceResourceData(dr.GetDid(), dr.ResourceId, dr.GetContentType())
+	result, err := c.ResourceService.DereferenceResourceData(c.Request().Context(), dr.GetDid(), dr.ResourceId, dr.GetContentType())
 	if err != nil {
 		err.IsDereferencing = dr.IsDereferencing
 		return err
@@ -44,7 +44,7 @@ func (dr *ResourceMetadataDereferencingService) SpecificValidation(c services.Re
 }
 
 func (dr *ResourceMetadataDereferencingService) Query(c services.ResolverContext) error {
-	result, err := c.ResourceService.DereferenceResourceMetadata(dr.GetDid(), dr.ResourceId, dr.GetContentType())
+	result, err := c.ResourceService.DereferenceResourceMetadata(c.Request().Context(), dr.GetDid(), dr.ResourceId, dr.GetContentType())
 	if err != nil {
 		err.IsDereferencing = dr.IsDereferencing
 		return err
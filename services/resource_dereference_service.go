@@ -4,8 +4,11 @@ import (
 	// jsonpb Marshaller is deprecated, but is needed because there's only one way to proto
 	// marshal in combination with our proto generator version
 
+	"context"
+	"fmt"
 	"strings"
 
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
 	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
 	"github.com/cheqd/did-resolver/types"
 )
@@ -13,45 +16,79 @@ import (
 type ResourceService struct {
 	didMethod     string
 	ledgerService LedgerServiceI
+	// Resource data larger than this number of bytes is not served
+	maxSize int
 }
 
-func NewResourceService(didMethod string, ledgerService LedgerServiceI) ResourceService {
+func NewResourceService(didMethod string, ledgerService LedgerServiceI, maxSize int) ResourceService {
 	return ResourceService{
 		didMethod:     didMethod,
 		ledgerService: ledgerService,
+		maxSize:       maxSize,
 	}
 }
 
-func (rds ResourceService) DereferenceResourceMetadata(did string, resourceId string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
+func (rds ResourceService) DereferenceResourceMetadata(ctx context.Context, did string, resourceId string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
 	dereferenceMetadata := types.NewDereferencingMetadata(did, contentType, "")
+	dereferenceMetadata.BlockHeight, _ = BlockHeightFromContext(ctx)
+	dereferenceMetadata.Proven = isLedgerProven(rds.ledgerService, did)
 
-	resource, err := rds.ledgerService.QueryResource(did, strings.ToLower(resourceId))
+	// The listing of the collection holds the metadata without the data, which may be too large to be served
+	resources, err := rds.ledgerService.QueryCollectionResources(ctx, did)
 	if err != nil {
 		err.ContentType = contentType
 		return nil, err
 	}
 
+	var metadata *resourceTypes.Metadata
+	for _, resource := range resources {
+		if resource.Id == strings.ToLower(resourceId) {
+			metadata = resource
+			break
+		}
+	}
+	if metadata == nil {
+		return nil, types.NewNotFoundError(did, contentType, nil, true)
+	}
+
 	var context string
 	if contentType == types.DIDJSONLD || contentType == types.JSONLD {
 		context = types.ResolutionSchemaJSONLD
 	}
 
-	contentStream := types.NewDereferencedResourceListStruct(did, []*resourceTypes.Metadata{resource.Metadata})
+	contentStream := types.NewDereferencedResourceListStruct(did, []*resourceTypes.Metadata{metadata})
 
 	return &types.ResourceDereferencing{Context: context, ContentStream: contentStream, DereferencingMetadata: dereferenceMetadata}, nil
 }
 
-func (rds ResourceService) DereferenceCollectionResources(did string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
+func (rds ResourceService) DereferenceCollectionResources(ctx context.Context, did string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
 	dereferenceMetadata := types.NewDereferencingMetadata(did, contentType, "")
+	dereferenceMetadata.BlockHeight, _ = BlockHeightFromContext(ctx)
+	dereferenceMetadata.Proven = isLedgerProven(rds.ledgerService, did)
 
-	didDoc, err := rds.ledgerService.QueryDIDDoc(did, "")
+	// The queries are verified against the same state
+	ctx, err := pinTrustedRoot(ctx, rds.ledgerService, did)
 	if err != nil {
+		err.ContentType = contentType
 		return nil, err
 	}
 
-	resources, err := rds.ledgerService.QueryCollectionResources(did)
+	var didDoc *didTypes.DidDocWithMetadata
+	var resources []*resourceTypes.Metadata
+	err = queryLedgerConcurrently(ctx,
+		func(ctx context.Context) (err *types.IdentityError) {
+			didDoc, err = rds.ledgerService.QueryDIDDoc(ctx, did, "")
+			return err
+		},
+		func(ctx context.Context) (err *types.IdentityError) {
+			resources, err = rds.ledgerService.QueryCollectionResources(ctx, did)
+			if err != nil {
+				err.ContentType = contentType
+			}
+			return err
+		},
+	)
 	if err != nil {
-		err.ContentType = contentType
 		return nil, err
 	}
 
@@ -65,17 +102,26 @@ func (rds ResourceService) DereferenceCollectionResources(did string, contentTyp
 	return &types.ResourceDereferencing{Context: context, ContentStream: &contentStream, DereferencingMetadata: dereferenceMetadata}, nil
 }
 
-func (rds ResourceService) DereferenceResourceData(did string, resourceId string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
+func (rds ResourceService) DereferenceResourceData(ctx context.Context, did string, resourceId string, contentType types.ContentType) (*types.ResourceDereferencing, *types.IdentityError) {
 	dereferenceMetadata := types.NewDereferencingMetadata(did, contentType, "")
+	dereferenceMetadata.BlockHeight, _ = BlockHeightFromContext(ctx)
+	dereferenceMetadata.Proven = isLedgerProven(rds.ledgerService, did)
 
-	resource, err := rds.ledgerService.QueryResource(did, strings.ToLower(resourceId))
+	resource, err := rds.ledgerService.QueryResource(ctx, did, strings.ToLower(resourceId))
 	if err != nil {
 		err.ContentType = contentType
 		return nil, err
 	}
 
+	if len(resource.Resource.Data) > rds.maxSize {
+		err := fmt.Errorf("resource data of %d bytes exceeds the maximum resource size of %d bytes", len(resource.Resource.Data), rds.maxSize)
+		return nil, types.NewResourceTooLargeError(did, contentType, err, true)
+	}
+
 	result := types.DereferencedResourceData(resource.Resource.Data)
 	dereferenceMetadata.ContentType = types.ContentType(resource.Metadata.MediaType)
 
-	return &types.ResourceDereferencing{ContentStream: &result, DereferencingMetadata: dereferenceMetadata}, nil
+	metadata := types.NewResolutionResourceMetadata(resource.Metadata)
+
+	return &types.ResourceDereferencing{ContentStream: &result, DereferencingMetadata: dereferenceMetadata, Metadata: metadata}, nil
 }
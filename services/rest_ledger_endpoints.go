@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cheqd/did-resolver/types"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	tmservice "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// restEndpointSet sends the queries of a namespace to the REST API of its nodes.
+// REST endpoints are not probed in background: an endpoint is unhealthy until it answers again.
+// Errors of the nodes are converted to gRPC statuses, so they are classified the same way as the errors of the gRPC API.
+type restEndpointSet = endpointSet[*resty.Client]
+
+func newRESTEndpointSet(network types.Network) (*restEndpointSet, error) {
+	tlsConfig, err := types.NewTLSConfig(network.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEndpointSet(network, "Ledger REST endpoint", func(config types.Endpoint) endpointTransport[*resty.Client] {
+		scheme := "http://"
+		if config.UseTls {
+			scheme = "https://"
+		}
+
+		client := resty.New().
+			SetBaseURL(scheme+config.Address).
+			SetHeader("Accept", "application/json").
+			SetTLSClientConfig(tlsConfig)
+		// The transport is traced once it's configured, resty can't configure it after it's wrapped
+		transport, _ := client.Transport()
+		client.SetTransport(otelhttp.NewTransport(transport))
+
+		return &restTransport{client: client, transport: transport}
+	}), nil
+}
+
+// restTransport sends the queries to the REST API of a node
+type restTransport struct {
+	client *resty.Client
+	// Transport wrapped by the tracing one, which doesn't close idle connections
+	transport *http.Transport
+}
+
+func (t *restTransport) Client() (*resty.Client, error) {
+	return t.client, nil
+}
+
+// Probe asks the tendermint service of the node whether it is catching up with the chain
+func (t *restTransport) Probe(ctx context.Context) error {
+	var response tmservice.GetSyncingResponse
+	err := getProto(ctx, t.client, "/cosmos/base/tendermint/v1beta1/syncing", nil, &response)
+	switch {
+	// Node answered, but doesn't expose the tendermint service
+	case status.Code(err) == codes.NotFound, status.Code(err) == codes.Unimplemented:
+		return nil
+	case err == nil && response.Syncing:
+		return errors.New("node is catching up")
+	}
+
+	return err
+}
+
+func (t *restTransport) Close() error {
+	t.transport.CloseIdleConnections()
+	return nil
+}
+
+// restError is the body of failed responses of the Cosmos SDK REST API
+type restError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// getProto requests the path and decodes the response into the proto message
+func getProto(ctx context.Context, client *resty.Client, path string, params url.Values, response proto.Message) error {
+	request := client.R().SetContext(ctx).SetQueryParamsFromValues(params)
+	if height, ok := BlockHeightFromContext(ctx); ok {
+		request.SetHeader(BlockHeightHeader, strconv.FormatInt(height, 10))
+	}
+
+	resp, err := request.Get(path)
+	if err != nil {
+		// Report transport failures as gRPC would, so the next endpoint is tried
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	if !resp.IsSuccess() {
+		var body restError
+		if json.Unmarshal(resp.Body(), &body) == nil && body.Message != "" {
+			return status.Error(codes.Code(body.Code), body.Message)
+		}
+		return status.Error(httpStatusToCode(resp.StatusCode()), resp.Status())
+	}
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(resp.Body(), response); err != nil {
+		return status.Error(codes.Internal, "malformed ledger response: "+err.Error())
+	}
+
+	return nil
+}
+
+// pageParams encodes the page request as query parameters of the REST API
+func pageParams(page *queryTypes.PageRequest) url.Values {
+	params := url.Values{}
+	if len(page.Key) != 0 {
+		params.Set("pagination.key", base64.StdEncoding.EncodeToString(page.Key))
+	}
+	params.Set("pagination.limit", strconv.FormatUint(page.Limit, 10))
+
+	return params
+}
+
+// httpStatusToCode maps statuses of responses without an error body, e.g. from proxies
+func httpStatusToCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
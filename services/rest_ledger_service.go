@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/cheqd/did-resolver/utils"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	didRESTPrefix      = "/cheqd/did/v2/"
+	resourceRESTPrefix = "/cheqd/resource/v2/"
+)
+
+// RESTLedgerService queries the REST (LCD) API of the nodes.
+// It is an alternative to LedgerService for networks which can't be reached over gRPC.
+type RESTLedgerService struct {
+	ledgers   map[string]types.Network    // namespace -> endpoints with configs
+	endpoints map[string]*restEndpointSet // namespace -> clients of the endpoints
+}
+
+func NewRESTLedgerService() RESTLedgerService {
+	ls := RESTLedgerService{}
+	ls.ledgers = make(map[string]types.Network)
+	ls.endpoints = make(map[string]*restEndpointSet)
+
+	return ls
+}
+
+func (ls RESTLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	method, namespace, _, _ := utils.TrySplitDID(did)
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
+	if !namespaceFound {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
+	}
+
+	var didDoc *didTypes.DidDocWithMetadata
+	err := endpoints.Call(ctx, "Querying DIDDoc: "+did, func(ctx context.Context, client *resty.Client) error {
+		if version == "" {
+			response := &didTypes.QueryDidDocResponse{}
+			if err := getProto(ctx, client, didRESTPrefix+url.PathEscape(did), nil, response); err != nil {
+				return err
+			}
+			didDoc = response.Value
+		} else {
+			response := &didTypes.QueryDidDocVersionResponse{}
+			if err := getProto(ctx, client, didRESTPrefix+url.PathEscape(did)+"/version/"+url.PathEscape(version), nil, response); err != nil {
+				return err
+			}
+			didDoc = response.Value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, newLedgerError(ctx, "QueryDIDDoc", did, err, false)
+	}
+
+	return didDoc, nil
+}
+
+func (ls RESTLedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
+	method, namespace, _, _ := utils.TrySplitDID(did)
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
+	if !namespaceFound {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
+	}
+
+	var versions []*didTypes.Metadata
+	err := endpoints.Call(ctx, "Querying all DIDDoc versions metadata: "+did, func(ctx context.Context, client *resty.Client) error {
+		// Start over if the previous endpoint failed in the middle of the list
+		versions = nil
+		return walkPages(endpoints.network, func(page *queryTypes.PageRequest) (*queryTypes.PageResponse, error) {
+			response := &didTypes.QueryAllDidDocVersionsMetadataResponse{}
+			if err := getProto(ctx, client, didRESTPrefix+url.PathEscape(did)+"/versions", pageParams(page), response); err != nil {
+				return nil, err
+			}
+			versions = append(versions, response.Versions...)
+
+			return response.Pagination, nil
+		})
+	})
+	if err != nil {
+		return nil, newLedgerError(ctx, "QueryAllDidDocVersionsMetadata", did, err, false)
+	}
+
+	return versions, nil
+}
+
+func (ls RESTLedgerService) QueryResource(ctx context.Context, did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+	method, namespace, collectionId, _ := utils.TrySplitDID(did)
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
+	if !namespaceFound {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, true)
+	}
+
+	var resource *resourceTypes.ResourceWithMetadata
+	err := endpoints.Call(ctx, "Querying DID resource: "+collectionId+", "+resourceId, func(ctx context.Context, client *resty.Client) error {
+		response := &resourceTypes.QueryResourceResponse{}
+		if err := getProto(ctx, client, resourceRESTPrefix+url.PathEscape(collectionId)+"/resources/"+url.PathEscape(resourceId), nil, response); err != nil {
+			return err
+		}
+		resource = response.Resource
+
+		return nil
+	})
+	if err != nil {
+		return nil, newResourceLedgerError(ctx, did, err)
+	}
+
+	return resource, nil
+}
+
+func (ls RESTLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	method, namespace, collectionId, _ := utils.TrySplitDID(did)
+	endpoints, namespaceFound := ls.endpoints[method+DELIMITER+namespace]
+	if !namespaceFound {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, false)
+	}
+
+	var resources []*resourceTypes.Metadata
+	err := endpoints.Call(ctx, "Querying DID resources: "+did, func(ctx context.Context, client *resty.Client) error {
+		// Start over if the previous endpoint failed in the middle of the list
+		resources = nil
+		return walkPages(endpoints.network, func(page *queryTypes.PageRequest) (*queryTypes.PageResponse, error) {
+			response := &resourceTypes.QueryCollectionResourcesResponse{}
+			if err := getProto(ctx, client, resourceRESTPrefix+url.PathEscape(collectionId)+"/metadata", pageParams(page), response); err != nil {
+				return nil, err
+			}
+			resources = append(resources, response.Resources...)
+
+			return response.Pagination, nil
+		})
+	})
+	if err != nil {
+		return nil, newLedgerError(ctx, "QueryCollectionResources", did, err, false)
+	}
+
+	return resources, nil
+}
+
+func (ls *RESTLedgerService) RegisterLedger(method string, endpoint types.Network) error {
+	if endpoint.Namespace == "" || method == "" {
+		err := errors.New("namespace and method cannot be empty")
+		log.Error().Err(err).Msg("RegisterLedger: failed")
+		return err
+	}
+
+	if len(endpoint.Endpoints) == 0 {
+		return errors.New("ledger node URL cannot be empty")
+	}
+	for _, e := range endpoint.Endpoints {
+		if e.Address == "" {
+			return errors.New("ledger node URL cannot be empty")
+		}
+	}
+
+	endpoints, err := newRESTEndpointSet(endpoint)
+	if err != nil {
+		return fmt.Errorf("TLS options for %s are invalid: %w", endpoint.Namespace, err)
+	}
+
+	key := method + DELIMITER + endpoint.Namespace
+	if old, ok := ls.endpoints[key]; ok {
+		_ = old.Close()
+	}
+
+	ls.ledgers[key] = endpoint
+	ls.endpoints[key] = endpoints
+
+	return nil
+}
+
+// Close releases idle connections to the nodes
+func (ls RESTLedgerService) Close() error {
+	var errs []error
+	for _, endpoints := range ls.endpoints {
+		if err := endpoints.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (ls RESTLedgerService) NetworkStatus(method string, namespace string) (types.NetworkStatus, bool) {
+	key := method + DELIMITER + namespace
+	endpoints, ok := ls.endpoints[key]
+	if !ok {
+		return types.NetworkStatus{}, false
+	}
+
+	return types.NetworkStatus{
+		Method:    method,
+		Namespace: namespace,
+		Api:       types.RESTApi,
+		Endpoints: endpoints.Status(),
+	}, true
+}
+
+// Probe checks the endpoints of the namespace, it fails unless one of them is healthy
+func (ls RESTLedgerService) Probe(ctx context.Context, method string, namespace string) error {
+	endpoints, ok := ls.endpoints[method+DELIMITER+namespace]
+	if !ok {
+		return fmt.Errorf("namespace %s is not registered", namespace)
+	}
+
+	return endpoints.Probe(ctx)
+}
+
+func (ls RESTLedgerService) GetNamespaces() []string {
+	keys := make([]string, 0, len(ls.ledgers))
+	for k := range ls.ledgers {
+		namespace := strings.Split(k, DELIMITER)[1]
+		keys = append(keys, namespace)
+	}
+
+	return keys
+}
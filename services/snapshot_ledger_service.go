@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/cheqd/did-resolver/utils"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// Changes made within the interval are applied with a single reload
+const snapshotReloadDelay = 500 * time.Millisecond
+
+// SnapshotLedgerService serves the ledger data exported to local files, without any network access.
+// Snapshots are reloaded when their files change.
+type SnapshotLedgerService struct {
+	ledgers   map[string]types.Network         // namespace -> snapshot config
+	snapshots map[string]*ledgerSnapshotSource // namespace -> loaded snapshot
+}
+
+func NewSnapshotLedgerService() SnapshotLedgerService {
+	ls := SnapshotLedgerService{}
+	ls.ledgers = make(map[string]types.Network)
+	ls.snapshots = make(map[string]*ledgerSnapshotSource)
+
+	return ls
+}
+
+// snapshot returns the current snapshot of the namespace of the DID.
+// Snapshots have no history, so queries at a block height can't be served.
+func (ls SnapshotLedgerService) snapshot(ctx context.Context, did string, isDereferencing bool) (*ledgerSnapshot, *types.IdentityError) {
+	method, namespace, _, _ := utils.TrySplitDID(did)
+	source, namespaceFound := ls.snapshots[method+DELIMITER+namespace]
+	if !namespaceFound {
+		return nil, types.NewInvalidDidError(did, types.JSON, nil, isDereferencing)
+	}
+	if _, ok := BlockHeightFromContext(ctx); ok {
+		return nil, types.NewBlockHeightNotAvailableError(did, types.JSON, errors.New("ledger snapshot has no history"), isDereferencing)
+	}
+
+	return source.current.Load(), nil
+}
+
+func (ls SnapshotLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	snapshot, err := ls.snapshot(ctx, did, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == "" {
+		if didDoc := snapshot.latestDidDoc(did); didDoc != nil {
+			return didDoc, nil
+		}
+		return nil, types.NewNotFoundError(did, types.JSON, nil, false)
+	}
+
+	for _, didDoc := range snapshot.didDocs[did] {
+		if didDoc.Metadata.VersionId == version {
+			return didDoc, nil
+		}
+	}
+
+	return nil, types.NewNotFoundError(did, types.JSON, nil, false)
+}
+
+func (ls SnapshotLedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
+	snapshot, err := ls.snapshot(ctx, did, false)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := snapshot.didDocs[did]
+	if len(versions) == 0 {
+		return nil, types.NewNotFoundError(did, types.JSON, nil, false)
+	}
+
+	metadata := make([]*didTypes.Metadata, len(versions))
+	for i, version := range versions {
+		metadata[i] = version.Metadata
+	}
+
+	return metadata, nil
+}
+
+func (ls SnapshotLedgerService) QueryResource(ctx context.Context, did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+	snapshot, err := ls.snapshot(ctx, did, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range snapshot.resources[did] {
+		if resource.Metadata.Id == resourceId {
+			return resource, nil
+		}
+	}
+
+	return nil, types.NewNotFoundError(did, types.JSON, nil, true)
+}
+
+func (ls SnapshotLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	snapshot, err := ls.snapshot(ctx, did, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ledger lists resources of existing DIDs only
+	if len(snapshot.didDocs[did]) == 0 {
+		return nil, types.NewNotFoundError(did, types.JSON, nil, false)
+	}
+
+	resources := snapshot.resources[did]
+	metadata := make([]*resourceTypes.Metadata, len(resources))
+	for i, resource := range resources {
+		metadata[i] = resource.Metadata
+	}
+
+	return metadata, nil
+}
+
+// RegisterLedger loads the snapshot of the network and starts watching its files for changes
+func (ls *SnapshotLedgerService) RegisterLedger(method string, network types.Network) error {
+	if network.Namespace == "" || method == "" {
+		err := errors.New("namespace and method cannot be empty")
+		log.Error().Err(err).Msg("RegisterLedger: failed")
+		return err
+	}
+	if network.SnapshotPath == "" {
+		return errors.New("ledger snapshot path cannot be empty")
+	}
+
+	source, err := newLedgerSnapshotSource(network.SnapshotPath, method, network.Namespace)
+	if err != nil {
+		return err
+	}
+
+	key := method + DELIMITER + network.Namespace
+	if old, ok := ls.snapshots[key]; ok {
+		_ = old.Close()
+	}
+
+	ls.ledgers[key] = network
+	ls.snapshots[key] = source
+
+	return nil
+}
+
+// Close stops watching the snapshot files
+func (ls SnapshotLedgerService) Close() error {
+	var errs []error
+	for _, source := range ls.snapshots {
+		if err := source.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NetworkStatus reports no endpoints, snapshots are served from local files
+func (ls SnapshotLedgerService) NetworkStatus(method string, namespace string) (types.NetworkStatus, bool) {
+	if _, ok := ls.snapshots[method+DELIMITER+namespace]; !ok {
+		return types.NetworkStatus{}, false
+	}
+
+	return types.NetworkStatus{
+		Method:    method,
+		Namespace: namespace,
+		Api:       types.SnapshotApi,
+		Endpoints: []types.EndpointStatus{},
+	}, true
+}
+
+func (ls SnapshotLedgerService) GetNamespaces() []string {
+	keys := make([]string, 0, len(ls.ledgers))
+	for k := range ls.ledgers {
+		namespace := strings.Split(k, DELIMITER)[1]
+		keys = append(keys, namespace)
+	}
+
+	return keys
+}
+
+// ledgerSnapshotSource keeps the snapshot loaded from the path up to date with the files
+type ledgerSnapshotSource struct {
+	path      string
+	method    string
+	namespace string
+	current   atomic.Pointer[ledgerSnapshot]
+
+	watcher  *fsnotify.Watcher
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newLedgerSnapshotSource(path string, method string, namespace string) (*ledgerSnapshotSource, error) {
+	snapshot, err := loadLedgerSnapshot(path, method, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	source := &ledgerSnapshotSource{
+		path:      path,
+		method:    method,
+		namespace: namespace,
+		watcher:   watcher,
+		stop:      make(chan struct{}),
+	}
+	source.current.Store(snapshot)
+	if err := source.watchDirs(); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	log.Info().Msgf("Loaded ledger snapshot of %s from %s", namespace, path)
+
+	go source.run()
+
+	return source, nil
+}
+
+// watchDirs watches all the directories of the snapshot, including the ones created after the start.
+// Archives are watched through their directory, so replacing the file is noticed as well.
+func (s *ledgerSnapshotSource) watchDirs() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return s.watcher.Add(filepath.Dir(s.path))
+	}
+
+	return filepath.WalkDir(s.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		return s.watcher.Add(path)
+	})
+}
+
+func (s *ledgerSnapshotSource) isSnapshotFile(name string) bool {
+	if filepath.Clean(name) == filepath.Clean(s.path) {
+		return true
+	}
+	rel, err := filepath.Rel(s.path, name)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+func (s *ledgerSnapshotSource) run() {
+	reload := time.NewTimer(snapshotReloadDelay)
+	reload.Stop()
+	defer reload.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			if s.isSnapshotFile(event.Name) {
+				reload.Reset(snapshotReloadDelay)
+			}
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error().Err(err).Msgf("Watching ledger snapshot %s failed", s.path)
+		case <-reload.C:
+			s.reload()
+		}
+	}
+}
+
+// reload replaces the snapshot with the files on disk. The old snapshot is kept if the files are invalid.
+func (s *ledgerSnapshotSource) reload() {
+	snapshot, err := loadLedgerSnapshot(s.path, s.method, s.namespace)
+	if err != nil {
+		log.Error().Err(err).Msgf("Reloading ledger snapshot %s failed, keeping the previous one", s.path)
+		return
+	}
+	s.current.Store(snapshot)
+	log.Info().Msgf("Reloaded ledger snapshot of %s from %s", s.namespace, s.path)
+
+	if err := s.watchDirs(); err != nil {
+		log.Error().Err(err).Msgf("Watching ledger snapshot %s failed", s.path)
+	}
+}
+
+func (s *ledgerSnapshotSource) Close() error {
+	var err error
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		err = s.watcher.Close()
+	})
+
+	return err
+}
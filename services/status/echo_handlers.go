@@ -0,0 +1,15 @@
+package status
+
+import (
+	"net/http"
+
+	"github.com/cheqd/did-resolver/services"
+	"github.com/labstack/echo/v4"
+)
+
+// StatusEchoHandler reports the health and the circuit breaker state of the ledger endpoints of every network
+func StatusEchoHandler(router services.LedgerRouter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, router.Status())
+	}
+}
@@ -0,0 +1,11 @@
+package status
+
+import (
+	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+func SetRoutes(e *echo.Echo, router services.LedgerRouter) {
+	e.GET(types.STATUS_PATH, StatusEchoHandler(router))
+}
@@ -0,0 +1,95 @@
+// Package tracing traces the requests with OpenTelemetry: the steps of handling them, the query handlers
+// and the ledger queries. Spans are exported over OTLP to a collector, tracing is disabled without one.
+package tracing
+
+import (
+	"context"
+
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ServiceName = "did-resolver"
+
+var tracer = otel.Tracer("github.com/cheqd/did-resolver")
+
+// Setup exports the spans to the collector of the config and propagates W3C trace context,
+// from the clients of the resolver to the ledger nodes. The returned function flushes the spans left.
+func Setup(ctx context.Context, config types.TracingConfig) (func(context.Context) error, error) {
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Middleware starts the span of every request, continuing the trace of the client.
+// Probes and scrapes of the metrics aren't traced.
+func Middleware() echo.MiddlewareFunc {
+	return otelecho.Middleware(ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		switch c.Path() {
+		case types.HEALTH_LIVE_PATH, types.HEALTH_READY_PATH, types.METRICS_PATH:
+			return true
+		}
+		return false
+	}))
+}
+
+// Start starts a span as a child of the span in the context
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records the error, if any, and ends the span. Resolution errors set the status to their name, e.g. notFound.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if identityError, ok := err.(*types.IdentityError); ok {
+			span.SetStatus(codes.Error, identityError.Message)
+		} else {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// Step runs a step of handling the request within a span. The span is passed to the step in the request context,
+// so that the spans of the ledger queries are its children, and the values the step adds to it are kept.
+func Step(c echo.Context, name string, step func() error) error {
+	parent := trace.SpanFromContext(c.Request().Context())
+	ctx, span := Start(c.Request().Context(), name)
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	err := step()
+	End(span, err)
+
+	c.SetRequest(c.Request().WithContext(trace.ContextWithSpan(c.Request().Context(), parent)))
+	return err
+}
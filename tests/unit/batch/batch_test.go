@@ -0,0 +1,187 @@
+//go:build unit
+
+package batch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	batchServices "github.com/cheqd/did-resolver/services/batch"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Batch resolution", func() {
+	var e *echo.Echo
+
+	BeforeEach(func() {
+		e = utils.SetupEcho(utils.MockLedger)
+		batchServices.SetRoutes(e, types.BatchConfig{MaxItems: 5, Concurrency: 2, MaxBodySize: 1024})
+	})
+
+	postBatch := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, types.BATCH_PATH, strings.NewReader(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request)
+
+		return rec
+	}
+
+	resolveBatch := func(items ...interface{}) []types.BatchResult {
+		body, err := json.Marshal(items)
+		Expect(err).To(BeNil())
+
+		rec := postBatch(string(body))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var response types.BatchResponse
+		Expect(json.Unmarshal(rec.Body.Bytes(), &response)).To(Succeed())
+		Expect(response.Results).To(HaveLen(len(items)))
+
+		return response.Results
+	}
+
+	It("returns what single requests return, in the order of the items", func() {
+		results := resolveBatch(
+			testconstants.ExistentDid,
+			testconstants.ExistentDid+"#key-1",
+			testconstants.ExistentDid+types.RESOURCE_PATH+testconstants.ExistentResourceId+"/metadata",
+			testconstants.ExistentDid+"/versions",
+		)
+
+		var resolution types.DidResolution
+		Expect(results[0].Status).To(Equal(http.StatusOK))
+		Expect(results[0].ContentType).To(Equal(string(types.DIDJSONLD)))
+		Expect(json.Unmarshal(results[0].Content, &resolution)).To(Succeed())
+		Expect(resolution.Did.Id).To(Equal(testconstants.ExistentDid))
+
+		var fragment struct {
+			ContentStream types.VerificationMethod `json:"contentStream"`
+		}
+		Expect(results[1].Status).To(Equal(http.StatusOK))
+		Expect(json.Unmarshal(results[1].Content, &fragment)).To(Succeed())
+		Expect(fragment.ContentStream.Id).To(Equal(testconstants.ExistentDid + "#key-1"))
+
+		var resourceMetadata struct {
+			ContentStream types.DereferencedResourceListStruct `json:"contentStream"`
+		}
+		Expect(results[2].Status).To(Equal(http.StatusOK))
+		Expect(json.Unmarshal(results[2].Content, &resourceMetadata)).To(Succeed())
+		Expect(resourceMetadata.ContentStream.Resources[0].ResourceId).To(Equal(testconstants.ExistentResourceId))
+
+		var versions struct {
+			ContentStream types.DereferencedDidVersionsList `json:"contentStream"`
+		}
+		Expect(results[3].Status).To(Equal(http.StatusOK))
+		Expect(json.Unmarshal(results[3].Content, &versions)).To(Succeed())
+		Expect(versions.ContentStream.Versions[0].VersionId).To(Equal(testconstants.ValidVersionId))
+
+		for i, result := range results {
+			Expect(result.DidUrl).To(Equal([]string{
+				testconstants.ExistentDid,
+				testconstants.ExistentDid + "#key-1",
+				testconstants.ExistentDid + types.RESOURCE_PATH + testconstants.ExistentResourceId + "/metadata",
+				testconstants.ExistentDid + "/versions",
+			}[i]))
+		}
+	})
+
+	It("applies the media type and the options of every item", func() {
+		results := resolveBatch(
+			types.BatchRequestItem{DidUrl: testconstants.ExistentDid, Accept: string(types.DIDJSON)},
+			types.BatchRequestItem{DidUrl: testconstants.ExistentDid, Options: map[string]string{types.VersionId: testconstants.ValidVersionId}},
+			types.BatchRequestItem{DidUrl: testconstants.ExistentDid, Accept: string(types.DIDCBOR)},
+		)
+
+		var resolution types.DidResolution
+		Expect(results[0].ContentType).To(Equal(string(types.DIDJSON)))
+		Expect(json.Unmarshal(results[0].Content, &resolution)).To(Succeed())
+		Expect(resolution.Did.Context).To(BeNil())
+
+		var versionResolution types.DidResolution
+		Expect(results[1].Status).To(Equal(http.StatusOK))
+		Expect(json.Unmarshal(results[1].Content, &versionResolution)).To(Succeed())
+		Expect(versionResolution.Metadata.VersionId).To(Equal(testconstants.ValidVersionId))
+
+		Expect(results[2].ContentType).To(Equal(string(types.DIDCBOR)))
+		Expect(results[2].Content).To(BeEmpty())
+		Expect(results[2].ContentBase64).ToNot(BeEmpty())
+	})
+
+	It("returns the errors of the items along with the other results", func() {
+		results := resolveBatch(
+			testconstants.NotExistentTestnetDid,
+			testconstants.ExistentDid,
+			"https://example.com",
+		)
+
+		var resolution types.DidResolution
+		Expect(results[0].Status).To(Equal(types.NotFoundHttpCode))
+		Expect(json.Unmarshal(results[0].Content, &resolution)).To(Succeed())
+		Expect(resolution.ResolutionMetadata.ResolutionError).To(Equal("notFound"))
+
+		Expect(results[1].Status).To(Equal(http.StatusOK))
+
+		var dereferencing types.DidDereferencing
+		Expect(results[2].Status).To(Equal(types.InvalidDidUrlHttpCode))
+		Expect(json.Unmarshal(results[2].Content, &dereferencing)).To(Succeed())
+		Expect(dereferencing.DereferencingMetadata.ResolutionError).To(Equal("invalidDidUrl"))
+	})
+
+	It("returns the redirects of service endpoints", func() {
+		results := resolveBatch(testconstants.ExistentDid + "?service=" + testconstants.ValidServiceId)
+
+		Expect(results[0].Status).To(Equal(http.StatusSeeOther))
+		Expect(results[0].Location).To(Equal("http://example.com"))
+	})
+
+	It("rejects batches which aren't lists of DID URLs", func() {
+		rec := postBatch(`{"didUrl": "` + testconstants.ExistentDid + `"}`)
+		Expect(rec.Code).To(Equal(types.InvalidDidUrlHttpCode))
+	})
+
+	It("rejects batches with too many items", func() {
+		items := make([]string, 6)
+		for i := range items {
+			items[i] = fmt.Sprintf("%q", testconstants.ExistentDid)
+		}
+
+		rec := postBatch("[" + strings.Join(items, ",") + "]")
+		Expect(rec.Code).To(Equal(types.InvalidDidUrlHttpCode))
+	})
+
+	It("rejects bodies larger than the limit", func() {
+		rec := postBatch(`["` + testconstants.ExistentDid + `"` + strings.Repeat(" ", 1024) + "]")
+		Expect(rec.Code).To(Equal(types.RequestTooLargeHttpCode))
+	})
+
+	It("rejects compressed bodies which are larger than the limit once decompressed", func() {
+		e.Use(middleware.Decompress())
+
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		_, err := writer.Write([]byte(`["` + testconstants.ExistentDid + `"` + strings.Repeat(" ", 64<<10) + "]"))
+		Expect(err).To(BeNil())
+		Expect(writer.Close()).To(Succeed())
+		Expect(compressed.Len()).To(BeNumerically("<", 1024))
+
+		request := httptest.NewRequest(http.MethodPost, types.BATCH_PATH, &compressed)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(echo.HeaderContentEncoding, "gzip")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request)
+		Expect(rec.Code).To(Equal(types.RequestTooLargeHttpCode))
+	})
+})
@@ -0,0 +1,169 @@
+//go:build unit
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+// countingLedgerService counts the queries which reached the ledger
+type countingLedgerService struct {
+	utils.MockLedgerService
+	calls *int
+}
+
+func (ls countingLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	*ls.calls++
+	return ls.MockLedgerService.QueryDIDDoc(ctx, did, version)
+}
+
+func (ls countingLedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
+	*ls.calls++
+	return ls.MockLedgerService.QueryAllDidDocVersionsMetadata(ctx, did)
+}
+
+func (ls countingLedgerService) QueryResource(ctx context.Context, did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+	*ls.calls++
+	return ls.MockLedgerService.QueryResource(ctx, did, resourceId)
+}
+
+func (ls countingLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	*ls.calls++
+	return ls.MockLedgerService.QueryCollectionResources(ctx, did)
+}
+
+var cacheConfig = types.CacheConfig{
+	Enabled:      true,
+	Size:         100,
+	MutableTTL:   time.Minute,
+	ImmutableTTL: time.Hour,
+	NotFoundTTL:  time.Minute,
+}
+
+var _ = Describe("Cached ledger service", func() {
+	var calls int
+	var ledger countingLedgerService
+
+	BeforeEach(func() {
+		calls = 0
+		ledger = countingLedgerService{MockLedgerService: utils.MockLedger, calls: &calls}
+	})
+
+	It("serves repeated queries from the cache", func() {
+		cachedLedger := services.NewCachedLedgerService(ledger, cacheConfig)
+
+		for i := 0; i < 3; i++ {
+			didDoc, err := cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).To(BeNil())
+			Expect(didDoc.DidDoc.Id).To(Equal(testconstants.ExistentDid))
+
+			_, err = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, testconstants.ValidVersionId)
+			Expect(err).To(BeNil())
+
+			versions, err := cachedLedger.QueryAllDidDocVersionsMetadata(context.Background(), testconstants.ExistentDid)
+			Expect(err).To(BeNil())
+			Expect(versions).To(HaveLen(1))
+
+			resource, err := cachedLedger.QueryResource(context.Background(), testconstants.ExistentDid, testconstants.ExistentResourceId)
+			Expect(err).To(BeNil())
+			Expect(resource.Metadata.Id).To(Equal(testconstants.ExistentResourceId))
+
+			resources, err := cachedLedger.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+			Expect(err).To(BeNil())
+			Expect(resources).To(HaveLen(1))
+		}
+
+		Expect(calls).To(Equal(5))
+	})
+
+	It("expires mutable entries before immutable ones", func() {
+		config := cacheConfig
+		config.MutableTTL = 50 * time.Millisecond
+		cachedLedger := services.NewCachedLedgerService(ledger, config)
+
+		_, err := cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		_, err = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, testconstants.ValidVersionId)
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal(2))
+
+		time.Sleep(100 * time.Millisecond)
+
+		_, err = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		_, err = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, testconstants.ValidVersionId)
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal(3))
+	})
+
+	It("caches notFound and returns a separate error to each caller", func() {
+		cachedLedger := services.NewCachedLedgerService(ledger, cacheConfig)
+
+		_, err := cachedLedger.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("notFound"))
+		err.ContentType = types.DIDJSONLD
+
+		_, err = cachedLedger.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("notFound"))
+		Expect(err.ContentType).To(Equal(types.JSON))
+
+		Expect(calls).To(Equal(1))
+	})
+
+	It("doesn't cache notFound when its TTL is zero", func() {
+		config := cacheConfig
+		config.NotFoundTTL = 0
+		cachedLedger := services.NewCachedLedgerService(ledger, config)
+
+		for i := 0; i < 2; i++ {
+			_, err := cachedLedger.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+			Expect(err).ToNot(BeNil())
+		}
+
+		Expect(calls).To(Equal(2))
+	})
+
+	It("evicts the least recently used entry when full", func() {
+		config := cacheConfig
+		config.Size = 2
+		cachedLedger := services.NewCachedLedgerService(ledger, config)
+
+		_, _ = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		_, _ = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, testconstants.ValidVersionId)
+		// Touch the latest DIDDoc, so the version becomes the least recently used one
+		_, _ = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		_, _ = cachedLedger.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(calls).To(Equal(3))
+
+		_, _ = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(calls).To(Equal(3))
+		_, _ = cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, testconstants.ValidVersionId)
+		Expect(calls).To(Equal(4))
+	})
+	It("keeps responses at different block heights apart", func() {
+		cachedLedger := services.NewCachedLedgerService(ledger, cacheConfig)
+		atHeight := services.WithBlockHeight(context.Background(), 42)
+
+		_, err := cachedLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		_, err = cachedLedger.QueryDIDDoc(atHeight, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		_, err = cachedLedger.QueryDIDDoc(atHeight, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+
+		Expect(calls).To(Equal(2))
+	})
+})
@@ -0,0 +1,36 @@
+//go:build unit
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Batch config", func() {
+	DescribeTable("rejects invalid batch settings", func(updateConfig func(rawConfig *types.RawConfig)) {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		updateConfig(&rawConfig)
+
+		_, err := types.NewConfig(rawConfig)
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"batch max items are not positive",
+			func(rawConfig *types.RawConfig) { rawConfig.BatchMaxItems = 0 },
+		),
+
+		Entry(
+			"batch concurrency is not positive",
+			func(rawConfig *types.RawConfig) { rawConfig.BatchConcurrency = 0 },
+		),
+
+		Entry(
+			"batch max body size is not positive",
+			func(rawConfig *types.RawConfig) { rawConfig.BatchMaxBodySize = 0 },
+		),
+	)
+})
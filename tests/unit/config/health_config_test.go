@@ -0,0 +1,50 @@
+//go:build unit
+
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Health config", func() {
+	It("reads the namespaces required by the readiness endpoint", func() {
+		rawConfig := newTestRawConfig([]types.RawNetwork{
+			{Namespace: "mainnet", Endpoint: "localhost:9090,false,5s"},
+			{Namespace: "testnet", Endpoint: "localhost:9091,false,5s"},
+		})
+		rawConfig.HealthRequiredNamespaces = " mainnet, "
+
+		config, err := types.NewConfig(rawConfig)
+		Expect(err).To(BeNil())
+		Expect(config.Health).To(Equal(types.HealthConfig{CacheTTL: 10 * time.Second, RequiredNamespaces: []string{"mainnet"}}))
+	})
+
+	DescribeTable("rejects invalid health settings", func(updateConfig func(rawConfig *types.RawConfig)) {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		updateConfig(&rawConfig)
+
+		_, err := types.NewConfig(rawConfig)
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"health cache TTL is not a duration",
+			func(rawConfig *types.RawConfig) { rawConfig.HealthCacheTTL = "often" },
+		),
+
+		Entry(
+			"health cache TTL is negative",
+			func(rawConfig *types.RawConfig) { rawConfig.HealthCacheTTL = "-1s" },
+		),
+
+		Entry(
+			"required namespace is not configured",
+			func(rawConfig *types.RawConfig) { rawConfig.HealthRequiredNamespaces = "devnet,mainnet" },
+		),
+	)
+})
@@ -0,0 +1,31 @@
+//go:build unit
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("HTTP cache config", func() {
+	DescribeTable("rejects invalid HTTP cache settings", func(updateConfig func(rawConfig *types.RawConfig)) {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		updateConfig(&rawConfig)
+
+		_, err := types.NewConfig(rawConfig)
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"max age is invalid",
+			func(rawConfig *types.RawConfig) { rawConfig.HttpCacheMaxAge = "forever" },
+		),
+
+		Entry(
+			"immutable max age is negative",
+			func(rawConfig *types.RawConfig) { rawConfig.HttpCacheImmutableMaxAge = "-1h" },
+		),
+	)
+})
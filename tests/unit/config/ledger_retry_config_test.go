@@ -0,0 +1,74 @@
+//go:build unit
+
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Ledger retry config", func() {
+	It("applies the retry policy and the circuit breaker to every network", func() {
+		rawConfig := newTestRawConfig([]types.RawNetwork{
+			{Namespace: "mainnet", Endpoint: "localhost:9090,false,5s"},
+			{Namespace: "local", Api: string(types.RESTApi), Endpoint: "localhost:1317,false,5s"},
+		})
+		rawConfig.LedgerRetryCodes = "UNAVAILABLE, deadlineexceeded"
+
+		config, err := types.NewConfig(rawConfig)
+		Expect(err).To(BeNil())
+		for _, network := range config.Networks {
+			Expect(network.Retry).To(Equal(types.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: 100 * time.Millisecond,
+				MaxBackoff:     2 * time.Second,
+				RetryableCodes: []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+			}))
+			Expect(network.CircuitBreaker).To(Equal(types.CircuitBreakerConfig{FailureThreshold: 5, OpenDuration: 30 * time.Second}))
+		}
+	})
+
+	DescribeTable("rejects invalid retry and circuit breaker settings", func(updateConfig func(rawConfig *types.RawConfig)) {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		updateConfig(&rawConfig)
+
+		_, err := types.NewConfig(rawConfig)
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"retry attempts are not positive",
+			func(rawConfig *types.RawConfig) { rawConfig.LedgerRetryAttempts = 0 },
+		),
+
+		Entry(
+			"retry backoff is invalid",
+			func(rawConfig *types.RawConfig) { rawConfig.LedgerRetryBackoff = "soon" },
+		),
+
+		Entry(
+			"max retry backoff is below the initial one",
+			func(rawConfig *types.RawConfig) { rawConfig.LedgerRetryMaxBackoff = "10ms" },
+		),
+
+		Entry(
+			"retry code is not a gRPC status code",
+			func(rawConfig *types.RawConfig) { rawConfig.LedgerRetryCodes = "Unavailable,Flaky" },
+		),
+
+		Entry(
+			"circuit breaker failures are negative",
+			func(rawConfig *types.RawConfig) { rawConfig.LedgerBreakerFailures = -1 },
+		),
+
+		Entry(
+			"circuit breaker open duration is not positive",
+			func(rawConfig *types.RawConfig) { rawConfig.LedgerBreakerOpenDuration = "0s" },
+		),
+	)
+})
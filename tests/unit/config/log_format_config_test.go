@@ -0,0 +1,29 @@
+//go:build unit
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Log format config", func() {
+	It("reads the log format", func() {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		rawConfig.LogFormat = "console"
+
+		config, err := types.NewConfig(rawConfig)
+		Expect(err).To(BeNil())
+		Expect(config.LogFormat).To(Equal(types.ConsoleLogFormat))
+	})
+
+	It("rejects an unsupported log format", func() {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		rawConfig.LogFormat = "xml"
+
+		_, err := types.NewConfig(rawConfig)
+		Expect(err).ToNot(BeNil())
+	})
+})
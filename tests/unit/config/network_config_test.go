@@ -0,0 +1,251 @@
+//go:build unit
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+const yamlNetworks = `
+networks:
+  - namespace: devnet
+    endpoints:
+      - address: localhost:9090
+        useTls: false
+        timeout: 2s
+      - address: grpc.devnet.example.com:443
+        useTls: true
+        timeout: 5s
+  - namespace: local
+    api: rest
+    endpoints:
+      - address: localhost:1317
+        useTls: false
+        timeout: 1s
+`
+
+const tomlNetworks = `
+[[networks]]
+namespace = "devnet"
+api = "snapshot"
+snapshotPath = "/var/lib/resolver/devnet.tar.gz"
+`
+
+func writeConfigFile(name string, content string) string {
+	path := filepath.Join(GinkgoT().TempDir(), name)
+	Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	return path
+}
+
+func setNetworkEnv(env map[string]string) {
+	for _, key := range []string{
+		"CONFIG_FILE", "NETWORKS", "MAINNET_ENDPOINT", "TESTNET_ENDPOINT", "DEVNET_ENDPOINT", "DEVNET_LEDGER_API",
+		"DEVNET_TLS_CA_FILE", "DEVNET_TLS_CERT_FILE", "DEVNET_TLS_KEY_FILE", "DEVNET_TLS_SERVER_NAME", "DEVNET_TLS_MIN_VERSION",
+		"DEVNET_VERIFY_CHAIN_ID", "DEVNET_VERIFY_TRUSTED_HEIGHT", "DEVNET_VERIFY_TRUSTED_HASH", "DEVNET_VERIFY_TRUSTING_PERIOD",
+		"DEVNET_QUORUM",
+	} {
+		GinkgoT().Setenv(key, env[key])
+	}
+}
+
+// newTestRawConfig returns the defaults of the settings which are not related to the networks
+func newTestRawConfig(rawNetworks []types.RawNetwork) types.RawConfig {
+	return types.RawConfig{
+		RawNetworks:               rawNetworks,
+		LogFormat:                 string(types.JSONLogFormat),
+		RequestTimeout:            "30s",
+		LedgerPoolSize:            1,
+		LedgerKeepAlive:           "5m",
+		LedgerHealthCheckInterval: "30s",
+		LedgerEndpointStrategy:    string(types.FailoverStrategy),
+		LedgerPageSize:            1000,
+		LedgerMaxPages:            100,
+		LedgerRetryAttempts:       3,
+		LedgerRetryBackoff:        "100ms",
+		LedgerRetryMaxBackoff:     "2s",
+		LedgerRetryCodes:          "Unavailable",
+		LedgerBreakerFailures:     5,
+		LedgerBreakerOpenDuration: "30s",
+		CacheSize:                 1,
+		CacheMutableTTL:           "1s",
+		CacheImmutableTTL:         "1s",
+		CacheNotFoundTTL:          "1s",
+		HttpCacheMaxAge:           "30s",
+		HttpCacheImmutableMaxAge:  "8760h",
+		ResourceMaxSize:           1024,
+		HealthCacheTTL:            "10s",
+		BatchMaxItems:             100,
+		BatchConcurrency:          10,
+		BatchMaxBodySize:          1 << 20,
+		TracingSampleRatio:        1,
+	}
+}
+
+var _ = Describe("Network config", func() {
+	It("loads any number of networks from a YAML file", func() {
+		setNetworkEnv(map[string]string{"CONFIG_FILE": writeConfigFile("networks.yaml", yamlNetworks)})
+
+		config, err := types.LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Networks).To(HaveLen(2))
+
+		Expect(config.Networks[0].Namespace).To(Equal("devnet"))
+		Expect(config.Networks[0].Api).To(Equal(types.GRPCApi))
+		Expect(config.Networks[0].Endpoints).To(Equal([]types.Endpoint{
+			{Address: "localhost:9090", UseTls: false, Timeout: 2 * time.Second},
+			{Address: "grpc.devnet.example.com:443", UseTls: true, Timeout: 5 * time.Second},
+		}))
+		Expect(config.Networks[0].PageSize).To(Equal(types.DefaultLedgerPageSize))
+
+		Expect(config.Networks[1].Namespace).To(Equal("local"))
+		Expect(config.Networks[1].Api).To(Equal(types.RESTApi))
+	})
+
+	It("loads networks from a TOML file", func() {
+		setNetworkEnv(map[string]string{"CONFIG_FILE": writeConfigFile("networks.toml", tomlNetworks)})
+
+		config, err := types.LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Networks).To(HaveLen(1))
+		Expect(config.Networks[0].Api).To(Equal(types.SnapshotApi))
+		Expect(config.Networks[0].SnapshotPath).To(Equal("/var/lib/resolver/devnet.tar.gz"))
+	})
+
+	It("overrides networks of the file with env", func() {
+		setNetworkEnv(map[string]string{
+			"CONFIG_FILE":       writeConfigFile("networks.yaml", yamlNetworks),
+			"DEVNET_ENDPOINT":   "devnet.example.com:1317,true,3s",
+			"DEVNET_LEDGER_API": "rest",
+		})
+
+		config, err := types.LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Networks[0].Api).To(Equal(types.RESTApi))
+		Expect(config.Networks[0].Endpoints).To(Equal([]types.Endpoint{
+			{Address: "devnet.example.com:1317", UseTls: true, Timeout: 3 * time.Second},
+		}))
+	})
+
+	It("loads TLS options from the file and env", func() {
+		certificates, err := utils.NewTestCertificates(GinkgoT().TempDir())
+		Expect(err).To(BeNil())
+		setNetworkEnv(map[string]string{
+			"CONFIG_FILE": writeConfigFile("networks.yaml", `
+networks:
+  - namespace: devnet
+    endpoints:
+      - address: grpc.devnet.example.com:443
+        useTls: true
+        timeout: 5s
+    tls:
+      caFile: `+certificates.CAFile+`
+      minVersion: "1.3"
+`),
+			"DEVNET_TLS_CERT_FILE":   certificates.ClientCertFile,
+			"DEVNET_TLS_KEY_FILE":    certificates.ClientKeyFile,
+			"DEVNET_TLS_SERVER_NAME": utils.TestServerName,
+		})
+
+		config, err := types.LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Networks[0].TLS).To(Equal(types.TLSConfig{
+			CAFile:     certificates.CAFile,
+			CertFile:   certificates.ClientCertFile,
+			KeyFile:    certificates.ClientKeyFile,
+			ServerName: utils.TestServerName,
+			MinVersion: "1.3",
+		}))
+	})
+
+	It("adds networks declared in env", func() {
+		setNetworkEnv(map[string]string{
+			"NETWORKS":         "devnet",
+			"DEVNET_ENDPOINT":  "localhost:9090,false,5s",
+			"MAINNET_ENDPOINT": "grpc.cheqd.net:443,true,5s",
+			"TESTNET_ENDPOINT": "grpc.cheqd.network:443,true,5s",
+		})
+
+		config, err := types.LoadConfig()
+		Expect(err).To(BeNil())
+
+		var namespaces []string
+		for _, network := range config.Networks {
+			namespaces = append(namespaces, network.Namespace)
+		}
+		Expect(namespaces).To(Equal([]string{"devnet", "mainnet", "testnet"}))
+	})
+
+	It("requires at least one network", func() {
+		setNetworkEnv(map[string]string{})
+
+		_, err := types.LoadConfig()
+		Expect(err).ToNot(BeNil())
+	})
+
+	DescribeTable("rejects invalid networks", func(rawNetworks []types.RawNetwork) {
+		_, err := types.NewConfig(newTestRawConfig(rawNetworks))
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"namespace is not alphanumeric",
+			[]types.RawNetwork{{Namespace: "dev-net", Endpoint: "localhost:9090,false,5s"}},
+		),
+
+		Entry(
+			"namespace is configured twice",
+			[]types.RawNetwork{
+				{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"},
+				{Namespace: "devnet", Endpoint: "localhost:9091,false,5s"},
+			},
+		),
+
+		Entry(
+			"network has no endpoints",
+			[]types.RawNetwork{{Namespace: "devnet"}},
+		),
+
+		Entry(
+			"endpoint timeout is invalid",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoints: []types.RawEndpoint{{Address: "localhost:9090", Timeout: "soon"}}}},
+		),
+
+		Entry(
+			"API is not supported",
+			[]types.RawNetwork{{Namespace: "devnet", Api: "websocket", Endpoint: "localhost:9090,false,5s"}},
+		),
+
+		Entry(
+			"snapshot path is empty",
+			[]types.RawNetwork{{Namespace: "devnet", Api: string(types.SnapshotApi)}},
+		),
+
+		Entry(
+			"TLS options are set, but no endpoint uses TLS",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s", TLS: types.TLSConfig{ServerName: "ledger.test"}}},
+		),
+
+		Entry(
+			"CA file doesn't exist",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,true,5s", TLS: types.TLSConfig{CAFile: "/nonexistent/ca.pem"}}},
+		),
+
+		Entry(
+			"client certificate is set without its key",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,true,5s", TLS: types.TLSConfig{CertFile: "/nonexistent/client.pem"}}},
+		),
+
+		Entry(
+			"TLS version is unknown",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,true,5s", TLS: types.TLSConfig{MinVersion: "1.4"}}},
+		),
+	)
+})
@@ -0,0 +1,67 @@
+//go:build unit
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Quorum config", func() {
+	It("loads the quorum from the file and env", func() {
+		configFile := writeConfigFile("networks.yaml", `
+networks:
+  - namespace: devnet
+    quorum: 2
+    endpoints:
+      - address: localhost:9090
+        useTls: false
+        timeout: 2s
+      - address: localhost:9091
+        useTls: false
+        timeout: 2s
+      - address: localhost:9092
+        useTls: false
+        timeout: 2s
+`)
+		setNetworkEnv(map[string]string{"CONFIG_FILE": configFile})
+
+		config, err := types.LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Networks[0].Quorum).To(Equal(2))
+
+		setNetworkEnv(map[string]string{"CONFIG_FILE": configFile, "DEVNET_QUORUM": "3"})
+
+		config, err = types.LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Networks[0].Quorum).To(Equal(3))
+	})
+
+	DescribeTable("rejects invalid quorum settings", func(rawNetworks []types.RawNetwork) {
+		_, err := types.NewConfig(newTestRawConfig(rawNetworks))
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"quorum is not a number",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s;localhost:9091,false,5s", Quorum: "majority"}},
+		),
+
+		Entry(
+			"quorum is larger than the number of endpoints",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s;localhost:9091,false,5s", Quorum: "3"}},
+		),
+
+		Entry(
+			"quorum is set for the REST API",
+			[]types.RawNetwork{{Namespace: "devnet", Api: string(types.RESTApi), Endpoint: "localhost:1317,false,5s;localhost:1318,false,5s", Quorum: "2"}},
+		),
+
+		Entry(
+			"quorum is combined with verification",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s;localhost:9091,false,5s", Quorum: "2", Verification: types.RawVerification{ChainId: "cheqd-devnet-1", TrustedHeight: "1", TrustedHash: trustedHash}}},
+		),
+	)
+})
@@ -0,0 +1,34 @@
+//go:build unit
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Resource max size config", func() {
+	It("applies the maximum resource size to every network", func() {
+		rawConfig := newTestRawConfig([]types.RawNetwork{
+			{Namespace: "mainnet", Endpoint: "localhost:9090,false,5s"},
+			{Namespace: "local", Api: string(types.RESTApi), Endpoint: "localhost:1317,false,5s"},
+		})
+
+		config, err := types.NewConfig(rawConfig)
+		Expect(err).To(BeNil())
+		Expect(config.ResourceMaxSize).To(Equal(1024))
+		for _, network := range config.Networks {
+			Expect(network.ResourceMaxSize).To(Equal(1024))
+		}
+	})
+
+	It("rejects a maximum resource size which is not positive", func() {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		rawConfig.ResourceMaxSize = 0
+
+		_, err := types.NewConfig(rawConfig)
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,42 @@
+//go:build unit
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Tracing config", func() {
+	It("reads the collector receiving the traces", func() {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		rawConfig.TracingEndpoint = "localhost:4317"
+		rawConfig.TracingInsecure = true
+		rawConfig.TracingSampleRatio = 0.25
+
+		config, err := types.NewConfig(rawConfig)
+		Expect(err).To(BeNil())
+		Expect(config.Tracing).To(Equal(types.TracingConfig{Endpoint: "localhost:4317", Insecure: true, SampleRatio: 0.25}))
+	})
+
+	DescribeTable("rejects invalid tracing settings", func(updateConfig func(rawConfig *types.RawConfig)) {
+		rawConfig := newTestRawConfig([]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s"}})
+		updateConfig(&rawConfig)
+
+		_, err := types.NewConfig(rawConfig)
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"sample ratio is negative",
+			func(rawConfig *types.RawConfig) { rawConfig.TracingSampleRatio = -0.5 },
+		),
+
+		Entry(
+			"sample ratio is above 1",
+			func(rawConfig *types.RawConfig) { rawConfig.TracingSampleRatio = 1.5 },
+		),
+	)
+})
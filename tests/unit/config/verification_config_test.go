@@ -0,0 +1,77 @@
+//go:build unit
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/types"
+)
+
+const yamlVerifiedNetwork = `
+networks:
+  - namespace: devnet
+    endpoints:
+      - address: localhost:9090
+        useTls: false
+        timeout: 2s
+    verification:
+      chainId: cheqd-devnet-1
+      trustedHeight: "1000"
+      trustedHash: 0000000000000000000000000000000000000000000000000000000000000000
+`
+
+const trustedHash = "A03CC75A3A1B6A1D724887BE1FC2983AEC0C482CEB820C938B4494CC0895DB52"
+
+var _ = Describe("Verification config", func() {
+	It("loads verification options from the file and env", func() {
+		setNetworkEnv(map[string]string{
+			"CONFIG_FILE":                   writeConfigFile("networks.yaml", yamlVerifiedNetwork),
+			"DEVNET_VERIFY_TRUSTED_HASH":    trustedHash,
+			"DEVNET_VERIFY_TRUSTING_PERIOD": "72h",
+		})
+
+		config, err := types.LoadConfig()
+		Expect(err).To(BeNil())
+		Expect(config.Networks[0].Verification).ToNot(BeNil())
+		Expect(config.Networks[0].Verification.ChainId).To(Equal("cheqd-devnet-1"))
+		Expect(config.Networks[0].Verification.TrustedHeight).To(Equal(int64(1000)))
+		Expect(fmt.Sprintf("%X", config.Networks[0].Verification.TrustedHash)).To(Equal(trustedHash))
+		Expect(config.Networks[0].Verification.TrustingPeriod).To(Equal(72 * time.Hour))
+	})
+
+	DescribeTable("rejects invalid verification settings", func(rawNetworks []types.RawNetwork) {
+		_, err := types.NewConfig(newTestRawConfig(rawNetworks))
+		Expect(err).ToNot(BeNil())
+	},
+
+		Entry(
+			"verification is enabled for the REST API",
+			[]types.RawNetwork{{Namespace: "devnet", Api: string(types.RESTApi), Endpoint: "localhost:1317,false,5s", Verification: types.RawVerification{ChainId: "cheqd-devnet-1", TrustedHeight: "1", TrustedHash: trustedHash}}},
+		),
+
+		Entry(
+			"chain ID to verify is not set",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s", Verification: types.RawVerification{TrustedHeight: "1", TrustedHash: trustedHash}}},
+		),
+
+		Entry(
+			"trusted height is not positive",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s", Verification: types.RawVerification{ChainId: "cheqd-devnet-1", TrustedHeight: "0", TrustedHash: trustedHash}}},
+		),
+
+		Entry(
+			"trusted hash is not a SHA-256 hash",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s", Verification: types.RawVerification{ChainId: "cheqd-devnet-1", TrustedHeight: "1", TrustedHash: "A03CC75A"}}},
+		),
+
+		Entry(
+			"trusting period is invalid",
+			[]types.RawNetwork{{Namespace: "devnet", Endpoint: "localhost:9090,false,5s", Verification: types.RawVerification{ChainId: "cheqd-devnet-1", TrustedHeight: "1", TrustedHash: trustedHash, TrustingPeriod: "-1h"}}},
+		),
+	)
+})
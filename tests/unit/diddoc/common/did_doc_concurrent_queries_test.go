@@ -0,0 +1,124 @@
+//go:build unit
+
+package common
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+// rendezvousLedgerService answers a query only when all the expected queries are in flight,
+// so queries made one after another time out
+type rendezvousLedgerService struct {
+	utils.MockLedgerService
+	inFlight *sync.WaitGroup
+}
+
+func (ls rendezvousLedgerService) meet(did string) *types.IdentityError {
+	ls.inFlight.Done()
+	met := make(chan struct{})
+	go func() {
+		ls.inFlight.Wait()
+		close(met)
+	}()
+
+	select {
+	case <-met:
+		return nil
+	case <-time.After(time.Second):
+		return types.NewLedgerTimeoutError(did, types.JSON, nil, false)
+	}
+}
+
+func (ls rendezvousLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	if err := ls.meet(did); err != nil {
+		return nil, err
+	}
+	return ls.MockLedgerService.QueryDIDDoc(ctx, did, version)
+}
+
+func (ls rendezvousLedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
+	if err := ls.meet(did); err != nil {
+		return nil, err
+	}
+	return ls.MockLedgerService.QueryAllDidDocVersionsMetadata(ctx, did)
+}
+
+func (ls rendezvousLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	if err := ls.meet(did); err != nil {
+		return nil, err
+	}
+	return ls.MockLedgerService.QueryCollectionResources(ctx, did)
+}
+
+// failingResourcesLedgerService fails collection queries at once and holds DIDDoc queries until they are cancelled
+type failingResourcesLedgerService struct {
+	utils.MockLedgerService
+	canceled *atomic.Bool
+}
+
+func (ls failingResourcesLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	select {
+	case <-ctx.Done():
+		ls.canceled.Store(true)
+		return nil, types.NewInternalError(did, types.JSON, ctx.Err(), false)
+	case <-time.After(time.Second):
+		return ls.MockLedgerService.QueryDIDDoc(ctx, did, version)
+	}
+}
+
+func (ls failingResourcesLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+	return nil, types.NewTemporarilyUnavailableError(did, types.JSON, nil, false)
+}
+
+var _ = Describe("DIDDoc service ledger queries", func() {
+	var inFlight *sync.WaitGroup
+	var diddocService services.DIDDocService
+
+	BeforeEach(func() {
+		inFlight = &sync.WaitGroup{}
+		inFlight.Add(2)
+		diddocService = services.NewDIDDocService(types.DID_METHOD, rendezvousLedgerService{MockLedgerService: utils.MockLedger, inFlight: inFlight})
+	})
+
+	It("resolves the DIDDoc and its resources concurrently", func() {
+		result, err := diddocService.Resolve(context.Background(), testconstants.ExistentDid, "", types.DIDJSON)
+		Expect(err).To(BeNil())
+		Expect(result.Did.Id).To(Equal(testconstants.ExistentDid))
+		Expect(result.Metadata.Resources).To(HaveLen(1))
+	})
+
+	It("gets the DIDDoc version metadata and resources concurrently", func() {
+		_, err := diddocService.GetDIDDocVersionsMetadata(context.Background(), testconstants.ExistentDid, testconstants.ValidVersionId, types.DIDJSON)
+		Expect(err).To(BeNil())
+	})
+
+	It("gets all the versions and resources concurrently", func() {
+		result, err := diddocService.GetAllDidDocVersionsMetadata(context.Background(), testconstants.ExistentDid, types.DIDJSON)
+		Expect(err).To(BeNil())
+		Expect(result.ContentStream.(*types.DereferencedDidVersionsList).Versions).To(HaveLen(1))
+	})
+
+	It("cancels the other queries and returns the error of the failed one", func() {
+		canceled := &atomic.Bool{}
+		diddocService = services.NewDIDDocService(types.DID_METHOD, failingResourcesLedgerService{MockLedgerService: utils.MockLedger, canceled: canceled})
+
+		_, err := diddocService.Resolve(context.Background(), testconstants.ExistentDid, "", types.DIDJSONLD)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+		Expect(err.ContentType).To(Equal(types.DIDJSONLD))
+		Expect(canceled.Load()).To(BeTrue())
+	})
+})
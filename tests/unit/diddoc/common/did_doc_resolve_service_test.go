@@ -3,6 +3,7 @@
 package common
 
 import (
+	"context"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -38,7 +39,7 @@ var _ = DescribeTable("Test Resolve method", func(testCase resolveDidDocTestCase
 		testCase.resolutionType,
 	)
 
-	resolutionResult, err := diddocService.Resolve(testCase.did, "", testCase.resolutionType)
+	resolutionResult, err := diddocService.Resolve(context.Background(), testCase.did, "", testCase.resolutionType)
 	if testCase.expectedError != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
@@ -3,6 +3,7 @@
 package common
 
 import (
+	"context"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -27,7 +28,7 @@ var _ = DescribeTable("Test DereferenceSecondary method", func(testCase derefere
 		testCase.expectedDidDereferencing.DereferencingMetadata.ContentType, testCase.dereferencingType,
 	)
 
-	dereferencingResult, err := diddocService.DereferenceSecondary(testCase.did, "", testCase.fragmentId, testCase.dereferencingType)
+	dereferencingResult, err := diddocService.DereferenceSecondary(context.Background(), testCase.did, "", testCase.fragmentId, testCase.dereferencingType)
 	if testCase.expectedError != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
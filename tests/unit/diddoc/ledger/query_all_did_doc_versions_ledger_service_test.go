@@ -3,6 +3,7 @@
 package ledger
 
 import (
+	"context"
 	testconstants "github.com/cheqd/did-resolver/tests/constants"
 	utils "github.com/cheqd/did-resolver/tests/unit"
 	"github.com/cheqd/did-resolver/types"
@@ -17,7 +18,7 @@ type queryDIDDocVersionsTestCase struct {
 }
 
 var _ = DescribeTable("Test QueryAllDidDocVersionsMetadata method", func(testCase queryDIDDocVersionsTestCase) {
-	didDocMetadata, err := utils.MockLedger.QueryAllDidDocVersionsMetadata(testCase.did)
+	didDocMetadata, err := utils.MockLedger.QueryAllDidDocVersionsMetadata(context.Background(), testCase.did)
 	didDocVersions := types.NewDereferencedDidVersionsList(testCase.did, didDocMetadata, nil)
 	if err != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
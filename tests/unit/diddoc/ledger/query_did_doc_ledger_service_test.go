@@ -3,6 +3,7 @@
 package ledger
 
 import (
+	"context"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -19,7 +20,7 @@ type queryDIDDocTestCase struct {
 }
 
 var _ = DescribeTable("Test QueryDIDDoc method", func(testCase queryDIDDocTestCase) {
-	didDocWithMetadata, err := utils.MockLedger.QueryDIDDoc(testCase.did, "")
+	didDocWithMetadata, err := utils.MockLedger.QueryDIDDoc(context.Background(), testCase.did, "")
 	if err != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
@@ -0,0 +1,140 @@
+//go:build unit
+
+package request
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/services"
+	didDocServices "github.com/cheqd/did-resolver/services/diddoc"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = DescribeTable("Test NegotiateContentType function", func(accept string, expectedContentType types.ContentType, isDocumentRequested bool) {
+	accepted, ok := services.NegotiateContentType(accept)
+	Expect(ok).To(Equal(expectedContentType != ""))
+	Expect(accepted.ContentType).To(Equal(expectedContentType))
+	if ok {
+		Expect(accepted.IsDocumentRequested()).To(Equal(isDocumentRequested))
+	}
+},
+
+	Entry("DID Document JSON", "application/did+json", types.DIDJSON, true),
+
+	Entry("DID Document JSON-LD", "application/did+ld+json", types.DIDJSONLD, true),
+
+	Entry("JSON-LD without a profile", "application/ld+json", types.DIDJSONLD, true),
+
+	Entry("DID resolution result", `application/ld+json;profile="https://w3id.org/did-resolution"`, types.DIDJSONLD, false),
+
+	Entry("DID resolution result among other profiles", `application/ld+json; profile="https://example.com/profile https://w3id.org/did-resolution"`, types.DIDJSONLD, false),
+
+	Entry("any media type", "text/html, */*", types.DIDJSONLD, false),
+
+	Entry("the first supported media type", "text/html, application/did+json, application/did+ld+json", types.DIDJSON, true),
+
+	Entry("the media type with the highest q-value", "application/did+json;q=0.5, application/did+ld+json;q=0.9", types.DIDJSONLD, true),
+
+	Entry("the media type listed first among equal q-values", "application/did+ld+json;q=0.8, application/did+json;q=0.8", types.DIDJSONLD, true),
+
+	Entry("a wildcard with a lower q-value", "*/*;q=0.1, application/did+json", types.DIDJSON, true),
+
+	Entry("no media type with q=0", "application/did+json;q=0", types.ContentType(""), false),
+
+	Entry("no media type with an invalid q-value", "application/did+json;q=high", types.ContentType(""), false),
+
+	Entry("DID Document CBOR", "application/did+cbor", types.DIDCBOR, true),
+
+	Entry("CBOR without a profile", "application/cbor", types.DIDCBOR, true),
+
+	Entry("CBOR DID resolution result", `application/cbor;profile="https://w3id.org/did-resolution"`, types.DIDCBOR, false),
+
+	Entry("no supported media type", "text/html", types.ContentType(""), false),
+)
+
+var _ = Describe("DIDDoc responses negotiated by Accept", func() {
+	newContext := func(accept string, didDocumentResponses bool) (echo.Context, *httptest.ResponseRecorder) {
+		request := httptest.NewRequest(http.MethodGet, "/1.0/identifiers/"+testconstants.ExistentDid, nil)
+		context, rec := utils.SetupEmptyContext(request, types.ContentType(accept), utils.MockLedger)
+		resolverContext := context.(services.ResolverContext)
+		resolverContext.DidDocumentResponses = didDocumentResponses
+
+		return resolverContext, rec
+	}
+
+	It("returns the DID resolution result for DID Document media types by default", func() {
+		context, rec := newContext(string(types.DIDJSON), false)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+
+		var resolution types.DidResolution
+		Expect(json.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution.Did.Id).To(Equal(testconstants.ExistentDid))
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDJSON)))
+	})
+
+	It("returns the DID Document alone with the metadata in headers", func() {
+		context, rec := newContext(string(types.DIDJSON), true)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+
+		var didDoc types.DidDoc
+		Expect(json.Unmarshal(rec.Body.Bytes(), &didDoc)).To(Succeed())
+		Expect(didDoc.Id).To(Equal(testconstants.ExistentDid))
+		Expect(didDoc.Context).To(BeNil())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDJSON)))
+
+		var resolutionMetadata types.ResolutionMetadata
+		Expect(json.Unmarshal([]byte(rec.Header().Get(types.DidResolutionMetadataHeader)), &resolutionMetadata)).To(Succeed())
+		Expect(resolutionMetadata.ContentType).To(Equal(types.DIDJSON))
+		Expect(resolutionMetadata.DidProperties.DidString).To(Equal(testconstants.ExistentDid))
+
+		var documentMetadata types.ResolutionDidDocMetadata
+		Expect(json.Unmarshal([]byte(rec.Header().Get(types.DidDocumentMetadataHeader)), &documentMetadata)).To(Succeed())
+		Expect(documentMetadata.VersionId).To(Equal(testconstants.ValidVersionId))
+		Expect(documentMetadata.Resources).To(BeEmpty())
+	})
+
+	It("returns the JSON-LD DID Document alone", func() {
+		context, rec := newContext(string(types.DIDJSONLD), true)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+
+		var didDoc types.DidDoc
+		Expect(json.Unmarshal(rec.Body.Bytes(), &didDoc)).To(Succeed())
+		Expect(didDoc.Context).To(ContainElement(types.DIDSchemaJSONLD))
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDJSONLD)))
+	})
+
+	It("returns the DID resolution result for the DID resolution profile", func() {
+		context, rec := newContext(`application/did+json;q=0.5, application/ld+json;profile="https://w3id.org/did-resolution"`, true)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+
+		var resolution types.DidResolution
+		Expect(json.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution.Did.Id).To(Equal(testconstants.ExistentDid))
+		Expect(resolution.ResolutionMetadata.ContentType).To(Equal(types.DIDJSONLD))
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(types.DIDResolutionResult))
+		Expect(rec.Header().Get(types.DidResolutionMetadataHeader)).To(BeEmpty())
+	})
+
+	It("returns the DID resolution result for any media type", func() {
+		context, rec := newContext("*/*", true)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+
+		var resolution types.DidResolution
+		Expect(json.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution.Did.Id).To(Equal(testconstants.ExistentDid))
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(types.DIDResolutionResult))
+	})
+})
@@ -0,0 +1,86 @@
+//go:build unit
+
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	didDocServices "github.com/cheqd/did-resolver/services/diddoc"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+type blockHeightTestCase struct {
+	didURL              string
+	expectedBlockHeight int64
+	expectedError       error
+}
+
+var _ = DescribeTable("Test blockHeight resolution option", func(testCase blockHeightTestCase) {
+	request := httptest.NewRequest(http.MethodGet, testCase.didURL, nil)
+	context, rec := utils.SetupEmptyContext(request, types.DIDJSONLD, utils.MockLedger)
+
+	err := didDocServices.DidDocEchoHandler(context)
+	if testCase.expectedError != nil {
+		Expect(err).ToNot(BeNil())
+		Expect(testCase.expectedError.Error()).To(Equal(err.Error()))
+	} else {
+		var resolutionResult types.DidResolution
+		Expect(err).To(BeNil())
+		Expect(json.Unmarshal(rec.Body.Bytes(), &resolutionResult)).To(BeNil())
+		Expect(resolutionResult.Did.Id).To(Equal(testconstants.ExistentDid))
+		Expect(resolutionResult.ResolutionMetadata.BlockHeight).To(Equal(testCase.expectedBlockHeight))
+	}
+},
+
+	Entry(
+		"can resolve DIDDoc at block height",
+		blockHeightTestCase{
+			didURL:              fmt.Sprintf("/1.0/identifiers/%s?blockHeight=4109000", testconstants.ExistentDid),
+			expectedBlockHeight: 4109000,
+		},
+	),
+
+	Entry(
+		"can resolve DIDDoc version at block height",
+		blockHeightTestCase{
+			didURL: fmt.Sprintf(
+				"/1.0/identifiers/%s?versionId=%s&blockHeight=4109000",
+				testconstants.ExistentDid,
+				testconstants.ValidVersionId,
+			),
+			expectedBlockHeight: 4109000,
+		},
+	),
+
+	Entry(
+		"doesn't set block height when it's not requested",
+		blockHeightTestCase{
+			didURL:              fmt.Sprintf("/1.0/identifiers/%s?versionId=%s", testconstants.ExistentDid, testconstants.ValidVersionId),
+			expectedBlockHeight: 0,
+		},
+	),
+
+	Entry(
+		"cannot resolve DIDDoc at not a number block height",
+		blockHeightTestCase{
+			didURL:        fmt.Sprintf("/1.0/identifiers/%s?blockHeight=latest", testconstants.ExistentDid),
+			expectedError: types.NewInvalidDidUrlError(testconstants.ExistentDid, types.DIDJSONLD, nil, false),
+		},
+	),
+
+	Entry(
+		"cannot resolve DIDDoc at negative block height",
+		blockHeightTestCase{
+			didURL:        fmt.Sprintf("/1.0/identifiers/%s?blockHeight=-1", testconstants.ExistentDid),
+			expectedError: types.NewInvalidDidUrlError(testconstants.ExistentDid, types.DIDJSONLD, nil, false),
+		},
+	),
+)
@@ -0,0 +1,153 @@
+//go:build unit
+
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/services"
+	didDocServices "github.com/cheqd/did-resolver/services/diddoc"
+	resourceServices "github.com/cheqd/did-resolver/services/resource"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("CBOR representations", func() {
+	newContext := func(path string, accept types.ContentType) (echo.Context, *httptest.ResponseRecorder) {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		return utils.SetupEmptyContext(request, accept, utils.MockLedger)
+	}
+
+	It("returns the DID resolution result in CBOR", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid, types.DIDCBOR)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDCBOR)))
+
+		var resolution types.DidResolution
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution.ResolutionMetadata.ContentType).To(Equal(types.DIDCBOR))
+		Expect(resolution.Did.Id).To(Equal(testconstants.ExistentDid))
+		Expect(resolution.Did.Context).To(BeNil())
+		Expect(resolution.Metadata.VersionId).To(Equal(testconstants.ValidVersionId))
+		Expect(*resolution.Metadata.Created).To(BeTemporally("==", testconstants.ValidMetadata.Created.AsTime()))
+	})
+
+	It("uses the names of the JSON fields and omits the fields JSON omits", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid, types.DIDCBOR)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+
+		var resolution map[string]interface{}
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution).To(HaveKey("didResolutionMetadata"))
+		Expect(resolution).To(HaveKey("didDocumentMetadata"))
+		Expect(resolution["didDocument"]).To(HaveKeyWithValue("id", testconstants.ExistentDid))
+		Expect(resolution["didDocument"]).ToNot(HaveKey("@context"))
+		Expect(resolution["didDocumentMetadata"]).ToNot(HaveKey("deactivated"))
+	})
+
+	It("returns the CBOR DID resolution result for application/cbor", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid, types.CBOR)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDCBOR)))
+
+		var resolution types.DidResolution
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution.Did.Id).To(Equal(testconstants.ExistentDid))
+	})
+
+	It("returns the DID Document alone in CBOR", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid, types.DIDCBOR)
+		resolverContext := context.(services.ResolverContext)
+		resolverContext.DidDocumentResponses = true
+
+		Expect(didDocServices.DidDocEchoHandler(resolverContext)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDCBOR)))
+		Expect(rec.Header().Get(types.DidResolutionMetadataHeader)).ToNot(BeEmpty())
+
+		var didDoc types.DidDoc
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &didDoc)).To(Succeed())
+		Expect(didDoc.Id).To(Equal(testconstants.ExistentDid))
+		Expect(didDoc.VerificationMethod).To(HaveLen(1))
+	})
+
+	It("returns the CBOR DID resolution result for the DID resolution profile", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid, `application/cbor;profile="https://w3id.org/did-resolution"`)
+		resolverContext := context.(services.ResolverContext)
+		resolverContext.DidDocumentResponses = true
+
+		Expect(didDocServices.DidDocEchoHandler(resolverContext)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(types.DIDResolutionResultCBOR))
+
+		var resolution types.DidResolution
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution.Did.Id).To(Equal(testconstants.ExistentDid))
+	})
+
+	It("dereferences a fragment in CBOR", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid, types.DIDCBOR)
+		context.SetParamNames("did")
+		context.SetParamValues(testconstants.ExistentDid + "#key-1")
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDCBOR)))
+
+		var dereferencing map[string]interface{}
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &dereferencing)).To(Succeed())
+		Expect(dereferencing["dereferencingMetadata"]).To(HaveKeyWithValue("contentType", string(types.DIDCBOR)))
+		Expect(dereferencing["contentStream"]).To(HaveKeyWithValue("id", testconstants.ExistentDid+"#key-1"))
+		Expect(dereferencing["contentStream"]).To(HaveKeyWithValue("type", "JsonWebKey2020"))
+	})
+
+	It("returns the DIDDoc versions in CBOR", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid+"/versions", types.DIDCBOR)
+
+		Expect(didDocServices.DidDocAllVersionMetadataEchoHandler(context)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDCBOR)))
+
+		var dereferencing struct {
+			ContentStream types.DereferencedDidVersionsList `json:"contentStream"`
+		}
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &dereferencing)).To(Succeed())
+		Expect(dereferencing.ContentStream.Versions).To(HaveLen(1))
+		Expect(dereferencing.ContentStream.Versions[0].VersionId).To(Equal(testconstants.ValidVersionId))
+	})
+
+	It("returns the resource metadata in CBOR", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.ExistentDid+"/resources/"+testconstants.ExistentResourceId+"/metadata", types.DIDCBOR)
+
+		Expect(resourceServices.ResourceMetadataEchoHandler(context)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDCBOR)))
+
+		var dereferencing struct {
+			ContentStream types.DereferencedResourceListStruct `json:"contentStream"`
+		}
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &dereferencing)).To(Succeed())
+		Expect(dereferencing.ContentStream.Resources).To(HaveLen(1))
+		Expect(dereferencing.ContentStream.Resources[0].ResourceId).To(Equal(testconstants.ExistentResourceId))
+	})
+
+	It("returns errors in CBOR", func() {
+		context, rec := newContext("/1.0/identifiers/"+testconstants.NotExistentTestnetDid, types.DIDCBOR)
+
+		err := didDocServices.DidDocEchoHandler(context)
+		Expect(err).ToNot(BeNil())
+		services.CustomHTTPErrorHandler(err, context)
+
+		Expect(rec.Code).To(Equal(types.NotFoundHttpCode))
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(string(types.DIDCBOR)))
+
+		var resolution types.DidResolution
+		Expect(cbor.Unmarshal(rec.Body.Bytes(), &resolution)).To(Succeed())
+		Expect(resolution.ResolutionMetadata.ResolutionError).To(Equal("notFound"))
+	})
+})
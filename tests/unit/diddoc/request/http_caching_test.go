@@ -0,0 +1,161 @@
+//go:build unit
+
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cheqd/did-resolver/services"
+	didDocServices "github.com/cheqd/did-resolver/services/diddoc"
+	resourceServices "github.com/cheqd/did-resolver/services/resource"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("HTTP caching", func() {
+	httpCache := types.HttpCacheConfig{MaxAge: 30 * time.Second, ImmutableMaxAge: 24 * time.Hour}
+	didDocPath := "/1.0/identifiers/" + testconstants.ExistentDid
+	lastModified := testconstants.ValidCreated.Format(http.TimeFormat)
+
+	serve := func(handler echo.HandlerFunc, path string, accept types.ContentType, headers map[string]string) (*httptest.ResponseRecorder, error) {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		context, rec := utils.SetupEmptyContext(request, accept, utils.MockLedger)
+		resolverContext := context.(services.ResolverContext)
+		resolverContext.HttpCache = httpCache
+
+		return rec, handler(resolverContext)
+	}
+
+	It("sets the validators and a short max age for the latest DIDDoc", func() {
+		rec, err := serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("ETag")).To(MatchRegexp(`^"[0-9a-f]{32}"$`))
+		Expect(rec.Header().Get(echo.HeaderLastModified)).To(Equal(lastModified))
+		Expect(rec.Header().Get(echo.HeaderCacheControl)).To(Equal("public, max-age=30"))
+		Expect(rec.Header().Get(echo.HeaderVary)).To(Equal(echo.HeaderAccept))
+	})
+
+	It("keeps the ETag of the same data and changes it with the representation", func() {
+		first, err := serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+		second, err := serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+		other, err := serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSON, nil)
+		Expect(err).To(BeNil())
+
+		Expect(second.Header().Get("ETag")).To(Equal(first.Header().Get("ETag")))
+		Expect(other.Header().Get("ETag")).ToNot(Equal(first.Header().Get("ETag")))
+	})
+
+	It("marks the data pinned by version, resource or block height immutable", func() {
+		for path, handler := range map[string]echo.HandlerFunc{
+			didDocPath + types.DID_VERSION_PATH + testconstants.ValidVersionId: didDocServices.DidDocVersionEchoHandler,
+			didDocPath + "?versionId=" + testconstants.ValidVersionId:          didDocServices.DidDocEchoHandler,
+			didDocPath + "?blockHeight=100":                                    didDocServices.DidDocEchoHandler,
+		} {
+			rec, err := serve(handler, path, types.DIDJSONLD, nil)
+			Expect(err).To(BeNil())
+			Expect(rec.Header().Get(echo.HeaderCacheControl)).To(Equal("public, max-age=86400, immutable"), path)
+		}
+
+		rec, err := serve(resourceServices.ResourceDataEchoHandler, didDocPath+types.RESOURCE_PATH+testconstants.ExistentResourceId, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+		Expect(rec.Header().Get("ETag")).ToNot(BeEmpty())
+		Expect(rec.Header().Get(echo.HeaderCacheControl)).To(Equal("public, max-age=86400, immutable"))
+	})
+
+	It("changes the ETag of a resource with its checksum", func() {
+		rec, err := serve(resourceServices.ResourceDataEchoHandler, didDocPath+types.RESOURCE_PATH+testconstants.ExistentResourceId, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+
+		resource := proto.Clone(testconstants.ValidResource[0].Metadata).(*resourceTypes.Metadata)
+		resource.Checksum = "another checksum"
+		ledger := utils.NewMockLedgerService(&testconstants.ValidDIDDoc, utils.MockLedger.Metadata, []resourceTypes.ResourceWithMetadata{{Resource: testconstants.ValidResource[0].Resource, Metadata: resource}})
+		request := httptest.NewRequest(http.MethodGet, didDocPath+types.RESOURCE_PATH+testconstants.ExistentResourceId, nil)
+		context, otherRec := utils.SetupEmptyContext(request, types.DIDJSONLD, ledger)
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+
+		Expect(otherRec.Header().Get("ETag")).ToNot(Equal(rec.Header().Get("ETag")))
+	})
+
+	It("uses a short max age for the lists which grow", func() {
+		rec, err := serve(didDocServices.DidDocAllVersionMetadataEchoHandler, didDocPath+types.DID_VERSIONS_PATH, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+		Expect(rec.Header().Get("ETag")).ToNot(BeEmpty())
+		Expect(rec.Header().Get(echo.HeaderCacheControl)).To(Equal("public, max-age=30"))
+
+		rec, err = serve(resourceServices.ResourceCollectionEchoHandler, didDocPath+types.DID_METADATA, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+		Expect(rec.Header().Get("ETag")).ToNot(BeEmpty())
+		Expect(rec.Header().Get(echo.HeaderCacheControl)).To(Equal("public, max-age=30"))
+	})
+
+	It("answers a matching If-None-Match with 304 and no body", func() {
+		rec, err := serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, nil)
+		Expect(err).To(BeNil())
+		etag := rec.Header().Get("ETag")
+
+		for _, ifNoneMatch := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+			rec, err = serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, map[string]string{"If-None-Match": ifNoneMatch})
+			Expect(err).To(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusNotModified), ifNoneMatch)
+			Expect(rec.Body.Len()).To(BeZero())
+			Expect(rec.Header().Get("ETag")).To(Equal(etag))
+			Expect(rec.Header().Get(echo.HeaderContentType)).To(BeEmpty())
+		}
+
+		rec, err = serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSON, map[string]string{"If-None-Match": etag})
+		Expect(err).To(BeNil())
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("answers If-Modified-Since with 304 unless the data was modified later", func() {
+		rec, err := serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, map[string]string{echo.HeaderIfModifiedSince: lastModified})
+		Expect(err).To(BeNil())
+		Expect(rec.Code).To(Equal(http.StatusNotModified))
+
+		earlier := testconstants.CreatedBefore.Format(http.TimeFormat)
+		rec, err = serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, map[string]string{echo.HeaderIfModifiedSince: earlier})
+		Expect(err).To(BeNil())
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("ignores If-Modified-Since when If-None-Match is given", func() {
+		rec, err := serve(didDocServices.DidDocEchoHandler, didDocPath, types.DIDJSONLD, map[string]string{
+			"If-None-Match":            `"other"`,
+			echo.HeaderIfModifiedSince: lastModified,
+		})
+		Expect(err).To(BeNil())
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("doesn't allow caching errors", func() {
+		rec, err := serve(didDocServices.DidDocEchoHandler, "/1.0/identifiers/"+testconstants.NotExistentTestnetDid, types.DIDJSONLD, nil)
+		Expect(err).ToNot(BeNil())
+		Expect(rec.Header().Get("ETag")).To(BeEmpty())
+		Expect(rec.Header().Get(echo.HeaderCacheControl)).To(BeEmpty())
+	})
+
+	It("asks to revalidate every time without max age", func() {
+		request := httptest.NewRequest(http.MethodGet, didDocPath, nil)
+		context, rec := utils.SetupEmptyContext(request, types.DIDJSONLD, utils.MockLedger)
+
+		Expect(didDocServices.DidDocEchoHandler(context)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderCacheControl)).To(Equal("no-cache"))
+		Expect(rec.Header().Get("ETag")).ToNot(BeEmpty())
+	})
+})
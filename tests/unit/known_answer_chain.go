@@ -0,0 +1,152 @@
+//go:build unit
+
+package unit
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	tmtypes "github.com/cosmos/cosmos-sdk/api/tendermint/types"
+	tmversion "github.com/cosmos/cosmos-sdk/api/tendermint/version"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The blocks of the known answer chain were built and signed with the types of CometBFT v0.37.4,
+// so the hashes and signatures below are the answers of CometBFT, not of the light client under test.
+
+const KnownAnswerChainId = "cometbft-kat-1"
+
+// Seeds of the ed25519 keys of the validators, in the order of the set
+var knownAnswerValidators = []struct {
+	seed  string
+	power int64
+}{
+	{"DBC1B4C900FFE48D575B5DA5C638040125F65DB0FE3E24494B76EA986457D986", 30},
+	{"4BF5122F344554C53BDE2EBB8CD2B7E3D1600AD631C385A5D7CCE23C7785459A", 30},
+	{"084FED08B978AF4D7D196A7446A86B58009E636B611DB16211B65A9AADFF29C5", 25},
+	{"E52D9C508C502347344D8C07AD91CBD6068AFC75FF6292F062A09CA381C89E71", 15},
+}
+
+// Hash of the validator set, which is the same at every height
+const knownAnswerValidatorsHash = "C45D2BEFCAD3898284BD0BF56875E777B3D99694F0D05C947067AFC4D95143C9"
+
+// Hash of each header with the signatures of its commit, which is included in the next block.
+// The commit of each height is of round height-1, the last validator is absent from the commit of height 3.
+var knownAnswerBlocks = []struct {
+	hash       string
+	partsHash  string
+	signatures []string
+}{
+	{
+		hash:      "0C0D25BE7340EAE80D48CAA183EC115469706A9272BC12B1B8B53C42ACCA0A83",
+		partsHash: "A4A8014682227C17B9E42177409696AFF94F3DA7B7853AB9DF85A1129CDA35C3",
+		signatures: []string{
+			"491D06549FA77348FE12D0D6B9C9F39188273E64C221115D768CA272773010CA04F8886A548C2993B18FAA4BA242C25218CADF511C45C652B73964C0FC798001",
+			"020295E289D87BF44DA82D012F0D88DF5C03BAB8FC7A2E10F2604119480EBBEAEE5FD1FA56464BFC9DC6E2CDEA658AAAC566F43CB4A0A09568EF8C6F50ADDA0E",
+			"12156EAFB336DD66D05D3BBC583DF63759A6C7BE2049F8BB40B016922AB69AB852975D6DDDC122734F0BB90B5E8FB1C6B0EDEA106045D9C8E4CE3F142D7BEF0D",
+			"4B2EE7D772AADBD0ABF465E524E1AFAB5E5D10996095CEB5E7A4B73A9777A03F3FE0BE60C021C4454AAA9EED126EDD30C1725F7F1D96E00DB45F691924A7490B",
+		},
+	},
+	{
+		hash:      "0CC0318FAA321302836ED1C3B3B6E94FEEC2EBA1DED06511F9CE0E4B027B81AD",
+		partsHash: "6E34EA8D2C63B2D86B3B05121EE6AA13CF7BBA6A1608CBBB1F8881E4E59A45A8",
+		signatures: []string{
+			"DF20445B32C8D3ED9C43D54AE0F2085326BD1B371F939C5CD3FF2BED4FEF893E265B792081DC29857952D8E40E3A373F0F3BEA5DA1D1C21CDFF6B8BFA0969A04",
+			"A01C81F21E1D36DB91E0EEF524318EB805AF2649CBDA5C5AF3BE3B6267590F456D7814F5A422D374E553CC511704159533D62936DBDEEC9100C96A2196BE5407",
+			"88EA87F920B662ACBFBF112983BEAB30CE12C40DE4DC0520C934FF0C77E0F903CBF453A1F6B20FF9420500DEAF75E9F75E869F3143F04913A15CDC7FEB4C7705",
+			"6F971D247BD0853378FC015F03F4C6CF1B143FA10F07824F71D581F8E736EFC28F53763DB62B131A39EE75B9B340F25D4A7F00FDCF5AEF42897B94CCEAEB3206",
+		},
+	},
+	{
+		hash:      "5920D90DB39A3747BB8D34491988F4A7F380D9DF85AD91563F96B90C34B52194",
+		partsHash: "F7410BB977412A5EDC0F6F136E028C506B90084A6C3B2F03A7C3ECE8C6D3BD0F",
+		signatures: []string{
+			"DD3CA352DCA58CD371E3CCC9C09E0122B040B2FA8D5607B45B3FA58AF034352377356C4A4C144BC6FDBE1FE671E63C9432133F9BFFEDF9D7E43CA77005F0B306",
+			"29BF1017BD06A72BFE81E681906298F692EE469D84B9E6C3005C293916349B53AF966F872AA917C8F3D09BDE339D0510426A30AAD532CFEC01CB861A6DF29A06",
+			"3B182F6784FC5E81E18798278E29ADF300D8749A7B081A17D6A9E1893E69B7D86143550548ACF881997D2742430DD7B45B7B86921FC3C0224BE6ED9C3581140F",
+			"",
+		},
+	},
+	{
+		hash:      "42DDFAFD60E32E9953B3FED603AC93D19B8328CF72EE81EE4158D0560971F638",
+		partsHash: "052CC4FC425B692861C014D86FFCEDC224385F2F7D507E28DD61EF5D5B4C5B33",
+	},
+}
+
+// NewKnownAnswerChain returns the blocks of the known answer chain. Its state can't be queried.
+func NewKnownAnswerChain() *MockChain {
+	chain := &MockChain{}
+
+	var validators []mockValidator
+	for _, validator := range knownAnswerValidators {
+		validators = append(validators, mockValidator{privKey: ed25519.NewKeyFromSeed(mustDecodeHex(validator.seed)), power: validator.power})
+	}
+	for range knownAnswerBlocks {
+		chain.validators = append(chain.validators, validators)
+	}
+	chain.validators = append(chain.validators, validators)
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	lastBlockId := &tmtypes.BlockID{PartSetHeader: &tmtypes.PartSetHeader{}}
+	var lastCommit *tmtypes.Commit
+	for i, block := range knownAnswerBlocks {
+		h := int64(i + 1)
+		header := &tmtypes.Header{
+			Version:            &tmversion.Consensus{Block: 11, App: 2},
+			ChainId:            KnownAnswerChainId,
+			Height:             h,
+			Time:               timestamppb.New(start.Add(time.Duration(i) * 5 * time.Second)),
+			LastBlockId:        lastBlockId,
+			LastCommitHash:     sha256Sum([]byte(fmt.Sprintf("last commit %d", h))),
+			DataHash:           sha256Sum([]byte(fmt.Sprintf("data %d", h))),
+			ValidatorsHash:     mustDecodeHex(knownAnswerValidatorsHash),
+			NextValidatorsHash: mustDecodeHex(knownAnswerValidatorsHash),
+			ConsensusHash:      sha256Sum([]byte("consensus")),
+			AppHash:            sha256Sum([]byte(fmt.Sprintf("app %d", h))),
+			LastResultsHash:    sha256Sum([]byte(fmt.Sprintf("results %d", h))),
+			EvidenceHash:       sha256Sum([]byte(fmt.Sprintf("evidence %d", h))),
+			ProposerAddress:    validators[0].address(),
+		}
+		chain.blocks = append(chain.blocks, &tmtypes.Block{Header: header, LastCommit: lastCommit})
+
+		lastBlockId = &tmtypes.BlockID{
+			Hash:          mustDecodeHex(block.hash),
+			PartSetHeader: &tmtypes.PartSetHeader{Total: 1, Hash: mustDecodeHex(block.partsHash)},
+		}
+		lastCommit = &tmtypes.Commit{Height: h, Round: int32(i), BlockId: lastBlockId}
+		for j, signature := range block.signatures {
+			if signature == "" {
+				lastCommit.Signatures = append(lastCommit.Signatures, &tmtypes.CommitSig{BlockIdFlag: tmtypes.BlockIDFlag_BLOCK_ID_FLAG_ABSENT})
+				continue
+			}
+			lastCommit.Signatures = append(lastCommit.Signatures, &tmtypes.CommitSig{
+				BlockIdFlag:      tmtypes.BlockIDFlag_BLOCK_ID_FLAG_COMMIT,
+				ValidatorAddress: validators[j].address(),
+				Timestamp:        timestamppb.New(header.Time.AsTime().Add(time.Second + time.Duration(j)*time.Millisecond)),
+				Signature:        mustDecodeHex(signature),
+			})
+		}
+	}
+
+	return chain
+}
+
+// KnownAnswerHeaderHash returns the hash CometBFT computed for the header at the height
+func KnownAnswerHeaderHash(height int64) []byte {
+	return mustDecodeHex(knownAnswerBlocks[height-1].hash)
+}
+
+// TamperAppHash changes the app hash of the header at the height, which makes it differ from the header signed by its commit
+func (c *MockChain) TamperAppHash(height int64) {
+	c.blocks[height-1].Header.AppHash = sha256Sum([]byte("tampered"))
+}
+
+func mustDecodeHex(s string) []byte {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return decoded
+}
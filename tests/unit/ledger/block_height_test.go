@@ -0,0 +1,86 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Ledger queries at block height", func() {
+	var primary, archive *utils.MockLedgerServer
+
+	BeforeEach(func() {
+		var err error
+		primary, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		archive, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		primary.Stop()
+		archive.Stop()
+	})
+
+	It("passes the requested height to the node", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address))
+		defer ledgerService.Close()
+
+		ctx := services.WithBlockHeight(context.Background(), 42)
+		_, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(primary.LastBlockHeight()).To(Equal(int64(42)))
+
+		_, err = ledgerService.QueryResource(ctx, testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+		Expect(primary.LastBlockHeight()).To(Equal(int64(42)))
+	})
+
+	It("queries the latest height by default", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(primary.LastBlockHeight()).To(Equal(int64(0)))
+	})
+
+	It("falls back to the next endpoint when the height is pruned", func() {
+		primary.SetEarliestHeight(100)
+
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address, archive.Address))
+		defer ledgerService.Close()
+
+		ctx := services.WithBlockHeight(context.Background(), 42)
+		_, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(archive.LastBlockHeight()).To(Equal(int64(42)))
+
+		// The pruned node stays in use for the recent heights
+		_, err = ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(primary.Calls()).To(Equal(2))
+	})
+
+	It("returns blockHeightNotAvailable when all the nodes pruned the height", func() {
+		primary.SetEarliestHeight(100)
+		archive.SetEarliestHeight(100)
+
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address, archive.Address))
+		defer ledgerService.Close()
+
+		ctx := services.WithBlockHeight(context.Background(), 42)
+		_, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("blockHeightNotAvailable"))
+		Expect(err.Code).To(Equal(types.BlockHeightNotAvailableHttpCode))
+	})
+})
@@ -0,0 +1,197 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/common/expfmt"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/services/metrics"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+// blockingLedgerService holds DIDDoc queries until released or canceled, so concurrent queries overlap
+type blockingLedgerService struct {
+	utils.MockLedgerService
+	calls    *atomic.Int32
+	canceled *atomic.Int32
+	release  chan struct{}
+}
+
+func (ls blockingLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+	ls.calls.Add(1)
+	select {
+	case <-ls.release:
+		return ls.MockLedgerService.QueryDIDDoc(ctx, did, version)
+	case <-ctx.Done():
+		ls.canceled.Add(1)
+		return nil, types.NewInternalError(did, types.JSON, ctx.Err(), false)
+	}
+}
+
+type didDocResult struct {
+	didDoc *didTypes.DidDocWithMetadata
+	err    *types.IdentityError
+}
+
+func queryDIDDocAsync(ledgerService services.LedgerServiceI, ctx context.Context, did string, version string) <-chan didDocResult {
+	result := make(chan didDocResult, 1)
+	go func() {
+		didDoc, err := ledgerService.QueryDIDDoc(ctx, did, version)
+		result <- didDocResult{didDoc: didDoc, err: err}
+	}()
+
+	return result
+}
+
+// coalescingMetric returns the value of the coalescing counter of DIDDoc queries
+func coalescingMetric(name string) float64 {
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, types.METRICS_PATH, nil))
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(rec.Body.String()))
+	Expect(err).To(BeNil())
+	for _, metric := range families[name].GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "query" && label.GetValue() == "QueryDIDDoc" {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+var _ = Describe("Coalescing ledger service", func() {
+	var ledger blockingLedgerService
+	var releaseOnce sync.Once
+
+	BeforeEach(func() {
+		ledger = blockingLedgerService{
+			MockLedgerService: utils.MockLedger,
+			calls:             &atomic.Int32{},
+			canceled:          &atomic.Int32{},
+			release:           make(chan struct{}),
+		}
+		releaseOnce = sync.Once{}
+	})
+
+	AfterEach(func() {
+		releaseOnce.Do(func() { close(ledger.release) })
+	})
+
+	It("makes concurrent identical queries share a single call", func() {
+		coalescingLedger := services.NewCoalescingLedgerService(ledger)
+		callsBefore := coalescingMetric("did_resolver_ledger_coalescing_calls_total")
+		coalescedBefore := coalescingMetric("did_resolver_ledger_coalesced_queries_total")
+
+		var results []<-chan didDocResult
+		for i := 0; i < 10; i++ {
+			results = append(results, queryDIDDocAsync(coalescingLedger, context.Background(), testconstants.ExistentDid, ""))
+		}
+		Eventually(ledger.calls.Load).Should(Equal(int32(1)))
+		// Let the other queries join the call
+		time.Sleep(100 * time.Millisecond)
+		releaseOnce.Do(func() { close(ledger.release) })
+
+		for _, result := range results {
+			r := <-result
+			Expect(r.err).To(BeNil())
+			Expect(r.didDoc.DidDoc.Id).To(Equal(testconstants.ExistentDid))
+		}
+		Expect(ledger.calls.Load()).To(Equal(int32(1)))
+		Expect(coalescingMetric("did_resolver_ledger_coalescing_calls_total")).To(Equal(callsBefore + 1))
+		Expect(coalescingMetric("did_resolver_ledger_coalesced_queries_total")).To(Equal(coalescedBefore + 9))
+	})
+
+	It("shares errors, giving each query its own copy", func() {
+		coalescingLedger := services.NewCoalescingLedgerService(ledger)
+		callsBefore := coalescingMetric("did_resolver_ledger_coalescing_calls_total")
+
+		first := queryDIDDocAsync(coalescingLedger, context.Background(), testconstants.NotExistentMainnetDid, "")
+		second := queryDIDDocAsync(coalescingLedger, context.Background(), testconstants.NotExistentMainnetDid, "")
+		Eventually(ledger.calls.Load).Should(Equal(int32(1)))
+		time.Sleep(100 * time.Millisecond)
+		releaseOnce.Do(func() { close(ledger.release) })
+
+		firstErr, secondErr := (<-first).err, (<-second).err
+		Expect(firstErr).ToNot(BeNil())
+		Expect(firstErr.Code).To(Equal(types.NotFoundHttpCode))
+		Expect(secondErr).To(Equal(firstErr))
+		Expect(secondErr).ToNot(BeIdenticalTo(firstErr))
+		Expect(coalescingMetric("did_resolver_ledger_coalescing_calls_total")).To(Equal(callsBefore + 1))
+	})
+
+	It("doesn't share calls between different versions or block heights", func() {
+		coalescingLedger := services.NewCoalescingLedgerService(ledger)
+		coalescedBefore := coalescingMetric("did_resolver_ledger_coalesced_queries_total")
+
+		_ = queryDIDDocAsync(coalescingLedger, context.Background(), testconstants.ExistentDid, "")
+		_ = queryDIDDocAsync(coalescingLedger, context.Background(), testconstants.ExistentDid, testconstants.ValidVersionId)
+		_ = queryDIDDocAsync(coalescingLedger, services.WithBlockHeight(context.Background(), 42), testconstants.ExistentDid, "")
+
+		Eventually(ledger.calls.Load).Should(Equal(int32(3)))
+		Expect(coalescingMetric("did_resolver_ledger_coalesced_queries_total")).To(Equal(coalescedBefore))
+	})
+
+	It("stops waiting when the context of a query is done, without aborting the shared call", func() {
+		coalescingLedger := services.NewCoalescingLedgerService(ledger)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		abandoned := queryDIDDocAsync(coalescingLedger, ctx, testconstants.ExistentDid, "")
+		Eventually(ledger.calls.Load).Should(Equal(int32(1)))
+		waiting := queryDIDDocAsync(coalescingLedger, context.Background(), testconstants.ExistentDid, "")
+		time.Sleep(100 * time.Millisecond)
+
+		cancel()
+		r := <-abandoned
+		Expect(r.err).ToNot(BeNil())
+		Expect(r.err.Code).To(Equal(types.InternalErrorHttpCode))
+
+		releaseOnce.Do(func() { close(ledger.release) })
+		r = <-waiting
+		Expect(r.err).To(BeNil())
+		Expect(r.didDoc.DidDoc.Id).To(Equal(testconstants.ExistentDid))
+		Expect(ledger.calls.Load()).To(Equal(int32(1)))
+	})
+
+	It("cancels the shared call once none of its queries waits for it", func() {
+		coalescingLedger := services.NewCoalescingLedgerService(ledger)
+
+		firstCtx, cancelFirst := context.WithCancel(context.Background())
+		first := queryDIDDocAsync(coalescingLedger, firstCtx, testconstants.ExistentDid, "")
+		Eventually(ledger.calls.Load).Should(Equal(int32(1)))
+		secondCtx, cancelSecond := context.WithCancel(context.Background())
+		second := queryDIDDocAsync(coalescingLedger, secondCtx, testconstants.ExistentDid, "")
+		time.Sleep(100 * time.Millisecond)
+
+		cancelFirst()
+		Expect((<-first).err).ToNot(BeNil())
+		Consistently(ledger.canceled.Load, 100*time.Millisecond).Should(BeZero())
+
+		cancelSecond()
+		Expect((<-second).err).ToNot(BeNil())
+		Eventually(ledger.canceled.Load).Should(Equal(int32(1)))
+
+		// The next query doesn't join the canceled call
+		third := queryDIDDocAsync(coalescingLedger, context.Background(), testconstants.ExistentDid, "")
+		Eventually(ledger.calls.Load).Should(Equal(int32(2)))
+		releaseOnce.Do(func() { close(ledger.release) })
+		r := <-third
+		Expect(r.err).To(BeNil())
+		Expect(r.didDoc.DidDoc.Id).To(Equal(testconstants.ExistentDid))
+	})
+})
@@ -0,0 +1,104 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func newTestNetwork(poolSize int, addresses ...string) types.Network {
+	network := types.Network{
+		PoolSize:         poolSize,
+		EndpointStrategy: types.FailoverStrategy,
+	}
+	for _, address := range addresses {
+		network.Endpoints = append(network.Endpoints, types.Endpoint{
+			Address: address,
+			UseTls:  false,
+			Timeout: 5 * time.Second,
+		})
+	}
+
+	return network
+}
+
+func newTestLedgerService(network types.Network) services.LedgerService {
+	ledgerService := services.NewLedgerService()
+	for _, namespace := range []string{testconstants.ValidMainnetNamespace, testconstants.ValidTestnetNamespace} {
+		network.Namespace = namespace
+		err := ledgerService.RegisterLedger(types.DID_METHOD, network)
+		Expect(err).To(BeNil())
+	}
+
+	return ledgerService
+}
+
+var _ = Describe("Ledger connection pool", func() {
+	var server *utils.MockLedgerServer
+
+	BeforeEach(func() {
+		var err error
+		server, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	It("reuses one connection for all the queries", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, server.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		_, err = ledgerService.QueryAllDidDocVersionsMetadata(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		_, err = ledgerService.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		_, err = ledgerService.QueryResource(context.Background(), testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+
+		Expect(server.Calls()).To(Equal(4))
+		Expect(server.Connections()).To(Equal(1))
+	})
+
+	It("spreads the queries over the pool", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(2, server.Address))
+		defer ledgerService.Close()
+
+		for i := 0; i < 4; i++ {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).To(BeNil())
+		}
+
+		Expect(server.Connections()).To(Equal(2))
+	})
+
+	It("returns notFound for not existent DID", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, server.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("notFound"))
+	})
+
+	It("fails to query after the connections were closed", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, server.Address))
+		Expect(ledgerService.Close()).To(BeNil())
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("temporarilyUnavailable"))
+	})
+})
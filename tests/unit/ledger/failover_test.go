@@ -0,0 +1,122 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Ledger endpoints failover", func() {
+	var primary, secondary *utils.MockLedgerServer
+
+	BeforeEach(func() {
+		var err error
+		primary, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		secondary, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		primary.Stop()
+		secondary.Stop()
+	})
+
+	It("queries the first endpoint while it is available", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address, secondary.Address))
+		defer ledgerService.Close()
+
+		for i := 0; i < 3; i++ {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).To(BeNil())
+		}
+
+		Expect(primary.Calls()).To(Equal(3))
+		Expect(secondary.Calls()).To(Equal(0))
+	})
+
+	It("fails over to the next endpoint when the first one is down", func() {
+		primary.Stop()
+
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address, secondary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(secondary.Calls()).To(Equal(1))
+	})
+
+	It("doesn't fail over when the DID is not found", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address, secondary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("notFound"))
+		Expect(secondary.Calls()).To(Equal(0))
+	})
+
+	It("returns temporarilyUnavailable when all the endpoints are down", func() {
+		primary.Stop()
+		secondary.Stop()
+
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address, secondary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("temporarilyUnavailable"))
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+	})
+
+	It("spreads the queries over the endpoints with round-robin strategy", func() {
+		network := newTestNetwork(1, primary.Address, secondary.Address)
+		network.EndpointStrategy = types.RoundRobinStrategy
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		for i := 0; i < 4; i++ {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).To(BeNil())
+		}
+
+		Expect(primary.Calls()).To(Equal(2))
+		Expect(secondary.Calls()).To(Equal(2))
+	})
+
+	It("skips the endpoint which is catching up with the chain", func() {
+		primary.SetSyncing(true)
+
+		network := newTestNetwork(1, primary.Address, secondary.Address)
+		network.HealthCheckInterval = 50 * time.Millisecond
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		Eventually(func() int {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).To(BeNil())
+			return secondary.Calls()
+		}, time.Second, 100*time.Millisecond).Should(BeNumerically(">", 0))
+	})
+	It("doesn't query any endpoint once the request is canceled", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, primary.Address, secondary.Address))
+		defer ledgerService.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("internalError"))
+		Expect(primary.Calls()).To(Equal(0))
+		Expect(secondary.Calls()).To(Equal(0))
+	})
+})
@@ -0,0 +1,267 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/services"
+	healthServices "github.com/cheqd/did-resolver/services/health"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func newHealthTestRouter(ledgers map[string]services.LedgerServiceI) services.LedgerRouter {
+	router := services.NewLedgerRouter()
+	for namespace, ledgerService := range ledgers {
+		router.Register(types.DID_METHOD, namespace, ledgerService)
+	}
+
+	return router
+}
+
+// blockingProber is a ledger whose probes wait until they are released
+type blockingProber struct {
+	utils.MockLedgerService
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (p *blockingProber) Probe(ctx context.Context, method string, namespace string) error {
+	p.calls.Add(1)
+	<-p.release
+	return nil
+}
+
+func checkReadiness(checker *services.HealthChecker) (int, types.ReadinessStatus) {
+	request := httptest.NewRequest(http.MethodGet, types.HEALTH_READY_PATH, nil)
+	rec := httptest.NewRecorder()
+	Expect(healthServices.ReadinessEchoHandler(checker)(echo.New().NewContext(request, rec))).To(Succeed())
+
+	var status types.ReadinessStatus
+	Expect(json.Unmarshal(rec.Body.Bytes(), &status)).To(Succeed())
+	return rec.Code, status
+}
+
+var _ = Describe("Health endpoints", func() {
+	var first, second *utils.MockLedgerServer
+
+	BeforeEach(func() {
+		var err error
+		first, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		second, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		first.Stop()
+		second.Stop()
+	})
+
+	It("reports liveness without querying the ledger", func() {
+		request := httptest.NewRequest(http.MethodGet, types.HEALTH_LIVE_PATH, nil)
+		rec := httptest.NewRecorder()
+
+		Expect(healthServices.LivenessEchoHandler(echo.New().NewContext(request, rec))).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{"status": "alive"}`))
+		Expect(first.Calls()).To(Equal(0))
+	})
+
+	It("is ready when the ledger of every network is reachable", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, first.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{
+			testconstants.ValidMainnetNamespace: ledgerService,
+			testconstants.ValidTestnetNamespace: ledgerService,
+		})
+
+		code, status := checkReadiness(services.NewHealthChecker(router, types.HealthConfig{}))
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(status.Ready).To(BeTrue())
+		Expect(status.Networks).To(HaveLen(2))
+		Expect(status.Networks[0].Namespace).To(Equal(testconstants.ValidMainnetNamespace))
+		Expect(status.Networks[1].Namespace).To(Equal(testconstants.ValidTestnetNamespace))
+		for _, network := range status.Networks {
+			Expect(network.Method).To(Equal(types.DID_METHOD))
+			Expect(network.Required).To(BeTrue())
+			Expect(network.Reachable).To(BeTrue())
+			Expect(network.Error).To(BeEmpty())
+		}
+	})
+
+	It("is ready while one of the endpoints is reachable", func() {
+		second.Stop()
+		ledgerService := newTestLedgerService(newTestNetwork(1, second.Address, first.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+
+		code, status := checkReadiness(services.NewHealthChecker(router, types.HealthConfig{}))
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(status.Networks[0].Reachable).To(BeTrue())
+
+		networkStatus, ok := ledgerService.NetworkStatus(types.DID_METHOD, testconstants.ValidMainnetNamespace)
+		Expect(ok).To(BeTrue())
+		Expect(networkStatus.Endpoints).To(Equal([]types.EndpointStatus{
+			{Address: second.Address, Healthy: false, CircuitBreaker: "closed"},
+			{Address: first.Address, Healthy: true, CircuitBreaker: "closed"},
+		}))
+	})
+
+	It("is not ready when a required network is unreachable", func() {
+		first.Stop()
+		ledgerService := newTestLedgerService(newTestNetwork(1, first.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+
+		code, status := checkReadiness(services.NewHealthChecker(router, types.HealthConfig{}))
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(status.Ready).To(BeFalse())
+		Expect(status.Networks[0].Reachable).To(BeFalse())
+		Expect(status.Networks[0].Error).ToNot(BeEmpty())
+	})
+
+	It("is not ready when the node is catching up with the chain", func() {
+		first.SetSyncing(true)
+		ledgerService := newTestLedgerService(newTestNetwork(1, first.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+
+		code, status := checkReadiness(services.NewHealthChecker(router, types.HealthConfig{}))
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(status.Networks[0].Error).To(ContainSubstring("catching up"))
+	})
+
+	It("is not ready when fewer endpoints than the quorum are reachable", func() {
+		second.Stop()
+		ledgerService := newTestLedgerService(newQuorumTestNetwork(2, first.Address, second.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+
+		code, _ := checkReadiness(services.NewHealthChecker(router, types.HealthConfig{}))
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("stays ready when a network which isn't required is unreachable", func() {
+		second.Stop()
+		up := newTestLedgerService(newTestNetwork(1, first.Address))
+		defer up.Close()
+		down := newTestLedgerService(newTestNetwork(1, second.Address))
+		defer down.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{
+			testconstants.ValidMainnetNamespace: up,
+			testconstants.ValidTestnetNamespace: down,
+		})
+
+		code, status := checkReadiness(services.NewHealthChecker(router, types.HealthConfig{
+			RequiredNamespaces: []string{testconstants.ValidMainnetNamespace},
+		}))
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(status.Ready).To(BeTrue())
+		Expect(status.Networks[1].Required).To(BeFalse())
+		Expect(status.Networks[1].Reachable).To(BeFalse())
+	})
+
+	It("reuses the probe results for the cache TTL", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, first.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+		checker := services.NewHealthChecker(router, types.HealthConfig{CacheTTL: time.Minute})
+
+		_, status := checkReadiness(checker)
+		Expect(first.Calls()).To(Equal(1))
+
+		first.Stop()
+		code, cached := checkReadiness(checker)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(cached.Networks[0].CheckedAt).To(BeTemporally("==", status.Networks[0].CheckedAt))
+		Expect(first.Calls()).To(Equal(1))
+	})
+
+	It("probes the ledger again without a cache TTL", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, first.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+		checker := services.NewHealthChecker(router, types.HealthConfig{})
+
+		checkReadiness(checker)
+		checkReadiness(checker)
+		Expect(first.Calls()).To(Equal(2))
+	})
+
+	It("probes the nodes of the REST API", func() {
+		restServer := utils.NewMockLedgerRESTServer(utils.MockLedger)
+		defer restServer.Stop()
+		ledgerService := newTestRESTLedgerService(newTestNetwork(1, restServer.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+		checker := services.NewHealthChecker(router, types.HealthConfig{})
+
+		code, _ := checkReadiness(checker)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(restServer.Calls()).To(Equal(1))
+
+		restServer.FailWith(http.StatusServiceUnavailable)
+		code, status := checkReadiness(checker)
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(status.Networks[0].Reachable).To(BeFalse())
+	})
+
+	It("is ready with ledger services which don't depend on nodes", func() {
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: utils.MockLedger})
+
+		code, status := checkReadiness(services.NewHealthChecker(router, types.HealthConfig{}))
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(status.Networks[0].Reachable).To(BeTrue())
+	})
+
+	It("stops waiting for the probe when the request is done", func() {
+		ledgerService := newTestLedgerService(newTestNetwork(1, first.Address))
+		defer ledgerService.Close()
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: ledgerService})
+		checker := services.NewHealthChecker(router, types.HealthConfig{CacheTTL: time.Minute})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		status := checker.Readiness(ctx)
+		Expect(status.Ready).To(BeFalse())
+
+		// The canceled probe isn't cached
+		Expect(checker.Readiness(context.Background()).Ready).To(BeTrue())
+	})
+
+	It("shares the probe among concurrent checks, which stop waiting when their requests are done", func() {
+		prober := &blockingProber{MockLedgerService: utils.MockLedger, release: make(chan struct{})}
+		router := newHealthTestRouter(map[string]services.LedgerServiceI{testconstants.ValidMainnetNamespace: prober})
+		checker := services.NewHealthChecker(router, types.HealthConfig{CacheTTL: time.Minute})
+
+		done := make(chan types.ReadinessStatus)
+		go func() {
+			done <- checker.Readiness(context.Background())
+		}()
+		Eventually(prober.calls.Load).Should(Equal(int32(1)))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		status := checker.Readiness(ctx)
+		Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+		Expect(status.Ready).To(BeFalse())
+
+		close(prober.release)
+		Eventually(done).Should(Receive(HaveField("Ready", BeTrue())))
+		Expect(checker.Readiness(context.Background()).Ready).To(BeTrue())
+		Expect(prober.calls.Load()).To(Equal(int32(1)))
+	})
+})
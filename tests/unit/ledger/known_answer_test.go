@@ -0,0 +1,82 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Headers signed by CometBFT", func() {
+	var server *utils.MockLedgerServer
+	var chain *utils.MockChain
+
+	newKnownAnswerNetwork := func(trustedHeight int64) types.Network {
+		network := newTestNetwork(1, server.Address)
+		network.Verification = &types.VerificationConfig{
+			ChainId:        utils.KnownAnswerChainId,
+			TrustedHeight:  trustedHeight,
+			TrustedHash:    utils.KnownAnswerHeaderHash(trustedHeight),
+			TrustingPeriod: 100 * 365 * 24 * time.Hour,
+		}
+		return network
+	}
+
+	BeforeEach(func() {
+		var err error
+		server, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+
+		chain = utils.NewKnownAnswerChain()
+		server.SetChain(chain)
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	DescribeTable("are verified by the light client",
+		func(trustedHeight int64) {
+			ledgerService := newTestLedgerService(newKnownAnswerNetwork(trustedHeight))
+			defer ledgerService.Close()
+
+			// The latest state which can be verified is the one committed to by header 3
+			ctx, err := ledgerService.PinTrustedRoot(context.Background(), testconstants.ExistentDid)
+			Expect(err).To(BeNil())
+			height, ok := services.BlockHeightFromContext(ctx)
+			Expect(ok).To(BeTrue())
+			Expect(height).To(Equal(int64(2)))
+		},
+
+		Entry("skipping from the trusted header", int64(1)),
+
+		Entry("adjacent to the trusted header", int64(2)),
+
+		Entry("linked to the trusted header by the hash of the previous block", int64(4)),
+	)
+
+	It("are rejected once changed after they were signed", func() {
+		chain.TamperAppHash(3)
+
+		ledgerService := newTestLedgerService(newKnownAnswerNetwork(1))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.PinTrustedRoot(context.Background(), testconstants.ExistentDid)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.InternalErrorHttpCode))
+	})
+
+	It("are hashed by the mock chain the way CometBFT does", func() {
+		for height := int64(1); height <= chain.Height(); height++ {
+			Expect(chain.HeaderHash(height)).To(Equal(utils.KnownAnswerHeaderHash(height)))
+		}
+	})
+})
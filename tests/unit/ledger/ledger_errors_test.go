@@ -0,0 +1,155 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+type ledgerErrorTestCase struct {
+	code            codes.Code
+	message         string
+	expectedMessage string
+	expectedCode    int
+}
+
+var _ = DescribeTable("Classify ledger errors", func(testCase ledgerErrorTestCase) {
+	server, err := utils.NewMockLedgerServer(utils.MockLedger)
+	Expect(err).To(BeNil())
+	defer server.Stop()
+	server.FailWith(testCase.code, testCase.message)
+
+	ledgerService := newTestLedgerService(newTestNetwork(1, server.Address))
+	defer ledgerService.Close()
+
+	_, identityErr := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+	Expect(identityErr).ToNot(BeNil())
+	Expect(identityErr.Message).To(Equal(testCase.expectedMessage))
+	Expect(identityErr.Code).To(Equal(testCase.expectedCode))
+},
+
+	Entry(
+		"NotFound is notFound",
+		ledgerErrorTestCase{codes.NotFound, "DID Doc not found", "notFound", types.NotFoundHttpCode},
+	),
+
+	Entry(
+		"not found module error is notFound",
+		ledgerErrorTestCase{codes.Unknown, "did:cheqd:mainnet:abc: DID Doc not found", "notFound", types.NotFoundHttpCode},
+	),
+
+	Entry(
+		"InvalidArgument is invalidDid",
+		ledgerErrorTestCase{codes.InvalidArgument, "invalid request", "invalidDid", types.InvalidDidHttpCode},
+	),
+
+	Entry(
+		"Unavailable is temporarilyUnavailable",
+		ledgerErrorTestCase{codes.Unavailable, "node is down", "temporarilyUnavailable", types.ServiceUnavailableHttpCode},
+	),
+
+	Entry(
+		"DeadlineExceeded is temporarilyUnavailable with gateway timeout",
+		ledgerErrorTestCase{codes.DeadlineExceeded, "too slow", "temporarilyUnavailable", types.GatewayTimeoutHttpCode},
+	),
+
+	Entry(
+		"answer beyond the receive limit is internalError",
+		ledgerErrorTestCase{codes.ResourceExhausted, "grpc: received message larger than max (5242880 vs. 4194304)", "internalError", types.InternalErrorHttpCode},
+	),
+
+	Entry(
+		"PermissionDenied is internalError",
+		ledgerErrorTestCase{codes.PermissionDenied, "forbidden", "internalError", types.InternalErrorHttpCode},
+	),
+
+	Entry(
+		"other module error is internalError",
+		ledgerErrorTestCase{codes.Unknown, "panic in keeper", "internalError", types.InternalErrorHttpCode},
+	),
+)
+
+var _ = Describe("Error handler", func() {
+	It("suggests when to retry temporary errors", func() {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+		services.CustomHTTPErrorHandler(types.NewTemporarilyUnavailableError(testconstants.ExistentDid, types.JSON, nil, false), c)
+
+		Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(rec.Header().Get(echo.HeaderRetryAfter)).To(Equal("10"))
+	})
+
+	It("doesn't suggest to retry permanent errors", func() {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+		services.CustomHTTPErrorHandler(types.NewNotFoundError(testconstants.ExistentDid, types.JSON, nil, false), c)
+
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Header().Get(echo.HeaderRetryAfter)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Resources beyond the receive limit", func() {
+	var (
+		primary, secondary *utils.MockLedgerServer
+		ledgerService      services.LedgerService
+		resourceId         string
+	)
+
+	BeforeEach(func() {
+		resource := proto.Clone(&testconstants.ValidResource[0]).(*resourceTypes.ResourceWithMetadata)
+		resource.Resource = &resourceTypes.Resource{Data: make([]byte, 5<<20)}
+		resourceId = resource.Metadata.Id
+		ledger := utils.NewMockLedgerService(&testconstants.ValidDIDDoc, utils.MockLedger.Metadata, []resourceTypes.ResourceWithMetadata{
+			{Resource: resource.Resource, Metadata: resource.Metadata},
+		})
+
+		var err error
+		primary, err = utils.NewMockLedgerServer(ledger)
+		Expect(err).To(BeNil())
+		secondary, err = utils.NewMockLedgerServer(ledger)
+		Expect(err).To(BeNil())
+
+		network := newTestNetwork(1, primary.Address, secondary.Address)
+		network.Retry = newTestRetryPolicy(3)
+		ledgerService = newTestLedgerService(network)
+	})
+
+	AfterEach(func() {
+		Expect(ledgerService.Close()).To(Succeed())
+		primary.Stop()
+		secondary.Stop()
+	})
+
+	It("are rejected as too large without failing over or retrying", func() {
+		_, identityErr := ledgerService.QueryResource(context.Background(), testconstants.ExistentDid, resourceId)
+		Expect(identityErr).ToNot(BeNil())
+		Expect(identityErr.Message).To(Equal("resourceTooLarge"))
+		Expect(identityErr.Code).To(Equal(types.ResourceTooLargeHttpCode))
+		Expect(primary.Calls() + secondary.Calls()).To(Equal(1))
+	})
+
+	It("still have their metadata served", func() {
+		resourceService := services.NewResourceService(types.DID_METHOD, ledgerService, types.DefaultResourceMaxSize)
+
+		result, identityErr := resourceService.DereferenceResourceMetadata(context.Background(), testconstants.ExistentDid, resourceId, types.DIDJSON)
+		Expect(identityErr).To(BeNil())
+		Expect(result.ContentStream).To(Equal(testconstants.ValidDereferencedResourceList))
+	})
+})
@@ -0,0 +1,103 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func newPagedLedger(size int) utils.MockLedgerService {
+	metadata := make([]*didTypes.Metadata, size)
+	resources := make([]resourceTypes.ResourceWithMetadata, size)
+	for i := 0; i < size; i++ {
+		metadata[i] = &didTypes.Metadata{VersionId: "version-" + strconv.Itoa(i)}
+		resources[i] = resourceTypes.ResourceWithMetadata{
+			Metadata: &resourceTypes.Metadata{
+				CollectionId: testconstants.ExistentDid,
+				Id:           "resource-" + strconv.Itoa(i),
+			},
+		}
+	}
+
+	return utils.NewMockLedgerService(&testconstants.ValidDIDDoc, metadata, resources)
+}
+
+var _ = Describe("Ledger pagination", func() {
+	var server *utils.MockLedgerServer
+
+	BeforeEach(func() {
+		var err error
+		server, err = utils.NewMockLedgerServer(newPagedLedger(5))
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	It("follows next keys to the end of the version history", func() {
+		network := newTestNetwork(1, server.Address)
+		network.PageSize = 2
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		versions, err := ledgerService.QueryAllDidDocVersionsMetadata(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(versions).To(HaveLen(5))
+		for i, version := range versions {
+			Expect(version.VersionId).To(Equal("version-" + strconv.Itoa(i)))
+		}
+		Expect(server.Calls()).To(Equal(3))
+	})
+
+	It("follows next keys to the end of the collection", func() {
+		network := newTestNetwork(1, server.Address)
+		network.PageSize = 2
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		resources, err := ledgerService.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(resources).To(HaveLen(5))
+		for i, resource := range resources {
+			Expect(resource.Id).To(Equal("resource-" + strconv.Itoa(i)))
+		}
+		Expect(server.Calls()).To(Equal(3))
+	})
+
+	It("requests a single page when everything fits into it", func() {
+		network := newTestNetwork(1, server.Address)
+		network.PageSize = 5
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		resources, err := ledgerService.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(resources).To(HaveLen(5))
+		Expect(server.Calls()).To(Equal(1))
+	})
+
+	It("fails instead of truncating the list when there are too many pages", func() {
+		network := newTestNetwork(1, server.Address)
+		network.PageSize = 2
+		network.MaxPages = 2
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		versions, err := ledgerService.QueryAllDidDocVersionsMetadata(context.Background(), testconstants.ExistentDid)
+		Expect(versions).To(BeNil())
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.InternalErrorHttpCode))
+		Expect(server.Calls()).To(Equal(2))
+	})
+})
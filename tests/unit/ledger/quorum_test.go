@@ -0,0 +1,128 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func newQuorumTestNetwork(quorum int, addresses ...string) types.Network {
+	network := newTestNetwork(1, addresses...)
+	network.Quorum = quorum
+
+	return network
+}
+
+var _ = Describe("Ledger queries with a quorum", func() {
+	var first, second, diverging *utils.MockLedgerServer
+	var divergingLedger utils.MockLedgerService
+
+	BeforeEach(func() {
+		var err error
+		first, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		second, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+
+		// A node which serves a deactivated DIDDoc
+		metadata := proto.Clone(&testconstants.ValidMetadata).(*didTypes.Metadata)
+		metadata.Deactivated = true
+		divergingLedger = utils.NewMockLedgerService(&testconstants.ValidDIDDoc, []*didTypes.Metadata{metadata}, utils.MockLedger.Resources)
+		diverging, err = utils.NewMockLedgerServer(divergingLedger)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		first.Stop()
+		second.Stop()
+		diverging.Stop()
+	})
+
+	It("queries all the endpoints and answers when they agree", func() {
+		ledgerService := newTestLedgerService(newQuorumTestNetwork(2, first.Address, second.Address))
+		defer ledgerService.Close()
+
+		didDoc, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(didDoc.Metadata.Deactivated).To(BeFalse())
+
+		resource, err := ledgerService.QueryResource(context.Background(), testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+		Expect(resource.Resource.Data).To(Equal(testconstants.ValidResource[0].Resource.Data))
+
+		resources, err := ledgerService.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(resources).To(HaveLen(1))
+
+		Expect(first.Calls()).To(Equal(3))
+		Expect(second.Calls()).To(Equal(3))
+	})
+
+	It("answers with the quorum when an endpoint diverges", func() {
+		ledgerService := newTestLedgerService(newQuorumTestNetwork(2, diverging.Address, first.Address, second.Address))
+		defer ledgerService.Close()
+
+		didDoc, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(didDoc.Metadata.Deactivated).To(BeFalse())
+		Expect(diverging.Calls()).To(Equal(1))
+	})
+
+	It("fails when the endpoints don't agree", func() {
+		ledgerService := newTestLedgerService(newQuorumTestNetwork(2, first.Address, diverging.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("temporarilyUnavailable"))
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+	})
+
+	It("fails when as many endpoints disagree as agree", func() {
+		anotherDiverging, err := utils.NewMockLedgerServer(divergingLedger)
+		Expect(err).To(BeNil())
+		defer anotherDiverging.Stop()
+
+		ledgerService := newTestLedgerService(newQuorumTestNetwork(2, first.Address, second.Address, diverging.Address, anotherDiverging.Address))
+		defer ledgerService.Close()
+
+		_, queryErr := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(queryErr).ToNot(BeNil())
+		Expect(queryErr.Code).To(Equal(types.ServiceUnavailableHttpCode))
+	})
+
+	It("agrees that the DIDDoc doesn't exist", func() {
+		ledgerService := newTestLedgerService(newQuorumTestNetwork(2, first.Address, second.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.NotFoundHttpCode))
+	})
+
+	It("doesn't count the endpoints which are down", func() {
+		third, err := utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		third.Stop()
+
+		ledgerService := newTestLedgerService(newQuorumTestNetwork(2, first.Address, second.Address, third.Address))
+		defer ledgerService.Close()
+
+		_, queryErr := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(queryErr).To(BeNil())
+
+		second.Stop()
+		_, queryErr = ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(queryErr).ToNot(BeNil())
+		Expect(queryErr.Code).To(Equal(types.ServiceUnavailableHttpCode))
+	})
+})
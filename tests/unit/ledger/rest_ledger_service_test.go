@@ -0,0 +1,208 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func newTestRESTLedgerService(network types.Network) services.RESTLedgerService {
+	ledgerService := services.NewRESTLedgerService()
+	for _, namespace := range []string{testconstants.ValidMainnetNamespace, testconstants.ValidTestnetNamespace} {
+		network.Namespace = namespace
+		network.Api = types.RESTApi
+		err := ledgerService.RegisterLedger(types.DID_METHOD, network)
+		Expect(err).To(BeNil())
+	}
+
+	return ledgerService
+}
+
+var _ = Describe("REST ledger service", func() {
+	var grpcServer *utils.MockLedgerServer
+	var restServer *utils.MockLedgerRESTServer
+
+	BeforeEach(func() {
+		var err error
+		grpcServer, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		restServer = utils.NewMockLedgerRESTServer(utils.MockLedger)
+	})
+
+	AfterEach(func() {
+		grpcServer.Stop()
+		restServer.Stop()
+	})
+
+	It("returns the same data as the gRPC API", func() {
+		grpcLedger := newTestLedgerService(newTestNetwork(1, grpcServer.Address))
+		defer grpcLedger.Close()
+		restLedger := newTestRESTLedgerService(newTestNetwork(1, restServer.Address))
+		defer restLedger.Close()
+		ctx := context.Background()
+
+		expectedDidDoc, err := grpcLedger.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		didDoc, err := restLedger.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(expectedDidDoc, didDoc)).To(BeTrue())
+
+		expectedDidDoc, err = grpcLedger.QueryDIDDoc(ctx, testconstants.ExistentDid, testconstants.ValidVersionId)
+		Expect(err).To(BeNil())
+		didDoc, err = restLedger.QueryDIDDoc(ctx, testconstants.ExistentDid, testconstants.ValidVersionId)
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(expectedDidDoc, didDoc)).To(BeTrue())
+
+		expectedVersions, err := grpcLedger.QueryAllDidDocVersionsMetadata(ctx, testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		versions, err := restLedger.QueryAllDidDocVersionsMetadata(ctx, testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(versions).To(HaveLen(len(expectedVersions)))
+		for i := range versions {
+			Expect(proto.Equal(expectedVersions[i], versions[i])).To(BeTrue())
+		}
+
+		expectedResource, err := grpcLedger.QueryResource(ctx, testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+		resource, err := restLedger.QueryResource(ctx, testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(expectedResource, resource)).To(BeTrue())
+
+		expectedResources, err := grpcLedger.QueryCollectionResources(ctx, testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		resources, err := restLedger.QueryCollectionResources(ctx, testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(resources).To(HaveLen(len(expectedResources)))
+		for i := range resources {
+			Expect(proto.Equal(expectedResources[i], resources[i])).To(BeTrue())
+		}
+	})
+
+	It("returns notFound for a DID which doesn't exist", func() {
+		restLedger := newTestRESTLedgerService(newTestNetwork(1, restServer.Address))
+		defer restLedger.Close()
+
+		didDoc, err := restLedger.QueryDIDDoc(context.Background(), testconstants.NotExistentMainnetDid, "")
+		Expect(didDoc).To(BeNil())
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.NotFoundHttpCode))
+	})
+
+	It("follows next keys to the end of the collection", func() {
+		pagedServer := utils.NewMockLedgerRESTServer(newPagedLedger(5))
+		defer pagedServer.Stop()
+
+		network := newTestNetwork(1, pagedServer.Address)
+		network.PageSize = 2
+		restLedger := newTestRESTLedgerService(network)
+		defer restLedger.Close()
+
+		resources, err := restLedger.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(resources).To(HaveLen(5))
+		Expect(pagedServer.Calls()).To(Equal(3))
+	})
+
+	It("fails over to the next endpoint when a proxy can't reach the node", func() {
+		failing := utils.NewMockLedgerRESTServer(utils.MockLedger)
+		defer failing.Stop()
+		failing.FailWith(http.StatusServiceUnavailable)
+
+		restLedger := newTestRESTLedgerService(newTestNetwork(1, failing.Address, restServer.Address))
+		defer restLedger.Close()
+
+		_, err := restLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(failing.Calls()).To(Equal(1))
+		Expect(restServer.Calls()).To(Equal(1))
+	})
+
+	It("returns temporarilyUnavailable when no endpoint can be reached", func() {
+		restServer.FailWith(http.StatusBadGateway)
+
+		restLedger := newTestRESTLedgerService(newTestNetwork(1, restServer.Address))
+		defer restLedger.Close()
+
+		_, err := restLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+	})
+
+	It("passes the requested height to the node", func() {
+		restLedger := newTestRESTLedgerService(newTestNetwork(1, restServer.Address))
+		defer restLedger.Close()
+
+		_, err := restLedger.QueryDIDDoc(services.WithBlockHeight(context.Background(), 42), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(restServer.LastBlockHeight()).To(Equal(int64(42)))
+	})
+
+	It("returns blockHeightNotAvailable when the node pruned the height", func() {
+		restServer.SetEarliestHeight(100)
+
+		restLedger := newTestRESTLedgerService(newTestNetwork(1, restServer.Address))
+		defer restLedger.Close()
+
+		_, err := restLedger.QueryDIDDoc(services.WithBlockHeight(context.Background(), 42), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.BlockHeightNotAvailableHttpCode))
+	})
+})
+
+var _ = Describe("Ledger router", func() {
+	var grpcServer *utils.MockLedgerServer
+	var restServer *utils.MockLedgerRESTServer
+
+	BeforeEach(func() {
+		var err error
+		grpcServer, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		restServer = utils.NewMockLedgerRESTServer(utils.MockLedger)
+	})
+
+	AfterEach(func() {
+		grpcServer.Stop()
+		restServer.Stop()
+	})
+
+	It("queries each namespace through its own API", func() {
+		grpcLedger := newTestLedgerService(newTestNetwork(1, grpcServer.Address))
+		defer grpcLedger.Close()
+		restLedger := newTestRESTLedgerService(newTestNetwork(1, restServer.Address))
+		defer restLedger.Close()
+
+		router := services.NewLedgerRouter()
+		router.Register(types.DID_METHOD, testconstants.ValidMainnetNamespace, restLedger)
+		router.Register(types.DID_METHOD, testconstants.ValidTestnetNamespace, grpcLedger)
+		Expect(router.GetNamespaces()).To(ConsistOf(testconstants.ValidMainnetNamespace, testconstants.ValidTestnetNamespace))
+
+		_, err := router.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(restServer.Calls()).To(Equal(1))
+		Expect(grpcServer.Calls()).To(Equal(0))
+
+		_, err = router.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.NotFoundHttpCode))
+		Expect(restServer.Calls()).To(Equal(1))
+		Expect(grpcServer.Calls()).To(Equal(1))
+	})
+
+	It("rejects DIDs of namespaces which are not registered", func() {
+		router := services.NewLedgerRouter()
+
+		_, err := router.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Message).To(Equal("invalidDid"))
+	})
+})
@@ -0,0 +1,216 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+
+	"github.com/cheqd/did-resolver/services"
+	statusServices "github.com/cheqd/did-resolver/services/status"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/labstack/echo/v4"
+)
+
+func newTestRetryPolicy(maxAttempts int) types.RetryPolicy {
+	return types.RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		RetryableCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+	}
+}
+
+var _ = Describe("Ledger retries", func() {
+	var server *utils.MockLedgerServer
+
+	BeforeEach(func() {
+		var err error
+		server, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	It("retries the query which failed with a retryable code", func() {
+		server.FailNext(2, codes.Unavailable, "node is overloaded")
+
+		network := newTestNetwork(1, server.Address)
+		network.Retry = newTestRetryPolicy(3)
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(server.Calls()).To(Equal(3))
+	})
+
+	It("gives up after the max attempts", func() {
+		server.FailWith(codes.ResourceExhausted, "rate limit exceeded")
+
+		network := newTestNetwork(1, server.Address)
+		network.Retry = newTestRetryPolicy(3)
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+		Expect(server.Calls()).To(Equal(3))
+	})
+
+	It("doesn't retry the codes which are not retryable", func() {
+		server.FailWith(codes.Internal, "node failed")
+
+		network := newTestNetwork(1, server.Address)
+		network.Retry = newTestRetryPolicy(3)
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.InternalErrorHttpCode))
+		Expect(server.Calls()).To(Equal(1))
+	})
+
+	It("doesn't retry when the deadline of the request leaves no time for the backoff", func() {
+		server.FailWith(codes.Unavailable, "node is overloaded")
+
+		network := newTestNetwork(1, server.Address)
+		network.Retry = newTestRetryPolicy(3)
+		network.Retry.InitialBackoff = time.Second
+		network.Retry.MaxBackoff = time.Second
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		_, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+		Expect(server.Calls()).To(Equal(1))
+	})
+})
+
+var _ = Describe("Ledger circuit breaker", func() {
+	var primary, secondary *utils.MockLedgerServer
+
+	BeforeEach(func() {
+		var err error
+		primary, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		secondary, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		primary.Stop()
+		secondary.Stop()
+	})
+
+	It("stops querying the endpoint after the consecutive failures", func() {
+		primary.FailWith(codes.Unavailable, "node is down")
+
+		network := newTestNetwork(1, primary.Address)
+		network.CircuitBreaker = types.CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: time.Minute}
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		for i := 0; i < 4; i++ {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).ToNot(BeNil())
+			Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+		}
+
+		Expect(primary.Calls()).To(Equal(2))
+	})
+
+	It("fails fast when the breakers of all the endpoints are open", func() {
+		primary.FailWith(codes.Unavailable, "node is down")
+		secondary.FailWith(codes.Unavailable, "node is down")
+
+		network := newTestNetwork(1, primary.Address, secondary.Address)
+		network.CircuitBreaker = types.CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute}
+		network.Retry = newTestRetryPolicy(3)
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+
+		_, err = ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.ServiceUnavailableHttpCode))
+		Expect(primary.Calls()).To(Equal(1))
+		Expect(secondary.Calls()).To(Equal(1))
+	})
+
+	It("closes the breaker when the trial query succeeds after the open duration", func() {
+		primary.FailNext(1, codes.Unavailable, "node is down")
+
+		network := newTestNetwork(1, primary.Address)
+		network.CircuitBreaker = types.CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: 100 * time.Millisecond}
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		for i := 0; i < 2; i++ {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).ToNot(BeNil())
+		}
+		Expect(primary.Calls()).To(Equal(1))
+
+		time.Sleep(150 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).To(BeNil())
+		}
+		Expect(primary.Calls()).To(Equal(4))
+	})
+
+	It("reports the state of the breakers on the status endpoint", func() {
+		primary.FailWith(codes.Unavailable, "node is down")
+
+		network := newTestNetwork(1, primary.Address, secondary.Address)
+		network.CircuitBreaker = types.CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute}
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		router := services.NewLedgerRouter()
+		router.Register(types.DID_METHOD, testconstants.ValidMainnetNamespace, ledgerService)
+		_, queryErr := router.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(queryErr).To(BeNil())
+
+		request := httptest.NewRequest(http.MethodGet, types.STATUS_PATH, nil)
+		rec := httptest.NewRecorder()
+		err := statusServices.StatusEchoHandler(router)(echo.New().NewContext(request, rec))
+		Expect(err).To(BeNil())
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var status types.ResolverStatus
+		Expect(json.Unmarshal(rec.Body.Bytes(), &status)).To(Succeed())
+		Expect(status.Networks).To(Equal([]types.NetworkStatus{
+			{
+				Method:    types.DID_METHOD,
+				Namespace: testconstants.ValidMainnetNamespace,
+				Api:       types.GRPCApi,
+				Endpoints: []types.EndpointStatus{
+					{Address: primary.Address, Healthy: false, CircuitBreaker: "open", ConsecutiveFailures: 1},
+					{Address: secondary.Address, Healthy: true, CircuitBreaker: "closed", ConsecutiveFailures: 0},
+				},
+			},
+		}))
+	})
+})
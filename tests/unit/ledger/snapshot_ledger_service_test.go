@@ -0,0 +1,187 @@
+//go:build unit
+
+package ledger
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func writeSnapshotFile(root string, name string, message proto.Message) {
+	data, err := protojson.Marshal(message)
+	Expect(err).To(BeNil())
+	Expect(os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(root, name), data, 0o644)).To(Succeed())
+}
+
+func writeSnapshotArchive(archivePath string, files map[string]proto.Message) {
+	file, err := os.Create(archivePath)
+	Expect(err).To(BeNil())
+	defer file.Close()
+	gzipWriter := gzip.NewWriter(file)
+	defer gzipWriter.Close()
+	archive := tar.NewWriter(gzipWriter)
+	defer archive.Close()
+
+	for name, message := range files {
+		data, err := proto.Marshal(message)
+		Expect(err).To(BeNil())
+		Expect(archive.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err = archive.Write(data)
+		Expect(err).To(BeNil())
+	}
+}
+
+func newTestSnapshotLedgerService(snapshotPath string) services.SnapshotLedgerService {
+	ledgerService := services.NewSnapshotLedgerService()
+	err := ledgerService.RegisterLedger(types.DID_METHOD, types.Network{
+		Namespace:    testconstants.ValidMainnetNamespace,
+		Api:          types.SnapshotApi,
+		SnapshotPath: snapshotPath,
+	})
+	Expect(err).To(BeNil())
+
+	return ledgerService
+}
+
+var validSnapshotDidDoc = &didTypes.DidDocWithMetadata{
+	DidDoc:   &testconstants.ValidDIDDoc,
+	Metadata: &testconstants.ValidMetadata,
+}
+
+var _ = Describe("Snapshot ledger service", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+		writeSnapshotFile(root, "diddocs/diddoc.json", validSnapshotDidDoc)
+		writeSnapshotFile(root, "resources/resource.json", &testconstants.ValidResource[0])
+	})
+
+	It("returns the same data as the ledger", func() {
+		ledgerService := newTestSnapshotLedgerService(root)
+		defer ledgerService.Close()
+		ctx := context.Background()
+
+		expectedDidDoc, _ := utils.MockLedger.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		didDoc, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(expectedDidDoc, didDoc)).To(BeTrue())
+
+		didDoc, err = ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, testconstants.ValidVersionId)
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(expectedDidDoc, didDoc)).To(BeTrue())
+
+		versions, err := ledgerService.QueryAllDidDocVersionsMetadata(ctx, testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(versions).To(HaveLen(1))
+		Expect(proto.Equal(&testconstants.ValidMetadata, versions[0])).To(BeTrue())
+
+		expectedResource, _ := utils.MockLedger.QueryResource(ctx, testconstants.ExistentDid, testconstants.ExistentResourceId)
+		resource, err := ledgerService.QueryResource(ctx, testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(expectedResource, resource)).To(BeTrue())
+
+		resources, err := ledgerService.QueryCollectionResources(ctx, testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(resources).To(HaveLen(1))
+		Expect(proto.Equal(testconstants.ValidResource[0].Metadata, resources[0])).To(BeTrue())
+	})
+
+	It("reads protobuf files from a tar.gz archive", func() {
+		archivePath := filepath.Join(GinkgoT().TempDir(), "snapshot.tar.gz")
+		writeSnapshotArchive(archivePath, map[string]proto.Message{
+			"diddocs/diddoc.pb":     validSnapshotDidDoc,
+			"resources/resource.pb": &testconstants.ValidResource[0],
+		})
+
+		ledgerService := newTestSnapshotLedgerService(archivePath)
+		defer ledgerService.Close()
+
+		didDoc, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(validSnapshotDidDoc, didDoc)).To(BeTrue())
+
+		resource, err := ledgerService.QueryResource(context.Background(), testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(&testconstants.ValidResource[0], resource)).To(BeTrue())
+	})
+
+	It("returns notFound for data which is not in the snapshot", func() {
+		ledgerService := newTestSnapshotLedgerService(root)
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.NotExistentMainnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.NotFoundHttpCode))
+
+		_, err = ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, testconstants.InvalidVersionId)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.NotFoundHttpCode))
+
+		_, err = ledgerService.QueryResource(context.Background(), testconstants.ExistentDid, testconstants.NotExistentIdentifier)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.NotFoundHttpCode))
+	})
+
+	It("returns blockHeightNotAvailable for queries at a block height", func() {
+		ledgerService := newTestSnapshotLedgerService(root)
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(services.WithBlockHeight(context.Background(), 42), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.BlockHeightNotAvailableHttpCode))
+	})
+
+	It("rejects a snapshot with DIDs of another namespace", func() {
+		ledgerService := services.NewSnapshotLedgerService()
+		err := ledgerService.RegisterLedger(types.DID_METHOD, types.Network{
+			Namespace:    testconstants.ValidTestnetNamespace,
+			Api:          types.SnapshotApi,
+			SnapshotPath: root,
+		})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("reloads the snapshot when the files change", func() {
+		ledgerService := newTestSnapshotLedgerService(root)
+		defer ledgerService.Close()
+
+		newResource := proto.Clone(&testconstants.ValidResource[0]).(*resourceTypes.ResourceWithMetadata)
+		newResource.Metadata.Id = testconstants.NotExistentIdentifier
+		writeSnapshotFile(root, "resources/new/resource.json", newResource)
+
+		Eventually(func() int {
+			resources, _ := ledgerService.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+			return len(resources)
+		}, 5*time.Second, 50*time.Millisecond).Should(Equal(2))
+	})
+
+	It("keeps the previous snapshot when the files become invalid", func() {
+		ledgerService := newTestSnapshotLedgerService(root)
+		defer ledgerService.Close()
+
+		Expect(os.WriteFile(filepath.Join(root, "diddocs", "broken.json"), []byte("{"), 0o644)).To(Succeed())
+
+		Consistently(func() *types.IdentityError {
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			return err
+		}, time.Second, 100*time.Millisecond).Should(BeNil())
+	})
+})
@@ -0,0 +1,143 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func newTestTLSNetwork(address string, tlsConfig types.TLSConfig) types.Network {
+	network := newTestNetwork(1, address)
+	network.Endpoints[0].UseTls = true
+	network.TLS = tlsConfig
+
+	return network
+}
+
+var _ = Describe("Ledger TLS", func() {
+	var certificates *utils.TestCertificates
+	var mutualTLS types.TLSConfig
+
+	BeforeEach(func() {
+		var err error
+		certificates, err = utils.NewTestCertificates(GinkgoT().TempDir())
+		Expect(err).To(BeNil())
+
+		mutualTLS = types.TLSConfig{
+			CAFile:     certificates.CAFile,
+			CertFile:   certificates.ClientCertFile,
+			KeyFile:    certificates.ClientKeyFile,
+			ServerName: utils.TestServerName,
+			MinVersion: "1.3",
+		}
+	})
+
+	Context("gRPC API", func() {
+		var server *utils.MockLedgerServer
+
+		BeforeEach(func() {
+			serverTLS, err := certificates.ServerTLSConfig()
+			Expect(err).To(BeNil())
+			server, err = utils.NewMockLedgerServer(utils.MockLedger, grpc.Creds(credentials.NewTLS(serverTLS)))
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			server.Stop()
+		})
+
+		It("connects with the CA, client certificate and server name of the network", func() {
+			ledgerService := newTestLedgerService(newTestTLSNetwork(server.Address, mutualTLS))
+			defer ledgerService.Close()
+
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).To(BeNil())
+			Expect(server.Calls()).To(Equal(1))
+		})
+
+		It("fails without the client certificate", func() {
+			mutualTLS.CertFile, mutualTLS.KeyFile = "", ""
+			ledgerService := newTestLedgerService(newTestTLSNetwork(server.Address, mutualTLS))
+			defer ledgerService.Close()
+
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).ToNot(BeNil())
+			Expect(server.Calls()).To(Equal(0))
+		})
+
+		It("fails without the server name override", func() {
+			mutualTLS.ServerName = ""
+			ledgerService := newTestLedgerService(newTestTLSNetwork(server.Address, mutualTLS))
+			defer ledgerService.Close()
+
+			_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(err).ToNot(BeNil())
+			Expect(server.Calls()).To(Equal(0))
+		})
+	})
+
+	Context("REST API", func() {
+		It("connects with the CA, client certificate and server name of the network", func() {
+			serverTLS, err := certificates.ServerTLSConfig()
+			Expect(err).To(BeNil())
+			server := utils.NewMockLedgerRESTServerTLS(utils.MockLedger, serverTLS)
+			defer server.Stop()
+
+			ledgerService := newTestRESTLedgerService(newTestTLSNetwork(server.Address, mutualTLS))
+			defer ledgerService.Close()
+
+			_, queryErr := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+			Expect(queryErr).To(BeNil())
+			Expect(server.Calls()).To(Equal(1))
+		})
+	})
+
+	DescribeTable("rejects TLS options which can't be used", func(updateConfig func(config *types.TLSConfig)) {
+		updateConfig(&mutualTLS)
+		network := newTestTLSNetwork("127.0.0.1:9090", mutualTLS)
+		network.Namespace = testconstants.ValidMainnetNamespace
+
+		grpcLedgerService := services.NewLedgerService()
+		Expect(grpcLedgerService.RegisterLedger(types.DID_METHOD, network)).ToNot(Succeed())
+
+		restLedgerService := services.NewRESTLedgerService()
+		Expect(restLedgerService.RegisterLedger(types.DID_METHOD, network)).ToNot(Succeed())
+	},
+
+		Entry(
+			"CA file doesn't exist",
+			func(config *types.TLSConfig) { config.CAFile = filepath.Join(GinkgoT().TempDir(), "missing.pem") },
+		),
+
+		Entry(
+			"CA file has no certificates",
+			func(config *types.TLSConfig) { config.CAFile = certificates.ClientKeyFile },
+		),
+
+		Entry(
+			"client key is not set",
+			func(config *types.TLSConfig) { config.KeyFile = "" },
+		),
+
+		Entry(
+			"client key doesn't match the certificate",
+			func(config *types.TLSConfig) { config.KeyFile = certificates.ServerKeyFile },
+		),
+
+		Entry(
+			"TLS version is unknown",
+			func(config *types.TLSConfig) { config.MinVersion = "2.0" },
+		),
+	)
+})
@@ -0,0 +1,204 @@
+//go:build unit
+
+package ledger
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+func newVerifiedTestNetwork(chain *utils.MockChain, trustedHeight int64, addresses ...string) types.Network {
+	network := newTestNetwork(1, addresses...)
+	network.Verification = &types.VerificationConfig{
+		ChainId:        utils.MockChainId,
+		TrustedHeight:  trustedHeight,
+		TrustedHash:    chain.HeaderHash(trustedHeight),
+		TrustingPeriod: time.Hour,
+	}
+
+	return network
+}
+
+var _ = Describe("Verified ledger queries", func() {
+	var primary, secondary *utils.MockLedgerServer
+	var chain *utils.MockChain
+
+	BeforeEach(func() {
+		var err error
+		primary, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		secondary, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+
+		chain = utils.NewMockChain(utils.MockLedger, 10)
+		primary.SetChain(chain)
+		secondary.SetChain(chain)
+	})
+
+	AfterEach(func() {
+		primary.Stop()
+		secondary.Stop()
+	})
+
+	It("resolves the DIDDoc proven against a verified header and marks it as proven", func() {
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address))
+		defer ledgerService.Close()
+
+		expectedDidDoc, _ := utils.MockLedger.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		didDoc, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+		Expect(proto.Equal(didDoc, expectedDidDoc)).To(BeTrue())
+
+		resolution, err := services.NewDIDDocService(types.DID_METHOD, ledgerService).Resolve(context.Background(), testconstants.ExistentDid, "", types.DIDJSONLD)
+		Expect(err).To(BeNil())
+		Expect(resolution.ResolutionMetadata.Proven).To(BeTrue())
+	})
+
+	It("proves that the DIDDoc doesn't exist", func() {
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.NotExistentTestnetDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.NotFoundHttpCode))
+	})
+
+	It("dereferences the resources with proven data", func() {
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address))
+		defer ledgerService.Close()
+
+		resource, err := ledgerService.QueryResource(context.Background(), testconstants.ExistentDid, testconstants.ExistentResourceId)
+		Expect(err).To(BeNil())
+		Expect(resource.Resource.Data).To(Equal(testconstants.ValidResource[0].Resource.Data))
+		Expect(proto.Equal(resource.Metadata, testconstants.ValidResource[0].Metadata)).To(BeTrue())
+
+		resources, err := ledgerService.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(resources).To(HaveLen(1))
+		Expect(proto.Equal(resources[0], testconstants.ValidResource[0].Metadata)).To(BeTrue())
+
+		versions, err := ledgerService.QueryAllDidDocVersionsMetadata(context.Background(), testconstants.ExistentDid)
+		Expect(err).To(BeNil())
+		Expect(versions).To(HaveLen(1))
+		Expect(versions[0].VersionId).To(Equal(testconstants.ValidVersionId))
+	})
+
+	It("verifies the DIDDoc and the resources of a resolution against the same trusted root", func() {
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address))
+		defer ledgerService.Close()
+
+		resolution, err := services.NewDIDDocService(types.DID_METHOD, ledgerService).Resolve(context.Background(), testconstants.ExistentDid, "", types.DIDJSONLD)
+		Expect(err).To(BeNil())
+		Expect(resolution.Metadata.Resources).To(HaveLen(1))
+		Expect(primary.LatestBlockCalls()).To(Equal(1))
+		// The requested block height is reported only
+		Expect(resolution.ResolutionMetadata.BlockHeight).To(BeZero())
+	})
+
+	It("rejects the listing of resources which leaves out a linked version", func() {
+		latest := proto.Clone(testconstants.ValidResource[0].Metadata).(*resourceTypes.Metadata)
+		latest.Id = testconstants.ValidNextVersionId
+		latest.PreviousVersionId = testconstants.ValidResource[0].Metadata.Id
+		first := proto.Clone(testconstants.ValidResource[0].Metadata).(*resourceTypes.Metadata)
+		first.NextVersionId = latest.Id
+
+		versioned := utils.NewMockLedgerService(&testconstants.ValidDIDDoc, []*didTypes.Metadata{&testconstants.ValidMetadata}, []resourceTypes.ResourceWithMetadata{
+			{Resource: testconstants.ValidResource[0].Resource, Metadata: first},
+			{Resource: testconstants.ValidResource[0].Resource, Metadata: latest},
+		})
+		// The node lists the first version only, while the ledger state has both
+		hiding, err := utils.NewMockLedgerServer(utils.NewMockLedgerService(&testconstants.ValidDIDDoc, []*didTypes.Metadata{&testconstants.ValidMetadata}, []resourceTypes.ResourceWithMetadata{
+			{Resource: testconstants.ValidResource[0].Resource, Metadata: first},
+		}))
+		Expect(err).To(BeNil())
+		defer hiding.Stop()
+		versionedChain := utils.NewMockChain(versioned, 10)
+		hiding.SetChain(versionedChain)
+
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(versionedChain, 1, hiding.Address))
+		defer ledgerService.Close()
+
+		_, queryErr := ledgerService.QueryCollectionResources(context.Background(), testconstants.ExistentDid)
+		Expect(queryErr).ToNot(BeNil())
+		Expect(queryErr.Internal.Error()).To(ContainSubstring("missing from the listing"))
+	})
+
+	It("fails over to the next endpoint when the data doesn't match its proof", func() {
+		primary.SetForging(true)
+
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address, secondary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+
+		secondary.SetForging(true)
+		_, err = ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.InternalErrorHttpCode))
+	})
+
+	It("rejects the header which is not signed by its validators", func() {
+		// Latest state is proven against header 9, whose commit is in block 10
+		chain.ForgeCommit(9)
+
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.InternalErrorHttpCode))
+	})
+
+	It("rejects the chain which doesn't match the trusted hash", func() {
+		network := newVerifiedTestNetwork(chain, 1, primary.Address)
+		network.Verification.TrustedHash = chain.HeaderHash(2)
+		ledgerService := newTestLedgerService(network)
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.InternalErrorHttpCode))
+	})
+
+	It("verifies the headers signed after the validator set changed", func() {
+		chain = utils.NewMockChain(utils.MockLedger, 10, 4, 7)
+		primary.SetChain(chain)
+
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address))
+		defer ledgerService.Close()
+
+		_, err := ledgerService.QueryDIDDoc(context.Background(), testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+	})
+
+	It("verifies the state at a block height older than the trusted header", func() {
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 8, primary.Address))
+		defer ledgerService.Close()
+
+		ctx := services.WithBlockHeight(context.Background(), 3)
+		_, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).To(BeNil())
+	})
+
+	It("doesn't verify the state at a block height which is not signed yet", func() {
+		ledgerService := newTestLedgerService(newVerifiedTestNetwork(chain, 1, primary.Address))
+		defer ledgerService.Close()
+
+		ctx := services.WithBlockHeight(context.Background(), 9)
+		_, err := ledgerService.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		Expect(err).ToNot(BeNil())
+		Expect(err.Code).To(Equal(types.BlockHeightNotAvailableHttpCode))
+	})
+})
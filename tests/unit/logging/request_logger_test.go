@@ -0,0 +1,202 @@
+//go:build unit
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/cheqd/did-resolver/services"
+	batchServices "github.com/cheqd/did-resolver/services/batch"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+// logBuffer collects the lines of the loggers writing at the same time, e.g. of the batch items
+type logBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.String()
+}
+
+var _ = Describe("Request logging", func() {
+	var (
+		e       *echo.Echo
+		server  *utils.MockLedgerServer
+		ledger  services.LedgerService
+		output  *logBuffer
+		restore zerolog.Logger
+	)
+
+	BeforeEach(func() {
+		output = &logBuffer{}
+		restore = log.Logger
+		log.Logger = zerolog.New(output)
+		zerolog.DefaultContextLogger = &log.Logger
+
+		var err error
+		server, err = utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		ledger = services.NewLedgerService()
+		Expect(ledger.RegisterLedger(types.DID_METHOD, types.Network{
+			Namespace:        testconstants.ValidMainnetNamespace,
+			Endpoints:        []types.Endpoint{{Address: server.Address, Timeout: 5 * time.Second}},
+			PoolSize:         1,
+			EndpointStrategy: types.FailoverStrategy,
+		})).To(Succeed())
+
+		e = utils.SetupEcho(ledger)
+		e.Use(services.RequestIDMiddleware())
+		e.Use(services.AccessLogMiddleware())
+		batchServices.SetRoutes(e, types.BatchConfig{MaxItems: 5, Concurrency: 2, MaxBodySize: 1024})
+	})
+
+	AfterEach(func() {
+		log.Logger = restore
+		zerolog.DefaultContextLogger = nil
+		Expect(ledger.Close()).To(Succeed())
+		server.Stop()
+	})
+
+	serve := func(request *http.Request) *httptest.ResponseRecorder {
+		request.Header.Set(echo.HeaderAccept, string(types.DIDJSONLD))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request)
+
+		return rec
+	}
+
+	get := func(path string, requestID string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		if requestID != "" {
+			request.Header.Set(echo.HeaderXRequestID, requestID)
+		}
+		return serve(request)
+	}
+
+	// logLines returns the lines logged with the request ID
+	logLines := func(requestID string) []map[string]interface{} {
+		var lines []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			var fields map[string]interface{}
+			Expect(json.Unmarshal([]byte(line), &fields)).To(Succeed(), line)
+			if fields["request_id"] == requestID {
+				lines = append(lines, fields)
+			}
+		}
+		return lines
+	}
+
+	It("returns the request ID of the client", func() {
+		rec := get(types.RESOLVER_PATH+testconstants.ExistentDid, "client-id")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get(echo.HeaderXRequestID)).To(Equal("client-id"))
+	})
+
+	It("generates a request ID for every request without one", func() {
+		first := get(types.RESOLVER_PATH+testconstants.ExistentDid, "").Header().Get(echo.HeaderXRequestID)
+		second := get(types.RESOLVER_PATH+testconstants.ExistentDid, "").Header().Get(echo.HeaderXRequestID)
+		Expect(first).ToNot(BeEmpty())
+		Expect(second).ToNot(BeEmpty())
+		Expect(first).ToNot(Equal(second))
+	})
+
+	It("logs every request with its ID", func() {
+		get(types.RESOLVER_PATH+testconstants.ExistentDid, "access-id")
+
+		lines := logLines("access-id")
+		Expect(lines).ToNot(BeEmpty())
+		access := lines[len(lines)-1]
+		Expect(access).To(HaveKeyWithValue("message", "request"))
+		Expect(access).To(HaveKeyWithValue("level", "info"))
+		Expect(access).To(HaveKeyWithValue("method", http.MethodGet))
+		Expect(access).To(HaveKeyWithValue("uri", types.RESOLVER_PATH+testconstants.ExistentDid))
+		Expect(access).To(HaveKeyWithValue("route", types.RESOLVER_PATH+":did"))
+		Expect(access).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusOK)))
+		Expect(access).To(HaveKey("latency"))
+		Expect(access).To(HaveKey("bytes_out"))
+	})
+
+	It("logs the ledger queries and the error of the request with its ID", func() {
+		rec := get(types.RESOLVER_PATH+testconstants.NotExistentMainnetDid, "error-id")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+
+		var messages []interface{}
+		for _, line := range logLines("error-id") {
+			messages = append(messages, line["message"])
+		}
+		// The query sent to the node, its result, the cause of the error and the request
+		Expect(messages).To(ContainElement(ContainSubstring("via " + server.Address)))
+		Expect(messages).To(ContainElement(ContainSubstring("not found")))
+		Expect(messages).To(ContainElement("notFound"))
+		Expect(messages[len(messages)-1]).To(Equal("request"))
+
+		var cause map[string]interface{}
+		for _, line := range logLines("error-id") {
+			if line["message"] == "notFound" {
+				cause = line
+			}
+		}
+		Expect(cause).To(HaveKeyWithValue("level", "warn"))
+		Expect(cause).To(HaveKeyWithValue("error", ContainSubstring("NotFound")))
+	})
+
+	It("answers and logs an error once", func() {
+		rec := get(types.RESOLVER_PATH+testconstants.NotExistentMainnetDid, "once-id")
+
+		// A second answer would be appended to the body
+		var identityError map[string]interface{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &identityError)).To(Succeed())
+
+		count := 0
+		for _, line := range logLines("once-id") {
+			if line["message"] == "notFound" {
+				count++
+			}
+		}
+		Expect(count).To(Equal(1))
+	})
+
+	It("logs the items of a batch request with the ID of the batch", func() {
+		request := httptest.NewRequest(http.MethodPost, types.BATCH_PATH, strings.NewReader(`["`+testconstants.ExistentDid+`"]`))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := serve(request)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		requestID := rec.Header().Get(echo.HeaderXRequestID)
+		batchItems := map[interface{}]interface{}{}
+		for _, line := range logLines(requestID) {
+			if line["message"] == "request" {
+				batchItems[line["route"]] = line["batch_item"]
+			}
+		}
+		// The items are marked, so they aren't mistaken for requests of their own
+		Expect(batchItems).To(Equal(map[interface{}]interface{}{
+			types.RESOLVER_PATH + ":did": true,
+			types.BATCH_PATH:             nil,
+		}))
+	})
+})
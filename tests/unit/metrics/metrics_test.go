@@ -0,0 +1,202 @@
+//go:build unit
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/cheqd/did-resolver/services"
+	batchServices "github.com/cheqd/did-resolver/services/batch"
+	metricsServices "github.com/cheqd/did-resolver/services/metrics"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+	resolverUtils "github.com/cheqd/did-resolver/utils"
+)
+
+const resourceDataRoute = types.RESOLVER_PATH + ":did" + types.RESOURCE_PATH + ":resource"
+
+// The metrics are global, so the specs compare them before and after the requests
+var _ = Describe("Metrics", func() {
+	var e *echo.Echo
+
+	BeforeEach(func() {
+		router := services.NewLedgerRouter()
+		router.Register(types.DID_METHOD, testconstants.ValidMainnetNamespace, utils.MockLedger)
+
+		e = utils.SetupEcho(router)
+		e.HTTPErrorHandler = services.CustomHTTPErrorHandler
+		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{Skipper: resolverUtils.GzipSkipper}))
+		e.Use(services.MetricsMiddleware)
+		metricsServices.SetRoutes(e)
+		batchServices.SetRoutes(e, types.BatchConfig{MaxItems: 5, Concurrency: 2, MaxBodySize: 1024})
+	})
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set(echo.HeaderAccept, string(types.DIDJSONLD))
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request)
+
+		return rec
+	}
+
+	// value returns the counter value or the histogram sample count of the series with the labels
+	value := func(name string, labels map[string]string) float64 {
+		rec := get(types.METRICS_PATH, nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(strings.NewReader(rec.Body.String()))
+		Expect(err).To(BeNil())
+
+		family, ok := families[name]
+		if !ok {
+			return 0
+		}
+		for _, metric := range family.GetMetric() {
+			if hasLabels(metric, labels) {
+				if metric.GetHistogram() != nil {
+					return float64(metric.GetHistogram().GetSampleCount())
+				}
+				return metric.GetCounter().GetValue()
+			}
+		}
+		return 0
+	}
+
+	It("counts requests by route pattern and outcome", func() {
+		route := types.RESOLVER_PATH + ":did"
+		success := map[string]string{"route": route, "code": "200", "outcome": "success"}
+		notFound := map[string]string{"route": route, "code": "404", "outcome": "notFound"}
+		successBefore := value("did_resolver_http_requests_total", success)
+		notFoundBefore := value("did_resolver_http_requests_total", notFound)
+		durationBefore := value("did_resolver_http_request_duration_seconds", map[string]string{"route": route, "outcome": "success"})
+
+		Expect(get(types.RESOLVER_PATH+testconstants.ExistentDid, nil).Code).To(Equal(http.StatusOK))
+		Expect(get(types.RESOLVER_PATH+testconstants.NotExistentMainnetDid, nil).Code).To(Equal(http.StatusNotFound))
+
+		Expect(value("did_resolver_http_requests_total", success)).To(Equal(successBefore + 1))
+		Expect(value("did_resolver_http_requests_total", notFound)).To(Equal(notFoundBefore + 1))
+		Expect(value("did_resolver_http_request_duration_seconds", map[string]string{"route": route, "outcome": "success"})).To(Equal(durationBefore + 1))
+	})
+
+	It("labels invalid DIDs with the error name", func() {
+		labels := map[string]string{"route": types.RESOLVER_PATH + ":did", "code": "400", "outcome": "invalidDid"}
+		before := value("did_resolver_http_requests_total", labels)
+
+		Expect(get(types.RESOLVER_PATH+"did:cheqd:mainnet:invalid", nil).Code).To(Equal(http.StatusBadRequest))
+		Expect(value("did_resolver_http_requests_total", labels)).To(Equal(before + 1))
+	})
+
+	It("never labels the metrics with DIDs", func() {
+		get(types.RESOLVER_PATH+testconstants.ExistentDid, nil)
+		get(types.RESOLVER_PATH+testconstants.NotExistentMainnetDid, nil)
+
+		body := get(types.METRICS_PATH, nil).Body.String()
+		Expect(body).ToNot(ContainSubstring(testconstants.ValidIdentifier))
+		Expect(body).ToNot(ContainSubstring(testconstants.NotExistentIdentifier))
+	})
+
+	It("counts ledger queries by namespace and outcome", func() {
+		success := map[string]string{"namespace": testconstants.ValidMainnetNamespace, "query": "QueryDIDDoc", "outcome": "success"}
+		notFound := map[string]string{"namespace": testconstants.ValidMainnetNamespace, "query": "QueryDIDDoc", "outcome": "notFound"}
+		successBefore := value("did_resolver_ledger_queries_total", success)
+		notFoundBefore := value("did_resolver_ledger_queries_total", notFound)
+		durationBefore := value("did_resolver_ledger_query_duration_seconds", map[string]string{"namespace": testconstants.ValidMainnetNamespace, "query": "QueryDIDDoc"})
+
+		get(types.RESOLVER_PATH+testconstants.ExistentDid, nil)
+		get(types.RESOLVER_PATH+testconstants.NotExistentMainnetDid, nil)
+
+		Expect(value("did_resolver_ledger_queries_total", success)).To(BeNumerically(">", successBefore))
+		Expect(value("did_resolver_ledger_queries_total", notFound)).To(Equal(notFoundBefore + 1))
+		Expect(value("did_resolver_ledger_query_duration_seconds", map[string]string{"namespace": testconstants.ValidMainnetNamespace, "query": "QueryDIDDoc"})).To(BeNumerically(">=", durationBefore+2))
+	})
+
+	It("doesn't count queries of namespaces which aren't configured", func() {
+		before := value("did_resolver_ledger_queries_total", map[string]string{"namespace": testconstants.ValidTestnetNamespace})
+
+		get(types.RESOLVER_PATH+testconstants.NotExistentTestnetDid, nil)
+		Expect(value("did_resolver_ledger_queries_total", map[string]string{"namespace": testconstants.ValidTestnetNamespace})).To(Equal(before))
+	})
+
+	It("counts the resource bytes served", func() {
+		labels := map[string]string{"namespace": testconstants.ValidMainnetNamespace}
+		before := value("did_resolver_resource_bytes_served_total", labels)
+		path := types.RESOLVER_PATH + testconstants.ExistentDid + types.RESOURCE_PATH + testconstants.ExistentResourceId
+
+		Expect(get(path, nil).Code).To(Equal(http.StatusOK))
+		Expect(get(path, map[string]string{"Range": "bytes=0-3"}).Code).To(Equal(http.StatusPartialContent))
+
+		data := testconstants.ValidResource[0].Resource.Data
+		Expect(value("did_resolver_resource_bytes_served_total", labels)).To(Equal(before + float64(len(data)+4)))
+		Expect(value("did_resolver_http_requests_total", map[string]string{"route": resourceDataRoute, "code": "206"})).To(BeNumerically(">=", 1))
+	})
+
+	It("counts compressed responses", func() {
+		gzip := map[string]string{"encoding": "gzip"}
+		identity := map[string]string{"encoding": "identity"}
+		gzipBefore := value("did_resolver_http_response_encodings_total", gzip)
+		identityBefore := value("did_resolver_http_response_encodings_total", identity)
+
+		rec := get(types.RESOLVER_PATH+testconstants.ExistentDid, map[string]string{echo.HeaderAcceptEncoding: "gzip"})
+		Expect(rec.Header().Get(echo.HeaderContentEncoding)).To(Equal("gzip"))
+		Expect(value("did_resolver_http_response_encodings_total", gzip)).To(Equal(gzipBefore + 1))
+
+		get(types.RESOLVER_PATH+testconstants.ExistentDid, nil)
+		// The scrapes of the metrics aren't compressed either
+		Expect(value("did_resolver_http_response_encodings_total", identity)).To(BeNumerically(">", identityBefore))
+	})
+
+	It("counts the items of batch requests apart from the requests", func() {
+		didRoute := types.RESOLVER_PATH + ":did"
+		requestsBefore := value("did_resolver_http_requests_total", map[string]string{"route": didRoute})
+		itemsBefore := value("did_resolver_batch_items_total", map[string]string{"route": didRoute, "code": "200", "outcome": metricsServices.Success})
+		batchesBefore := value("did_resolver_http_requests_total", map[string]string{"route": types.BATCH_PATH, "code": "200"})
+
+		request := httptest.NewRequest(http.MethodPost, types.BATCH_PATH, strings.NewReader(`["`+testconstants.ExistentDid+`", "`+testconstants.ExistentDid+`"]`))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		Expect(value("did_resolver_batch_items_total", map[string]string{"route": didRoute, "code": "200", "outcome": metricsServices.Success})).To(Equal(itemsBefore + 2))
+		Expect(value("did_resolver_http_requests_total", map[string]string{"route": didRoute})).To(Equal(requestsBefore))
+		Expect(value("did_resolver_http_requests_total", map[string]string{"route": types.BATCH_PATH, "code": "200"})).To(Equal(batchesBefore + 1))
+	})
+
+	It("labels requests which matched no route", func() {
+		labels := map[string]string{"route": "unmatched", "code": "400", "outcome": "invalidDidUrl"}
+		before := value("did_resolver_http_requests_total", labels)
+
+		Expect(get("/unknown/path", nil).Code).To(Equal(http.StatusBadRequest))
+		Expect(value("did_resolver_http_requests_total", labels)).To(Equal(before + 1))
+		Expect(get(types.METRICS_PATH, nil).Body.String()).ToNot(ContainSubstring("/unknown/path"))
+	})
+})
+
+// hasLabels reports whether the metric has all of the labels, it may have others
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, pair := range metric.GetLabel() {
+		if value, ok := labels[pair.GetName()]; ok {
+			if value != pair.GetValue() {
+				return false
+			}
+			matched++
+		}
+	}
+	return matched == len(labels)
+}
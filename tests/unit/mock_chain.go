@@ -0,0 +1,462 @@
+//go:build unit
+
+package unit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	tmservice "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	ed25519types "github.com/cosmos/cosmos-sdk/api/cosmos/crypto/ed25519"
+	tmcrypto "github.com/cosmos/cosmos-sdk/api/tendermint/crypto"
+	tmtypes "github.com/cosmos/cosmos-sdk/api/tendermint/types"
+	tmversion "github.com/cosmos/cosmos-sdk/api/tendermint/version"
+	ics23 "github.com/cosmos/ics23/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const MockChainId = "cheqd-mock-1"
+
+// MockChain is a chain of blocks signed by ed25519 validators whose app hash commits to the state
+// of a MockLedgerService, laid out in the module stores the way cheqd-node does it.
+// The state is the same at every height.
+type MockChain struct {
+	blocks     []*tmtypes.Block
+	validators [][]mockValidator
+	stores     map[string]*mockStore
+	appHash    []byte
+}
+
+type mockValidator struct {
+	privKey ed25519.PrivateKey
+	power   int64
+}
+
+func (v mockValidator) pubKey() ed25519.PublicKey {
+	return v.privKey.Public().(ed25519.PublicKey)
+}
+
+func (v mockValidator) address() []byte {
+	return sha256Sum(v.pubKey())[:20]
+}
+
+// NewMockChain produces the blocks up to the height. The whole validator set is replaced
+// with a new one at each of the validator changes heights.
+func NewMockChain(ledger MockLedgerService, height int64, validatorChanges ...int64) *MockChain {
+	chain := &MockChain{stores: newMockStores(ledger)}
+	chain.appHash = chain.multistoreRoot()
+
+	// Validators of the height after the last block are needed for its next validators hash
+	validators := newMockValidators(4)
+	for h := int64(1); h <= height+1; h++ {
+		for _, change := range validatorChanges {
+			if change == h {
+				validators = newMockValidators(4)
+			}
+		}
+		chain.validators = append(chain.validators, validators)
+	}
+
+	start := time.Now().Add(-time.Duration(height+1) * time.Second)
+	lastBlockId := &tmtypes.BlockID{PartSetHeader: &tmtypes.PartSetHeader{}}
+	var lastCommit *tmtypes.Commit
+	for h := int64(1); h <= height; h++ {
+		header := &tmtypes.Header{
+			Version:            &tmversion.Consensus{Block: 11},
+			ChainId:            MockChainId,
+			Height:             h,
+			Time:               timestamppb.New(start.Add(time.Duration(h) * time.Second)),
+			LastBlockId:        lastBlockId,
+			ValidatorsHash:     validatorSetHash(chain.validatorsOf(h)),
+			NextValidatorsHash: validatorSetHash(chain.validatorsOf(h + 1)),
+			ConsensusHash:      sha256Sum([]byte("consensus")),
+			AppHash:            chain.appHash,
+			ProposerAddress:    chain.validatorsOf(h)[0].address(),
+		}
+		chain.blocks = append(chain.blocks, &tmtypes.Block{Header: header, LastCommit: lastCommit})
+
+		lastBlockId = &tmtypes.BlockID{
+			Hash:          headerHash(header),
+			PartSetHeader: &tmtypes.PartSetHeader{Total: 1, Hash: sha256Sum([]byte(fmt.Sprint(h)))},
+		}
+		lastCommit = chain.sign(h, lastBlockId, header.Time.AsTime())
+	}
+
+	return chain
+}
+
+// Height returns the height of the latest block
+func (c *MockChain) Height() int64 {
+	return int64(len(c.blocks))
+}
+
+// HeaderHash returns the hash of the header at the height, to trust it out of band
+func (c *MockChain) HeaderHash(height int64) []byte {
+	return headerHash(c.blocks[height-1].Header)
+}
+
+// ForgeCommit replaces the signatures of the block with the ones of validators not in the set
+func (c *MockChain) ForgeCommit(height int64) {
+	commit := c.blocks[height].LastCommit
+	forged := newMockValidators(len(commit.Signatures))
+	for i := range commit.Signatures {
+		commit.Signatures[i].Signature = ed25519.Sign(forged[i].privKey, voteSignBytes(MockChainId, commit, i))
+	}
+}
+
+func (c *MockChain) block(height int64) (*tmtypes.Block, bool) {
+	if height < 1 || height > c.Height() {
+		return nil, false
+	}
+	return c.blocks[height-1], true
+}
+
+// validatorsOf returns the validators of the height in the order of the set
+func (c *MockChain) validatorsOf(height int64) []mockValidator {
+	validators := append([]mockValidator{}, c.validators[height-1]...)
+	sort.SliceStable(validators, func(i, j int) bool {
+		if validators[i].power != validators[j].power {
+			return validators[i].power > validators[j].power
+		}
+		return bytes.Compare(validators[i].address(), validators[j].address()) < 0
+	})
+	return validators
+}
+
+func (c *MockChain) validatorSet(height int64) []*tmservice.Validator {
+	var validators []*tmservice.Validator
+	for _, validator := range c.validatorsOf(height) {
+		pubKey, _ := anypb.New(&ed25519types.PubKey{Key: validator.pubKey()})
+		pubKey.TypeUrl = "/cosmos.crypto.ed25519.PubKey"
+		validators = append(validators, &tmservice.Validator{
+			Address:     fmt.Sprintf("cheqdvalcons%x", validator.address()),
+			PubKey:      pubKey,
+			VotingPower: validator.power,
+		})
+	}
+	return validators
+}
+
+// sign makes all the validators of the height sign the block
+func (c *MockChain) sign(height int64, blockId *tmtypes.BlockID, blockTime time.Time) *tmtypes.Commit {
+	commit := &tmtypes.Commit{Height: height, BlockId: blockId}
+	for i, validator := range c.validatorsOf(height) {
+		commit.Signatures = append(commit.Signatures, &tmtypes.CommitSig{
+			BlockIdFlag:      tmtypes.BlockIDFlag_BLOCK_ID_FLAG_COMMIT,
+			ValidatorAddress: validator.address(),
+			Timestamp:        timestamppb.New(blockTime.Add(time.Duration(i) * time.Millisecond)),
+		})
+		commit.Signatures[i].Signature = ed25519.Sign(validator.privKey, voteSignBytes(MockChainId, commit, i))
+	}
+	return commit
+}
+
+// The hashes and sign bytes of the chain are encoded with the protobuf messages CometBFT encodes, rather than
+// with the encoders of the light client, which are checked against the answers of CometBFT in NewKnownAnswerChain
+
+// headerHash is the Merkle root of the fields of the header, each encoded on its own
+func headerHash(header *tmtypes.Header) []byte {
+	fields := []proto.Message{
+		header.Version,
+		wrapperspb.String(header.ChainId),
+		wrapperspb.Int64(header.Height),
+		header.Time,
+		header.LastBlockId,
+		wrapperspb.Bytes(header.LastCommitHash),
+		wrapperspb.Bytes(header.DataHash),
+		wrapperspb.Bytes(header.ValidatorsHash),
+		wrapperspb.Bytes(header.NextValidatorsHash),
+		wrapperspb.Bytes(header.ConsensusHash),
+		wrapperspb.Bytes(header.AppHash),
+		wrapperspb.Bytes(header.LastResultsHash),
+		wrapperspb.Bytes(header.EvidenceHash),
+		wrapperspb.Bytes(header.ProposerAddress),
+	}
+	leaves := make([][]byte, 0, len(fields))
+	for _, field := range fields {
+		leaves = append(leaves, merkleLeaf(field))
+	}
+	root, _ := simpleMerkle(leaves, -1)
+	return root
+}
+
+// validatorSetHash is the Merkle root of the validators encoded as SimpleValidator
+func validatorSetHash(validators []mockValidator) []byte {
+	leaves := make([][]byte, 0, len(validators))
+	for _, validator := range validators {
+		leaves = append(leaves, merkleLeaf(&tmtypes.SimpleValidator{
+			PubKey:      &tmcrypto.PublicKey{Sum: &tmcrypto.PublicKey_Ed25519{Ed25519: validator.pubKey()}},
+			VotingPower: validator.power,
+		}))
+	}
+	root, _ := simpleMerkle(leaves, -1)
+	return root
+}
+
+// voteSignBytes is the length-delimited CanonicalVote of the precommit, which has no message in the API module
+func voteSignBytes(chainId string, commit *tmtypes.Commit, index int) []byte {
+	sig := commit.Signatures[index]
+
+	vote := protowire.AppendTag(nil, 1, protowire.VarintType)
+	vote = protowire.AppendVarint(vote, uint64(tmtypes.SignedMsgType_SIGNED_MSG_TYPE_PRECOMMIT))
+	if commit.Height != 0 {
+		vote = protowire.AppendTag(vote, 2, protowire.Fixed64Type)
+		vote = protowire.AppendFixed64(vote, uint64(commit.Height))
+	}
+	if commit.Round != 0 {
+		vote = protowire.AppendTag(vote, 3, protowire.Fixed64Type)
+		vote = protowire.AppendFixed64(vote, uint64(commit.Round))
+	}
+	if sig.BlockIdFlag == tmtypes.BlockIDFlag_BLOCK_ID_FLAG_COMMIT {
+		blockId, _ := proto.MarshalOptions{Deterministic: true}.Marshal(commit.BlockId)
+		vote = protowire.AppendTag(vote, 4, protowire.BytesType)
+		vote = protowire.AppendBytes(vote, blockId)
+	}
+	timestamp, _ := proto.MarshalOptions{Deterministic: true}.Marshal(sig.Timestamp)
+	vote = protowire.AppendTag(vote, 5, protowire.BytesType)
+	vote = protowire.AppendBytes(vote, timestamp)
+	vote = protowire.AppendTag(vote, 6, protowire.BytesType)
+	vote = protowire.AppendString(vote, chainId)
+
+	return protowire.AppendBytes(nil, vote)
+}
+
+func merkleLeaf(message proto.Message) []byte {
+	encoded, _ := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	return sha256Sum(append([]byte{0}, encoded...))
+}
+
+// Query returns the value of the key in the store with the proofs of the key and of the store root
+func (c *MockChain) Query(storeName string, key []byte) ([]byte, *tmcrypto.ProofOps, error) {
+	store, ok := c.stores[storeName]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown store %s", storeName)
+	}
+
+	value, keyProof := store.prove(key)
+	rootProof := c.multistoreProof(storeName)
+
+	keyProofData, err := keyProof.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	rootProofData, err := rootProof.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return value, &tmcrypto.ProofOps{Ops: []*tmcrypto.ProofOp{
+		{Type_: "ics23:iavl", Key: key, Data: keyProofData},
+		{Type_: "ics23:simple", Key: []byte(storeName), Data: rootProofData},
+	}}, nil
+}
+
+// Module stores hold the keys in the layout of cheqd-node
+func newMockStores(ledger MockLedgerService) map[string]*mockStore {
+	didStore := map[string][]byte{}
+	for _, metadata := range ledger.Metadata {
+		value, _ := proto.MarshalOptions{Deterministic: true}.Marshal(&didTypes.DidDocWithMetadata{DidDoc: ledger.Did, Metadata: metadata})
+		didStore["did-version:"+ledger.Did.Id+":"+metadata.VersionId] = value
+	}
+	didStore["did-latest:"+ledger.Did.Id] = []byte(ledger.Metadata[len(ledger.Metadata)-1].VersionId)
+
+	resourceStore := map[string][]byte{}
+	for i := range ledger.Resources {
+		resource := &ledger.Resources[i]
+		key := resource.Metadata.CollectionId + ":" + resource.Metadata.Id
+		value, _ := proto.MarshalOptions{Deterministic: true}.Marshal(resource.Metadata)
+		resourceStore["resource-metadata:"+key] = value
+		resourceStore["resource-data:"+key] = resource.Resource.Data
+	}
+
+	return map[string]*mockStore{
+		"cheqd":    newMockStore(didStore),
+		"resource": newMockStore(resourceStore),
+	}
+}
+
+// multistoreRoot is the simple Merkle root of the store roots
+func (c *MockChain) multistoreRoot() []byte {
+	leaves := make([][]byte, 0, len(c.stores))
+	for _, name := range c.storeNames() {
+		leaves = append(leaves, c.multistoreLeaf(name))
+	}
+	root, _ := simpleMerkle(leaves, -1)
+	return root
+}
+
+func (c *MockChain) multistoreProof(storeName string) *ics23.CommitmentProof {
+	names := c.storeNames()
+	leaves := make([][]byte, 0, len(names))
+	index := 0
+	for i, name := range names {
+		leaves = append(leaves, c.multistoreLeaf(name))
+		if name == storeName {
+			index = i
+		}
+	}
+	_, path := simpleMerkle(leaves, index)
+
+	return &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: &ics23.ExistenceProof{
+		Key:   []byte(storeName),
+		Value: c.stores[storeName].root,
+		Leaf:  ics23.TendermintSpec.LeafSpec,
+		Path:  path,
+	}}}
+}
+
+func (c *MockChain) multistoreLeaf(name string) []byte {
+	leaf, _ := ics23.TendermintSpec.LeafSpec.Apply([]byte(name), c.stores[name].root)
+	return leaf
+}
+
+func (c *MockChain) storeNames() []string {
+	names := make([]string, 0, len(c.stores))
+	for name := range c.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// simpleMerkle returns the RFC 6962 root of the leaf hashes and the path from the leaf at the index
+func simpleMerkle(leaves [][]byte, index int) ([]byte, []*ics23.InnerOp) {
+	if len(leaves) == 1 {
+		return leaves[0], nil
+	}
+
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	left, leftPath := simpleMerkle(leaves[:k], index)
+	right, rightPath := simpleMerkle(leaves[k:], index-k)
+	root := sha256Sum(append(append([]byte{1}, left...), right...))
+
+	switch {
+	case index >= 0 && index < k:
+		return root, append(leftPath, &ics23.InnerOp{Hash: ics23.HashOp_SHA256, Prefix: []byte{1}, Suffix: right})
+	case index >= k && index < len(leaves):
+		return root, append(rightPath, &ics23.InnerOp{Hash: ics23.HashOp_SHA256, Prefix: append([]byte{1}, left...)})
+	default:
+		return root, nil
+	}
+}
+
+// mockStore is a balanced tree hashed the way IAVL trees are
+type mockStore struct {
+	keys   [][]byte
+	values [][]byte
+	root   []byte
+}
+
+func newMockStore(kv map[string][]byte) *mockStore {
+	store := &mockStore{}
+	for key := range kv {
+		store.keys = append(store.keys, []byte(key))
+	}
+	sort.Slice(store.keys, func(i, j int) bool { return bytes.Compare(store.keys[i], store.keys[j]) < 0 })
+	for _, key := range store.keys {
+		store.values = append(store.values, kv[string(key)])
+	}
+	store.root, _, _, _ = store.subtree(0, len(store.keys), -1)
+	return store
+}
+
+// prove returns the value of the key with the proof that the key has it, or the proof that the key is absent
+func (s *mockStore) prove(key []byte) ([]byte, *ics23.CommitmentProof) {
+	index := sort.Search(len(s.keys), func(i int) bool { return bytes.Compare(s.keys[i], key) >= 0 })
+	if index < len(s.keys) && bytes.Equal(s.keys[index], key) {
+		return s.values[index], &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: s.existenceProof(index)}}
+	}
+
+	nonExist := &ics23.NonExistenceProof{Key: key}
+	if index > 0 {
+		nonExist.Left = s.existenceProof(index - 1)
+	}
+	if index < len(s.keys) {
+		nonExist.Right = s.existenceProof(index)
+	}
+	return nil, &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Nonexist{Nonexist: nonExist}}
+}
+
+func (s *mockStore) existenceProof(index int) *ics23.ExistenceProof {
+	_, _, _, path := s.subtree(0, len(s.keys), index)
+	return &ics23.ExistenceProof{
+		Key:   s.keys[index],
+		Value: s.values[index],
+		Leaf:  iavlLeafOp(),
+		Path:  path,
+	}
+}
+
+// subtree returns the hash, height and size of the subtree of the keys between start and end
+// and the path from the leaf at the index
+func (s *mockStore) subtree(start int, end int, index int) ([]byte, int64, int64, []*ics23.InnerOp) {
+	if end-start == 1 {
+		hash, _ := iavlLeafOp().Apply(s.keys[start], s.values[start])
+		return hash, 0, 1, nil
+	}
+
+	middle := (start + end + 1) / 2
+	left, leftHeight, leftSize, leftPath := s.subtree(start, middle, index)
+	right, rightHeight, rightSize, rightPath := s.subtree(middle, end, index)
+	height := leftHeight + 1
+	if rightHeight >= leftHeight {
+		height = rightHeight + 1
+	}
+	size := leftSize + rightSize
+
+	prefix := binary.AppendVarint(binary.AppendVarint(binary.AppendVarint(nil, height), size), 1)
+	hash := sha256Sum(append(append(append(append(append([]byte{}, prefix...), 32), left...), 32), right...))
+
+	switch {
+	case index >= start && index < middle:
+		return hash, height, size, append(leftPath, &ics23.InnerOp{
+			Hash:   ics23.HashOp_SHA256,
+			Prefix: append(prefix, 32),
+			Suffix: append([]byte{32}, right...),
+		})
+	case index >= middle && index < end:
+		return hash, height, size, append(rightPath, &ics23.InnerOp{
+			Hash:   ics23.HashOp_SHA256,
+			Prefix: append(append(append(prefix, 32), left...), 32),
+		})
+	default:
+		return hash, height, size, nil
+	}
+}
+
+// Leaves of IAVL trees are prefixed with their height, size and version
+func iavlLeafOp() *ics23.LeafOp {
+	return &ics23.LeafOp{
+		Hash:         ics23.HashOp_SHA256,
+		PrehashValue: ics23.HashOp_SHA256,
+		Length:       ics23.LengthOp_VAR_PROTO,
+		Prefix:       binary.AppendVarint(binary.AppendVarint(binary.AppendVarint(nil, 0), 1), 1),
+	}
+}
+
+func newMockValidators(n int) []mockValidator {
+	validators := make([]mockValidator, 0, n)
+	for i := 0; i < n; i++ {
+		_, privKey, _ := ed25519.GenerateKey(nil)
+		validators = append(validators, mockValidator{privKey: privKey, power: 10})
+	}
+	return validators
+}
+
+func sha256Sum(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
@@ -0,0 +1,202 @@
+//go:build unit
+
+package unit
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/types"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// MockLedgerRESTServer serves the cheqd REST (LCD) API on a local port using MockLedgerService as storage.
+// Responses are encoded the way Cosmos SDK nodes do it.
+type MockLedgerRESTServer struct {
+	Address string
+
+	server   *httptest.Server
+	ledger   MockLedgerService
+	calls    int32
+	failWith int32
+
+	earliestHeight  int64
+	lastBlockHeight int64
+	traceParent     atomic.Value
+}
+
+func NewMockLedgerRESTServer(ledger MockLedgerService) *MockLedgerRESTServer {
+	s := &MockLedgerRESTServer{ledger: ledger}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.Address = strings.TrimPrefix(s.server.URL, "http://")
+
+	return s
+}
+
+// NewMockLedgerRESTServerTLS starts the server with HTTPS using the TLS config
+func NewMockLedgerRESTServerTLS(ledger MockLedgerService, tlsConfig *tls.Config) *MockLedgerRESTServer {
+	s := &MockLedgerRESTServer{ledger: ledger}
+	s.server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	s.server.TLS = tlsConfig
+	s.server.StartTLS()
+	s.Address = strings.TrimPrefix(s.server.URL, "https://")
+
+	return s
+}
+
+// Calls returns the number of handled requests
+func (s *MockLedgerRESTServer) Calls() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+// FailWith makes the node answer all the requests with the given HTTP status and no body, as proxies do
+func (s *MockLedgerRESTServer) FailWith(httpStatus int) {
+	atomic.StoreInt32(&s.failWith, int32(httpStatus))
+}
+
+// SetEarliestHeight makes the node behave as if the state below the height was pruned
+func (s *MockLedgerRESTServer) SetEarliestHeight(height int64) {
+	atomic.StoreInt64(&s.earliestHeight, height)
+}
+
+// LastBlockHeight returns the block height requested by the last query, 0 if it wasn't set
+func (s *MockLedgerRESTServer) LastBlockHeight() int64 {
+	return atomic.LoadInt64(&s.lastBlockHeight)
+}
+
+// TraceParent returns the traceparent header sent with the last query, empty if there was none
+func (s *MockLedgerRESTServer) TraceParent() string {
+	traceParent, _ := s.traceParent.Load().(string)
+	return traceParent
+}
+
+func (s *MockLedgerRESTServer) Stop() {
+	s.server.Close()
+}
+
+func (s *MockLedgerRESTServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.calls, 1)
+	s.traceParent.Store(r.Header.Get("traceparent"))
+	if failWith := atomic.LoadInt32(&s.failWith); failWith != 0 {
+		w.WriteHeader(int(failWith))
+		return
+	}
+
+	height, _ := strconv.ParseInt(r.Header.Get(services.BlockHeightHeader), 10, 64)
+	atomic.StoreInt64(&s.lastBlockHeight, height)
+	if height > 0 && height < atomic.LoadInt64(&s.earliestHeight) {
+		writeRESTError(w, http.StatusInternalServerError, codes.Unknown, "failed to load state at height "+strconv.FormatInt(height, 10)+"; version does not exist")
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		writeRESTError(w, http.StatusBadRequest, codes.InvalidArgument, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	var response proto.Message
+	var identityErr *types.IdentityError
+	switch parts := strings.Split(r.URL.Path, "/"); {
+	// /cheqd/did/v2/{id}
+	case len(parts) == 5 && parts[1] == "cheqd" && parts[2] == "did":
+		var didDoc *didTypes.DidDocWithMetadata
+		didDoc, identityErr = s.ledger.QueryDIDDoc(ctx, parts[4], "")
+		response = &didTypes.QueryDidDocResponse{Value: didDoc}
+	// /cheqd/did/v2/{id}/version/{version}
+	case len(parts) == 7 && parts[1] == "cheqd" && parts[2] == "did" && parts[5] == "version":
+		var didDoc *didTypes.DidDocWithMetadata
+		didDoc, identityErr = s.ledger.QueryDIDDoc(ctx, parts[4], parts[6])
+		response = &didTypes.QueryDidDocVersionResponse{Value: didDoc}
+	// /cheqd/did/v2/{id}/versions
+	case len(parts) == 6 && parts[1] == "cheqd" && parts[2] == "did" && parts[5] == "versions":
+		var versions []*didTypes.Metadata
+		versions, identityErr = s.ledger.QueryAllDidDocVersionsMetadata(ctx, parts[4])
+		start, end, pagination := paginate(len(versions), page)
+		response = &didTypes.QueryAllDidDocVersionsMetadataResponse{Versions: versions[start:end], Pagination: pagination}
+	// /cheqd/resource/v2/{collection_id}/resources/{id}
+	case len(parts) == 7 && parts[1] == "cheqd" && parts[2] == "resource" && parts[5] == "resources":
+		var resource *resourceTypes.ResourceWithMetadata
+		resource, identityErr = s.ledger.QueryResource(ctx, s.collectionDid(parts[4]), parts[6])
+		response = &resourceTypes.QueryResourceResponse{Resource: resource}
+	// /cheqd/resource/v2/{collection_id}/metadata
+	case len(parts) == 6 && parts[1] == "cheqd" && parts[2] == "resource" && parts[5] == "metadata":
+		var resources []*resourceTypes.Metadata
+		resources, identityErr = s.ledger.QueryCollectionResources(ctx, s.collectionDid(parts[4]))
+		start, end, pagination := paginate(len(resources), page)
+		response = &resourceTypes.QueryCollectionResourcesResponse{Resources: resources[start:end], Pagination: pagination}
+	default:
+		writeRESTError(w, http.StatusNotImplemented, codes.Unimplemented, "Not Implemented")
+		return
+	}
+
+	if identityErr != nil {
+		if identityErr.Code == types.NotFoundHttpCode {
+			writeRESTError(w, http.StatusNotFound, codes.NotFound, identityErr.Message)
+		} else {
+			writeRESTError(w, http.StatusInternalServerError, codes.Internal, identityErr.Message)
+		}
+		return
+	}
+
+	body, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(response)
+	if err != nil {
+		writeRESTError(w, http.StatusInternalServerError, codes.Internal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// The node is queried by collection id only, so restore the DID of the mocked DIDDoc
+func (s *MockLedgerRESTServer) collectionDid(collectionId string) string {
+	return (&mockResourceQueryServer{ledger: s.ledger}).collectionDid(collectionId)
+}
+
+func parsePageParams(r *http.Request) (*queryTypes.PageRequest, error) {
+	query := r.URL.Query()
+	if !query.Has("pagination.key") && !query.Has("pagination.limit") {
+		return nil, nil
+	}
+
+	page := &queryTypes.PageRequest{}
+	if query.Has("pagination.key") {
+		key, err := base64.StdEncoding.DecodeString(query.Get("pagination.key"))
+		if err != nil {
+			return nil, err
+		}
+		page.Key = key
+	}
+	if query.Has("pagination.limit") {
+		limit, err := strconv.ParseUint(query.Get("pagination.limit"), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+func writeRESTError(w http.ResponseWriter, httpStatus int, code codes.Code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    int(code),
+		"message": message,
+		"details": []interface{}{},
+	})
+}
@@ -0,0 +1,375 @@
+//go:build unit
+
+package unit
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	"github.com/cheqd/did-resolver/services"
+	"github.com/cheqd/did-resolver/types"
+	"github.com/cheqd/did-resolver/utils"
+	queryTypes "github.com/cosmos/cosmos-sdk/api/cosmos/base/query/v1beta1"
+	tmservice "github.com/cosmos/cosmos-sdk/api/cosmos/base/tendermint/v1beta1"
+	abci "github.com/cosmos/cosmos-sdk/api/tendermint/abci"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MockLedgerServer serves the cheqd gRPC query API on a local port using MockLedgerService as storage
+type MockLedgerServer struct {
+	Address string
+
+	server   *grpc.Server
+	listener *countingListener
+	calls    int32
+	syncing  atomic.Bool
+	failWith atomic.Value
+	// Number of the next queries to fail with failNextWith
+	failNext     atomic.Int32
+	failNextWith atomic.Value
+
+	earliestHeight  int64
+	lastBlockHeight int64
+
+	// Blocks and proofs of the ledger state, the node serves none if not set
+	chain  atomic.Pointer[MockChain]
+	forged atomic.Bool
+
+	// W3C trace context of the last query
+	traceParent atomic.Value
+	// Number of the requests of the latest block
+	latestBlocks atomic.Int32
+}
+
+// NewMockLedgerServer starts the server. Options such as TLS credentials are passed to the gRPC server.
+func NewMockLedgerServer(ledger MockLedgerService, opts ...grpc.ServerOption) (*MockLedgerServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &MockLedgerServer{
+		Address:  listener.Addr().String(),
+		listener: &countingListener{Listener: listener},
+	}
+	s.server = grpc.NewServer(append(opts,
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.intercept(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		// ABCIQuery is missing from the generated tendermint service
+		grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			if err := s.intercept(stream.Context()); err != nil {
+				return err
+			}
+			return s.handleUnknown(stream)
+		}),
+	)...)
+	didTypes.RegisterQueryServer(s.server, &mockDidQueryServer{ledger: ledger})
+	resourceTypes.RegisterQueryServer(s.server, &mockResourceQueryServer{ledger: ledger})
+	tmservice.RegisterServiceServer(s.server, &mockTendermintServer{server: s})
+
+	go func() {
+		_ = s.server.Serve(s.listener)
+	}()
+
+	return s, nil
+}
+
+func (s *MockLedgerServer) intercept(ctx context.Context) error {
+	atomic.AddInt32(&s.calls, 1)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		s.traceParent.Store(strings.Join(md.Get("traceparent"), ","))
+	}
+	if err, ok := s.failWith.Load().(error); ok && err != nil {
+		return err
+	}
+	if s.takeFailure() {
+		return s.failNextWith.Load().(error)
+	}
+	return s.checkBlockHeight(ctx)
+}
+
+// SetChain makes the node serve the blocks of the chain and the proofs of its state
+func (s *MockLedgerServer) SetChain(chain *MockChain) {
+	s.chain.Store(chain)
+}
+
+// SetForging makes the node tamper with the values it proves
+func (s *MockLedgerServer) SetForging(forged bool) {
+	s.forged.Store(forged)
+}
+
+func (s *MockLedgerServer) handleUnknown(stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	chain := s.chain.Load()
+	if method != "/cosmos.base.tendermint.v1beta1.Service/ABCIQuery" || chain == nil {
+		return status.Errorf(codes.Unimplemented, "method %s not implemented", method)
+	}
+
+	request := &abci.RequestQuery{}
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	storeName := strings.TrimSuffix(strings.TrimPrefix(request.Path, "/store/"), "/key")
+	value, proofOps, err := chain.Query(storeName, request.Data)
+	if err != nil {
+		return stream.SendMsg(&abci.ResponseQuery{Code: 1, Log: err.Error()})
+	}
+	if s.forged.Load() && len(value) > 0 {
+		value = append([]byte{}, value...)
+		value[0] ^= 1
+	}
+
+	return stream.SendMsg(&abci.ResponseQuery{Key: request.Data, Value: value, ProofOps: proofOps, Height: request.Height})
+}
+
+// Connections returns the number of accepted client connections
+func (s *MockLedgerServer) Connections() int {
+	return int(atomic.LoadInt32(&s.listener.accepted))
+}
+
+// Calls returns the number of handled gRPC calls
+func (s *MockLedgerServer) Calls() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+// TraceParent returns the traceparent header sent with the last query, empty if there was none
+func (s *MockLedgerServer) TraceParent() string {
+	traceParent, _ := s.traceParent.Load().(string)
+	return traceParent
+}
+
+// SetSyncing makes the node report that it is catching up with the chain
+func (s *MockLedgerServer) SetSyncing(syncing bool) {
+	s.syncing.Store(syncing)
+}
+
+// FailWith makes the node answer all the queries with the given status
+func (s *MockLedgerServer) FailWith(code codes.Code, message string) {
+	s.failWith.Store(status.Error(code, message))
+}
+
+// FailNext makes the node answer the next n queries with the given status
+func (s *MockLedgerServer) FailNext(n int32, code codes.Code, message string) {
+	s.failNextWith.Store(status.Error(code, message))
+	s.failNext.Store(n)
+}
+
+func (s *MockLedgerServer) takeFailure() bool {
+	for n := s.failNext.Load(); n > 0; n = s.failNext.Load() {
+		if s.failNext.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// LatestBlockCalls returns the number of times the latest block was requested
+func (s *MockLedgerServer) LatestBlockCalls() int {
+	return int(s.latestBlocks.Load())
+}
+
+// SetEarliestHeight makes the node behave as if the state below the height was pruned
+func (s *MockLedgerServer) SetEarliestHeight(height int64) {
+	atomic.StoreInt64(&s.earliestHeight, height)
+}
+
+// LastBlockHeight returns the block height requested by the last query, 0 if it wasn't set
+func (s *MockLedgerServer) LastBlockHeight() int64 {
+	return atomic.LoadInt64(&s.lastBlockHeight)
+}
+
+func (s *MockLedgerServer) checkBlockHeight(ctx context.Context) error {
+	var height int64
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(services.BlockHeightHeader); len(values) == 1 {
+		height, _ = strconv.ParseInt(values[0], 10, 64)
+	}
+	atomic.StoreInt64(&s.lastBlockHeight, height)
+
+	if height > 0 && height < atomic.LoadInt64(&s.earliestHeight) {
+		return status.Errorf(codes.Unknown, "failed to load state at height %d; version does not exist (latest height: %d)", height, atomic.LoadInt64(&s.earliestHeight))
+	}
+	return nil
+}
+
+func (s *MockLedgerServer) Stop() {
+	s.server.Stop()
+}
+
+type countingListener struct {
+	net.Listener
+	accepted int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt32(&l.accepted, 1)
+	}
+	return conn, err
+}
+
+type mockTendermintServer struct {
+	tmservice.UnimplementedServiceServer
+	server *MockLedgerServer
+}
+
+func (s *mockTendermintServer) GetSyncing(ctx context.Context, req *tmservice.GetSyncingRequest) (*tmservice.GetSyncingResponse, error) {
+	return &tmservice.GetSyncingResponse{Syncing: s.server.syncing.Load()}, nil
+}
+
+func (s *mockTendermintServer) GetLatestBlock(ctx context.Context, req *tmservice.GetLatestBlockRequest) (*tmservice.GetLatestBlockResponse, error) {
+	s.server.latestBlocks.Add(1)
+	chain := s.server.chain.Load()
+	if chain == nil {
+		return nil, status.Error(codes.Unimplemented, "no blocks")
+	}
+	block, _ := chain.block(chain.Height())
+	return &tmservice.GetLatestBlockResponse{Block: block}, nil
+}
+
+func (s *mockTendermintServer) GetBlockByHeight(ctx context.Context, req *tmservice.GetBlockByHeightRequest) (*tmservice.GetBlockByHeightResponse, error) {
+	chain := s.server.chain.Load()
+	if chain == nil {
+		return nil, status.Error(codes.Unimplemented, "no blocks")
+	}
+	block, ok := chain.block(req.Height)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "requested block height is bigger then the chain length")
+	}
+	return &tmservice.GetBlockByHeightResponse{Block: block}, nil
+}
+
+func (s *mockTendermintServer) GetValidatorSetByHeight(ctx context.Context, req *tmservice.GetValidatorSetByHeightRequest) (*tmservice.GetValidatorSetByHeightResponse, error) {
+	chain := s.server.chain.Load()
+	if chain == nil {
+		return nil, status.Error(codes.Unimplemented, "no validators")
+	}
+	if req.Height < 1 || req.Height > chain.Height()+1 {
+		return nil, status.Error(codes.InvalidArgument, "requested block height is bigger then the chain length")
+	}
+
+	// Validator sets are paginated by offset
+	validators := chain.validatorSet(req.Height)
+	start, end, _ := paginate(len(validators), &queryTypes.PageRequest{Offset: req.Pagination.GetOffset(), Limit: req.Pagination.GetLimit()})
+	return &tmservice.GetValidatorSetByHeightResponse{
+		BlockHeight: req.Height,
+		Validators:  validators[start:end],
+		Pagination:  &queryTypes.PageResponse{Total: uint64(len(validators))},
+	}, nil
+}
+
+type mockDidQueryServer struct {
+	didTypes.UnimplementedQueryServer
+	ledger MockLedgerService
+}
+
+func (s *mockDidQueryServer) DidDoc(ctx context.Context, req *didTypes.QueryDidDocRequest) (*didTypes.QueryDidDocResponse, error) {
+	didDoc, err := s.ledger.QueryDIDDoc(ctx, req.Id, "")
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &didTypes.QueryDidDocResponse{Value: didDoc}, nil
+}
+
+func (s *mockDidQueryServer) DidDocVersion(ctx context.Context, req *didTypes.QueryDidDocVersionRequest) (*didTypes.QueryDidDocVersionResponse, error) {
+	didDoc, err := s.ledger.QueryDIDDoc(ctx, req.Id, req.Version)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &didTypes.QueryDidDocVersionResponse{Value: didDoc}, nil
+}
+
+func (s *mockDidQueryServer) AllDidDocVersionsMetadata(ctx context.Context, req *didTypes.QueryAllDidDocVersionsMetadataRequest) (*didTypes.QueryAllDidDocVersionsMetadataResponse, error) {
+	versions, err := s.ledger.QueryAllDidDocVersionsMetadata(ctx, req.Id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	start, end, page := paginate(len(versions), req.Pagination)
+	return &didTypes.QueryAllDidDocVersionsMetadataResponse{Versions: versions[start:end], Pagination: page}, nil
+}
+
+type mockResourceQueryServer struct {
+	resourceTypes.UnimplementedQueryServer
+	ledger MockLedgerService
+}
+
+func (s *mockResourceQueryServer) Resource(ctx context.Context, req *resourceTypes.QueryResourceRequest) (*resourceTypes.QueryResourceResponse, error) {
+	resource, err := s.ledger.QueryResource(ctx, s.collectionDid(req.CollectionId), req.Id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &resourceTypes.QueryResourceResponse{Resource: resource}, nil
+}
+
+func (s *mockResourceQueryServer) CollectionResources(ctx context.Context, req *resourceTypes.QueryCollectionResourcesRequest) (*resourceTypes.QueryCollectionResourcesResponse, error) {
+	resources, err := s.ledger.QueryCollectionResources(ctx, s.collectionDid(req.CollectionId))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	start, end, page := paginate(len(resources), req.Pagination)
+	return &resourceTypes.QueryCollectionResourcesResponse{Resources: resources[start:end], Pagination: page}, nil
+}
+
+// The node is queried by collection id only, so restore the DID of the mocked DIDDoc
+func (s *mockResourceQueryServer) collectionDid(collectionId string) string {
+	method, namespace, _, err := utils.TrySplitDID(s.ledger.Did.Id)
+	if err != nil {
+		return collectionId
+	}
+	return utils.JoinDID(method, namespace, collectionId)
+}
+
+// paginate selects the part of a list to return the way Cosmos SDK nodes do.
+// Next keys are the index of the first item of the next page.
+func paginate(total int, req *queryTypes.PageRequest) (int, int, *queryTypes.PageResponse) {
+	if req == nil {
+		return 0, total, nil
+	}
+
+	start := int(req.Offset)
+	if len(req.Key) != 0 {
+		start, _ = strconv.Atoi(string(req.Key))
+	}
+	if start > total {
+		start = total
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 100
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	page := &queryTypes.PageResponse{}
+	if end < total {
+		page.NextKey = []byte(strconv.Itoa(end))
+	}
+	if req.CountTotal {
+		page.Total = uint64(total)
+	}
+
+	return start, end, page
+}
+
+func toStatusError(err *types.IdentityError) error {
+	if err.Code == types.NotFoundHttpCode {
+		return status.Error(codes.NotFound, err.Message)
+	}
+	return status.Error(codes.Internal, err.Message)
+}
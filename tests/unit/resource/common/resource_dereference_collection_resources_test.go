@@ -3,6 +3,7 @@
 package common
 
 import (
+	"context"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -20,14 +21,14 @@ type dereferenceCollectionResourcesTestCase struct {
 }
 
 var _ = DescribeTable("Test DereferenceCollectionResources method", func(testCase dereferenceCollectionResourcesTestCase) {
-	resourceService := services.NewResourceService(testconstants.ValidMethod, utils.MockLedger)
+	resourceService := services.NewResourceService(testconstants.ValidMethod, utils.MockLedger, types.DefaultResourceMaxSize)
 
 	expectedContentType := utils.DefineContentType(
 		testCase.expectedResourceDereferencing.DereferencingMetadata.ContentType,
 		testCase.dereferencingType,
 	)
 
-	dereferencingResult, err := resourceService.DereferenceCollectionResources(testCase.did, testCase.dereferencingType)
+	dereferencingResult, err := resourceService.DereferenceCollectionResources(context.Background(), testCase.did, testCase.dereferencingType)
 	if err != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
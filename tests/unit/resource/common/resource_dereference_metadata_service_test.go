@@ -3,6 +3,7 @@
 package common
 
 import (
+	"context"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -23,14 +24,14 @@ type dereferenceResourceMetadataTestCase struct {
 }
 
 var _ = DescribeTable("Test DereferenceResourceMetadata method", func(testCase dereferenceResourceMetadataTestCase) {
-	resourceService := services.NewResourceService(testconstants.ValidMethod, utils.MockLedger)
+	resourceService := services.NewResourceService(testconstants.ValidMethod, utils.MockLedger, types.DefaultResourceMaxSize)
 
 	expectedContentType := utils.DefineContentType(
 		testCase.expectedResourceDereferencing.DereferencingMetadata.ContentType,
 		testCase.dereferencingType,
 	)
 
-	dereferencingResult, err := resourceService.DereferenceResourceMetadata(testCase.did, testCase.resourceId, testCase.dereferencingType)
+	dereferencingResult, err := resourceService.DereferenceResourceMetadata(context.Background(), testCase.did, testCase.resourceId, testCase.dereferencingType)
 	if err != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
@@ -3,6 +3,7 @@
 package common
 
 import (
+	"context"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -23,10 +24,10 @@ type dereferenceResourceDataTestCase struct {
 }
 
 var _ = DescribeTable("Test DereferenceResourceData method", func(testCase dereferenceResourceDataTestCase) {
-	resourceService := services.NewResourceService(testconstants.ValidMethod, utils.MockLedger)
+	resourceService := services.NewResourceService(testconstants.ValidMethod, utils.MockLedger, types.DefaultResourceMaxSize)
 
 	expectedContentType := types.ContentType(testconstants.ValidResource[0].Metadata.MediaType)
-	dereferencingResult, err := resourceService.DereferenceResourceData(testCase.did, testCase.resourceId, testCase.dereferencingType)
+	dereferencingResult, err := resourceService.DereferenceResourceData(context.Background(), testCase.did, testCase.resourceId, testCase.dereferencingType)
 	if err != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
@@ -54,7 +55,7 @@ var _ = DescribeTable("Test DereferenceResourceData method", func(testCase deref
 					},
 				},
 				ContentStream: &testconstants.ValidResourceDereferencing,
-				Metadata:      types.ResolutionResourceMetadata{},
+				Metadata:      types.NewResolutionResourceMetadata(testconstants.ValidResource[0].Metadata),
 			},
 			expectedError: nil,
 		},
@@ -75,7 +76,7 @@ var _ = DescribeTable("Test DereferenceResourceData method", func(testCase deref
 					},
 				},
 				ContentStream: &testconstants.ValidResourceDereferencing,
-				Metadata:      types.ResolutionResourceMetadata{},
+				Metadata:      types.NewResolutionResourceMetadata(testconstants.ValidResource[0].Metadata),
 			},
 			expectedError: nil,
 		},
@@ -3,6 +3,7 @@
 package ledger
 
 import (
+	"context"
 	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
 	testconstants "github.com/cheqd/did-resolver/tests/constants"
 	utils "github.com/cheqd/did-resolver/tests/unit"
@@ -18,7 +19,7 @@ type queryCollectionResourcesTestCase struct {
 }
 
 var _ = DescribeTable("Test QueryCollectionResources method", func(testCase queryCollectionResourcesTestCase) {
-	collection, err := utils.MockLedger.QueryCollectionResources(testCase.did)
+	collection, err := utils.MockLedger.QueryCollectionResources(context.Background(), testCase.did)
 	if err != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
@@ -3,6 +3,7 @@
 package ledger
 
 import (
+	"context"
 	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
 	testconstants "github.com/cheqd/did-resolver/tests/constants"
 	utils "github.com/cheqd/did-resolver/tests/unit"
@@ -19,7 +20,7 @@ type queryResourceTestCase struct {
 }
 
 var _ = DescribeTable("Test QueryResource method", func(testCase queryResourceTestCase) {
-	resource, err := utils.MockLedger.QueryResource(testCase.collectionId, testCase.resourceId)
+	resource, err := utils.MockLedger.QueryResource(context.Background(), testCase.collectionId, testCase.resourceId)
 	if err != nil {
 		Expect(testCase.expectedError.Code).To(Equal(err.Code))
 		Expect(testCase.expectedError.Message).To(Equal(err.Message))
@@ -0,0 +1,155 @@
+//go:build unit
+
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	didTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/did/v2"
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+	resourceServices "github.com/cheqd/did-resolver/services/resource"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+type resourceCollectionPagingTestCase struct {
+	didURL              string
+	expectedResourceIds []string
+	expectedError       error
+}
+
+var pagingLedger = func() utils.MockLedgerService {
+	resources := make([]resourceTypes.ResourceWithMetadata, 5)
+	for i := range resources {
+		metadata := proto.Clone(testconstants.ValidResource[0].Metadata).(*resourceTypes.Metadata)
+		metadata.Id = "resource-" + strconv.Itoa(i)
+		resources[i] = resourceTypes.ResourceWithMetadata{Metadata: metadata}
+	}
+
+	return utils.NewMockLedgerService(
+		&testconstants.ValidDIDDoc,
+		[]*didTypes.Metadata{&testconstants.ValidMetadata},
+		resources,
+	)
+}()
+
+var _ = DescribeTable("Test paging of the collection of resources", func(testCase resourceCollectionPagingTestCase) {
+	request := httptest.NewRequest(http.MethodGet, testCase.didURL, nil)
+	context, rec := utils.SetupEmptyContext(request, types.DIDJSONLD, pagingLedger)
+
+	err := resourceServices.ResourceCollectionEchoHandler(context)
+	if testCase.expectedError != nil {
+		Expect(err).ToNot(BeNil())
+		Expect(testCase.expectedError.Error()).To(Equal(err.Error()))
+	} else {
+		var dereferencingResult DereferencingResult
+		Expect(err).To(BeNil())
+		Expect(json.Unmarshal(rec.Body.Bytes(), &dereferencingResult)).To(BeNil())
+
+		resourceIds := []string{}
+		for _, resource := range dereferencingResult.ContentStream.Resources {
+			resourceIds = append(resourceIds, resource.ResourceId)
+		}
+		Expect(resourceIds).To(Equal(testCase.expectedResourceIds))
+	}
+},
+
+	Entry(
+		"returns all the resources without paging queries",
+		resourceCollectionPagingTestCase{
+			didURL:              fmt.Sprintf("/1.0/identifiers/%s/metadata", testconstants.ExistentDid),
+			expectedResourceIds: []string{"resource-0", "resource-1", "resource-2", "resource-3", "resource-4"},
+		},
+	),
+
+	Entry(
+		"returns the first page of resources",
+		resourceCollectionPagingTestCase{
+			didURL:              fmt.Sprintf("/1.0/identifiers/%s/metadata?limit=2", testconstants.ExistentDid),
+			expectedResourceIds: []string{"resource-0", "resource-1"},
+		},
+	),
+
+	Entry(
+		"returns a page of resources at the offset",
+		resourceCollectionPagingTestCase{
+			didURL:              fmt.Sprintf("/1.0/identifiers/%s/metadata?limit=2&offset=2", testconstants.ExistentDid),
+			expectedResourceIds: []string{"resource-2", "resource-3"},
+		},
+	),
+
+	Entry(
+		"returns the rest of resources after the offset",
+		resourceCollectionPagingTestCase{
+			didURL:              fmt.Sprintf("/1.0/identifiers/%s/metadata?offset=3", testconstants.ExistentDid),
+			expectedResourceIds: []string{"resource-3", "resource-4"},
+		},
+	),
+
+	Entry(
+		"returns no resources when the offset is past the end",
+		resourceCollectionPagingTestCase{
+			didURL:              fmt.Sprintf("/1.0/identifiers/%s/metadata?offset=10", testconstants.ExistentDid),
+			expectedResourceIds: []string{},
+		},
+	),
+
+	Entry(
+		"cannot get a page with zero limit",
+		resourceCollectionPagingTestCase{
+			didURL:        fmt.Sprintf("/1.0/identifiers/%s/metadata?limit=0", testconstants.ExistentDid),
+			expectedError: types.NewInvalidDidUrlError(testconstants.ExistentDid, types.DIDJSONLD, nil, true),
+		},
+	),
+
+	Entry(
+		"cannot get a page with negative offset",
+		resourceCollectionPagingTestCase{
+			didURL:        fmt.Sprintf("/1.0/identifiers/%s/metadata?offset=-1", testconstants.ExistentDid),
+			expectedError: types.NewInvalidDidUrlError(testconstants.ExistentDid, types.DIDJSONLD, nil, true),
+		},
+	),
+
+	Entry(
+		"cannot get a page with a limit beyond the maximum",
+		resourceCollectionPagingTestCase{
+			didURL:        fmt.Sprintf("/1.0/identifiers/%s/metadata?limit=%d&offset=1", testconstants.ExistentDid, int64(math.MaxInt64)),
+			expectedError: types.NewInvalidDidUrlError(testconstants.ExistentDid, types.DIDJSONLD, nil, true),
+		},
+	),
+
+	Entry(
+		"cannot get collection of resources with other queries",
+		resourceCollectionPagingTestCase{
+			didURL:        fmt.Sprintf("/1.0/identifiers/%s/metadata?limit=2&resourceName=name", testconstants.ExistentDid),
+			expectedError: types.NewInvalidDidUrlError(testconstants.ExistentDid, types.DIDJSONLD, nil, true),
+		},
+	),
+)
+
+var _ = DescribeTable("Test bounds of a page", func(page types.Page, length int, expectedStart int, expectedEnd int) {
+	start, end := page.Bounds(length)
+	Expect(start).To(Equal(expectedStart))
+	Expect(end).To(Equal(expectedEnd))
+},
+
+	Entry("a page within the list", types.Page{Offset: 1, Limit: 2}, 5, 1, 3),
+
+	Entry("a page cut at the end of the list", types.Page{Offset: 3, Limit: 5}, 5, 3, 5),
+
+	Entry("the rest of the list without a limit", types.Page{Offset: 2}, 5, 2, 5),
+
+	Entry("an offset past the end of the list", types.Page{Offset: 10, Limit: 2}, 5, 5, 5),
+
+	Entry("the greatest limit after an offset", types.Page{Offset: 1, Limit: math.MaxInt}, 5, 1, 5),
+)
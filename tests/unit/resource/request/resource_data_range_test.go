@@ -0,0 +1,134 @@
+//go:build unit
+
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cheqd/did-resolver/services"
+	resourceServices "github.com/cheqd/did-resolver/services/resource"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+var _ = Describe("Resource data byte ranges", func() {
+	data := testconstants.ValidResource[0].Resource.Data
+
+	newContext := func(headers map[string]string) (services.ResolverContext, *httptest.ResponseRecorder) {
+		request := httptest.NewRequest(http.MethodGet, fmt.Sprintf(
+			"/1.0/identifiers/%s/resources/%s",
+			testconstants.ExistentDid,
+			testconstants.ExistentResourceId,
+		), nil)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		context, rec := utils.SetupEmptyContext(request, types.DIDJSONLD, utils.MockLedger)
+
+		return context.(services.ResolverContext), rec
+	}
+
+	// The ETag of the resource data, as returned with the whole of it
+	currentETag := func() string {
+		context, rec := newContext(nil)
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		return rec.Header().Get("ETag")
+	}
+
+	It("returns the whole resource with its length and accepted ranges", func() {
+		context, rec := newContext(nil)
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.Bytes()).To(Equal(data))
+		Expect(rec.Header().Get(echo.HeaderContentLength)).To(Equal(strconv.Itoa(len(data))))
+		Expect(rec.Header().Get("Accept-Ranges")).To(Equal("bytes"))
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(testconstants.ValidResource[0].Metadata.MediaType))
+	})
+
+	It("returns the requested range", func() {
+		context, rec := newContext(map[string]string{"Range": "bytes=2-7"})
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusPartialContent))
+		Expect(rec.Body.Bytes()).To(Equal(data[2:8]))
+		Expect(rec.Header().Get(echo.HeaderContentLength)).To(Equal("6"))
+		Expect(rec.Header().Get("Content-Range")).To(Equal(fmt.Sprintf("bytes 2-7/%d", len(data))))
+		Expect(rec.Header().Get(echo.HeaderContentType)).To(Equal(testconstants.ValidResource[0].Metadata.MediaType))
+	})
+
+	It("returns the rest of the resource from an offset", func() {
+		context, rec := newContext(map[string]string{"Range": "bytes=10-"})
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusPartialContent))
+		Expect(rec.Body.Bytes()).To(Equal(data[10:]))
+	})
+
+	It("rejects a range beyond the end of the resource", func() {
+		context, rec := newContext(map[string]string{"Range": fmt.Sprintf("bytes=%d-", len(data)+10)})
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusRequestedRangeNotSatisfiable))
+		Expect(rec.Header().Get("Content-Range")).To(Equal(fmt.Sprintf("bytes */%d", len(data))))
+	})
+
+	It("returns the range if If-Range matches the ETag", func() {
+		context, rec := newContext(map[string]string{"Range": "bytes=0-3", "If-Range": currentETag()})
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusPartialContent))
+		Expect(rec.Body.Bytes()).To(Equal(data[0:4]))
+	})
+
+	It("returns the whole resource if If-Range doesn't match the ETag", func() {
+		context, rec := newContext(map[string]string{"Range": "bytes=0-3", "If-Range": `"outdated"`})
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.Bytes()).To(Equal(data))
+	})
+
+	It("doesn't compress byte ranges", func() {
+		context, rec := newContext(map[string]string{"Range": "bytes=0-3", echo.HeaderAcceptEncoding: "gzip"})
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusPartialContent))
+		Expect(rec.Header().Get(echo.HeaderContentEncoding)).To(BeEmpty())
+	})
+
+	It("returns the range if If-Range matches the ETag of the compressed resource", func() {
+		context, rec := newContext(map[string]string{echo.HeaderAcceptEncoding: "gzip"})
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Header().Get(echo.HeaderContentEncoding)).To(Equal("gzip"))
+		etag := rec.Header().Get("ETag")
+
+		context, rec = newContext(map[string]string{"Range": "bytes=0-3", "If-Range": etag, echo.HeaderAcceptEncoding: "gzip"})
+
+		Expect(resourceServices.ResourceDataEchoHandler(context)).To(Succeed())
+		Expect(rec.Code).To(Equal(http.StatusPartialContent))
+		Expect(rec.Header().Get("ETag")).To(Equal(etag))
+		Expect(rec.Body.Bytes()).To(Equal(data[0:4]))
+	})
+
+	It("rejects resources larger than the maximum resource size", func() {
+		context, _ := newContext(nil)
+		context.ResourceService = services.NewResourceService(types.DID_METHOD, utils.MockLedger, len(data)-1)
+
+		err := resourceServices.ResourceDataEchoHandler(context)
+		Expect(err).To(HaveOccurred())
+
+		identityError, ok := err.(*types.IdentityError)
+		Expect(ok).To(BeTrue())
+		Expect(identityError.Message).To(Equal("resourceTooLarge"))
+		Expect(identityError.Code).To(Equal(types.ResourceTooLargeHttpCode))
+	})
+})
@@ -3,6 +3,7 @@
 package unit
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -32,7 +33,7 @@ func SetupEmptyContext(request *http.Request, resolutionType types.ContentType,
 	resourceServices.SetRoutes(e)
 
 	didService := services.NewDIDDocService(types.DID_METHOD, ledgerService)
-	resourceService := services.NewResourceService(types.DID_METHOD, ledgerService)
+	resourceService := services.NewResourceService(types.DID_METHOD, ledgerService, types.DefaultResourceMaxSize)
 
 	rec := httptest.NewRecorder()
 	context := e.NewContext(request, rec)
@@ -48,6 +49,31 @@ func SetupEmptyContext(request *http.Request, resolutionType types.ContentType,
 	return rc, rec
 }
 
+// SetupEcho returns the resolver routes with the middleware which passes the services to them,
+// for the tests which send requests through the whole server
+func SetupEcho(ledgerService services.LedgerServiceI) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = services.CustomHTTPErrorHandler
+
+	didService := services.NewDIDDocService(types.DID_METHOD, ledgerService)
+	resourceService := services.NewResourceService(types.DID_METHOD, ledgerService, types.DefaultResourceMaxSize)
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return next(services.ResolverContext{
+				Context:         c,
+				LedgerService:   ledgerService,
+				DidDocService:   didService,
+				ResourceService: resourceService,
+			})
+		}
+	})
+
+	didDocServices.SetRoutes(e)
+	resourceServices.SetRoutes(e)
+
+	return e
+}
+
 type RedirectDIDTestCase struct {
 	DidURL                 string
 	ResolutionType         types.ContentType
@@ -76,7 +102,7 @@ func NewMockLedgerService(did *didTypes.DidDoc, metadata []*didTypes.Metadata, r
 }
 
 // TODO: add more unit tests for testing QueryDIDDoc method.
-func (ls MockLedgerService) QueryDIDDoc(did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
+func (ls MockLedgerService) QueryDIDDoc(ctx context.Context, did string, version string) (*didTypes.DidDocWithMetadata, *types.IdentityError) {
 	if ls.Did.Id == did {
 		if version == "" {
 			return &didTypes.DidDocWithMetadata{DidDoc: ls.Did, Metadata: ls.Metadata[len(ls.Metadata)-1]}, nil
@@ -92,7 +118,7 @@ func (ls MockLedgerService) QueryDIDDoc(did string, version string) (*didTypes.D
 }
 
 // TODO: add unit tests for testing QueryAllDidDocVersionsMetadata method.
-func (ls MockLedgerService) QueryAllDidDocVersionsMetadata(did string) ([]*didTypes.Metadata, *types.IdentityError) {
+func (ls MockLedgerService) QueryAllDidDocVersionsMetadata(ctx context.Context, did string) ([]*didTypes.Metadata, *types.IdentityError) {
 	if ls.Did.Id == did {
 		return ls.Metadata, nil
 	}
@@ -100,7 +126,7 @@ func (ls MockLedgerService) QueryAllDidDocVersionsMetadata(did string) ([]*didTy
 	return nil, types.NewNotFoundError(did, types.JSON, nil, true)
 }
 
-func (ls MockLedgerService) QueryResource(did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
+func (ls MockLedgerService) QueryResource(ctx context.Context, did string, resourceId string) (*resourceTypes.ResourceWithMetadata, *types.IdentityError) {
 	if ls.Did.Id != did {
 		return nil, types.NewNotFoundError(did, types.JSON, nil, true)
 	}
@@ -118,7 +144,7 @@ func (ls MockLedgerService) QueryResource(did string, resourceId string) (*resou
 }
 
 // TODO: add unit tests for testing QueryCollectionResources method.
-func (ls MockLedgerService) QueryCollectionResources(did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
+func (ls MockLedgerService) QueryCollectionResources(ctx context.Context, did string) ([]*resourceTypes.Metadata, *types.IdentityError) {
 	if ls.Did.Id != did {
 		return []*resourceTypes.Metadata{}, types.NewNotFoundError(did, types.JSON, nil, true)
 	}
@@ -0,0 +1,225 @@
+//go:build unit
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cheqd/did-resolver/services"
+	tracingServices "github.com/cheqd/did-resolver/services/tracing"
+	testconstants "github.com/cheqd/did-resolver/tests/constants"
+	utils "github.com/cheqd/did-resolver/tests/unit"
+	"github.com/cheqd/did-resolver/types"
+)
+
+const clientTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// Spans are exported as soon as they end
+var exporter = tracetest.NewInMemoryExporter()
+
+var _ = BeforeSuite(func() {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+})
+
+// spanNamed returns the only exported span with the name
+func spanNamed(name string) tracetest.SpanStub {
+	var found []tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+	ExpectWithOffset(1, found).To(HaveLen(1), "span %s", name)
+	return found[0]
+}
+
+// ancestors returns the names of the exported spans the span is nested in, the closest first
+func ancestors(span tracetest.SpanStub) []string {
+	var names []string
+	for parent := span.Parent; parent.IsValid(); {
+		found := false
+		for _, candidate := range exporter.GetSpans() {
+			if candidate.SpanContext.SpanID() == parent.SpanID() {
+				names = append(names, candidate.Name)
+				parent = candidate.Parent
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return names
+}
+
+var _ = Describe("Tracing", func() {
+	var e *echo.Echo
+
+	BeforeEach(func() {
+		exporter.Reset()
+
+		router := services.NewLedgerRouter()
+		router.Register(types.DID_METHOD, testconstants.ValidMainnetNamespace, utils.MockLedger)
+		e = utils.SetupEcho(router)
+		e.Use(tracingServices.Middleware())
+	})
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set(echo.HeaderAccept, string(types.DIDJSONLD))
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, request)
+
+		return rec
+	}
+
+	It("traces every step of the request, every query handler and the ledger queries", func() {
+		query := url.Values{
+			types.ResourceName: {testconstants.ValidResource[0].Metadata.Name},
+			types.ResourceType: {testconstants.ValidResource[0].Metadata.ResourceType},
+		}
+		rec := get(types.RESOLVER_PATH+testconstants.ExistentDid+"?"+query.Encode(), nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		request := spanNamed(types.RESOLVER_PATH + ":did")
+		Expect(request.SpanKind).To(Equal(trace.SpanKindServer))
+		for _, step := range []string{"prepare", "validate", "query", "respond"} {
+			Expect(spanNamed(step).Parent.SpanID()).To(Equal(request.SpanContext.SpanID()), "step %s", step)
+		}
+		for _, handler := range []string{"DidQueryAllVersionsHandler", "ResourceNameHandler", "ResourceTypeHandler", "StopHandler"} {
+			Expect(ancestors(spanNamed(handler))).To(ContainElement("query"), "handler %s", handler)
+		}
+		// Every handler of the chain runs within the previous one
+		Expect(ancestors(spanNamed("ResourceTypeHandler"))).To(ContainElement("ResourceNameHandler"))
+
+		ledgerQuery := spanNamed("QueryDIDDoc")
+		Expect(ancestors(ledgerQuery)).To(ContainElement("DidDocResolveHandler"))
+		Expect(ledgerQuery.Attributes).To(ContainElement(HaveField("Value.AsString()", testconstants.ValidMainnetNamespace)))
+
+		for _, span := range exporter.GetSpans() {
+			Expect(span.SpanContext.TraceID()).To(Equal(request.SpanContext.TraceID()))
+		}
+	})
+
+	It("continues the trace of the client", func() {
+		get(types.RESOLVER_PATH+testconstants.ExistentDid, map[string]string{"traceparent": clientTraceParent})
+
+		request := spanNamed(types.RESOLVER_PATH + ":did")
+		Expect(request.SpanContext.TraceID().String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(request.Parent.SpanID().String()).To(Equal("00f067aa0ba902b7"))
+	})
+
+	It("records the resolution errors", func() {
+		rec := get(types.RESOLVER_PATH+testconstants.NotExistentMainnetDid, nil)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+
+		for _, name := range []string{"query", "QueryDIDDoc"} {
+			span := spanNamed(name)
+			Expect(span.Status.Code).To(Equal(codes.Error))
+			Expect(span.Status.Description).To(Equal("notFound"))
+		}
+		Expect(spanNamed("prepare").Status.Code).To(Equal(codes.Unset))
+		Expect(spanNamed(types.RESOLVER_PATH + ":did").Status.Code).To(Equal(codes.Unset))
+	})
+
+	It("doesn't trace probes and scrapes of the metrics", func() {
+		e.GET(types.HEALTH_LIVE_PATH, func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+		get(types.HEALTH_LIVE_PATH, nil)
+		Expect(exporter.GetSpans()).To(BeEmpty())
+	})
+
+	It("keeps the values the step adds to the request context", func() {
+		type key struct{}
+		ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx), httptest.NewRecorder())
+
+		var stepSpan trace.Span
+		Expect(tracingServices.Step(c, "step", func() error {
+			stepSpan = trace.SpanFromContext(c.Request().Context())
+			c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), key{}, "value")))
+			return nil
+		})).To(Succeed())
+		parent.End()
+
+		Expect(stepSpan.SpanContext().SpanID()).ToNot(Equal(parent.SpanContext().SpanID()))
+		Expect(trace.SpanFromContext(c.Request().Context())).To(Equal(parent))
+		Expect(c.Request().Context().Value(key{})).To(Equal("value"))
+		Expect(spanNamed("step").Parent.SpanID()).To(Equal(parent.SpanContext().SpanID()))
+	})
+
+	It("sends the trace context to the node with every gRPC call", func() {
+		server, err := utils.NewMockLedgerServer(utils.MockLedger)
+		Expect(err).To(BeNil())
+		defer server.Stop()
+
+		ledgerService := services.NewLedgerService()
+		defer ledgerService.Close()
+		Expect(ledgerService.RegisterLedger(types.DID_METHOD, types.Network{
+			Namespace:        testconstants.ValidMainnetNamespace,
+			Endpoints:        []types.Endpoint{{Address: server.Address, Timeout: 5 * time.Second}},
+			PoolSize:         1,
+			EndpointStrategy: types.FailoverStrategy,
+		})).To(Succeed())
+		router := services.NewLedgerRouter()
+		router.Register(types.DID_METHOD, testconstants.ValidMainnetNamespace, ledgerService)
+
+		ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+		_, queryErr := router.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		request.End()
+		Expect(queryErr).To(BeNil())
+
+		call := spanNamed("cheqd.did.v2.Query/DidDoc")
+		Expect(call.SpanKind).To(Equal(trace.SpanKindClient))
+		Expect(ancestors(call)).To(Equal([]string{"QueryDIDDoc", "request"}))
+		Expect(server.TraceParent()).To(ContainSubstring(request.SpanContext().TraceID().String()))
+		Expect(server.TraceParent()).To(ContainSubstring(call.SpanContext.SpanID().String()))
+	})
+
+	It("sends the trace context to the node with every REST call", func() {
+		server := utils.NewMockLedgerRESTServer(utils.MockLedger)
+		defer server.Stop()
+
+		ledgerService := services.NewRESTLedgerService()
+		defer ledgerService.Close()
+		Expect(ledgerService.RegisterLedger(types.DID_METHOD, types.Network{
+			Namespace:        testconstants.ValidMainnetNamespace,
+			Endpoints:        []types.Endpoint{{Address: server.Address, Timeout: 5 * time.Second}},
+			PoolSize:         1,
+			EndpointStrategy: types.FailoverStrategy,
+			Api:              types.RESTApi,
+		})).To(Succeed())
+		router := services.NewLedgerRouter()
+		router.Register(types.DID_METHOD, testconstants.ValidMainnetNamespace, ledgerService)
+
+		ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+		_, queryErr := router.QueryDIDDoc(ctx, testconstants.ExistentDid, "")
+		request.End()
+		Expect(queryErr).To(BeNil())
+
+		call := spanNamed("HTTP GET")
+		Expect(call.SpanKind).To(Equal(trace.SpanKindClient))
+		Expect(ancestors(call)).To(Equal([]string{"QueryDIDDoc", "request"}))
+		Expect(server.TraceParent()).To(ContainSubstring(request.SpanContext().TraceID().String()))
+		Expect(server.TraceParent()).To(ContainSubstring(call.SpanContext.SpanID().String()))
+	})
+})
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"encoding/json"
+)
+
+// BatchRequestItem is a DID or DID URL of a batch request, with the options of its resolution or dereferencing.
+// It is given either as an object or as a plain DID URL string.
+type BatchRequestItem struct {
+	DidUrl string `json:"didUrl" example:"did:cheqd:mainnet:zF7rhDBfUt9d1gJPjx7s1JXfUY7oVWkY"`
+	// Media type of the result, as in the Accept header of a single request, */* by default
+	Accept string `json:"accept,omitempty" example:"application/did+ld+json"`
+	// Query parameters added to the DID URL, e.g. versionId or resourceMetadata
+	Options map[string]string `json:"options,omitempty"`
+}
+
+func (i *BatchRequestItem) UnmarshalJSON(data []byte) error {
+	var didUrl string
+	if err := json.Unmarshal(data, &didUrl); err == nil {
+		*i = BatchRequestItem{DidUrl: didUrl}
+		return nil
+	}
+
+	// Decode as an object without calling this method again
+	type batchRequestItem BatchRequestItem
+	return json.Unmarshal(data, (*batchRequestItem)(i))
+}
+
+// BatchResponse is the response of the batch endpoint, with the results in the order of the request items
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is what a single request of the DID URL would have returned
+type BatchResult struct {
+	DidUrl string `json:"didUrl" example:"did:cheqd:mainnet:zF7rhDBfUt9d1gJPjx7s1JXfUY7oVWkY"`
+	// HTTP status code of the result
+	Status      int    `json:"status" example:"200"`
+	ContentType string `json:"contentType,omitempty" example:"application/did+ld+json"`
+	// Target of a redirect, e.g. of a service endpoint
+	Location string `json:"location,omitempty"`
+	// Result or error, if it is JSON
+	Content json.RawMessage `json:"content,omitempty" swaggertype:"object"`
+	// Result of any other media type, e.g. of resource data or CBOR
+	ContentBase64 []byte `json:"contentBase64,omitempty" swaggertype:"string" format:"base64"`
+}
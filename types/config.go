@@ -3,26 +3,218 @@ package types
 import (
 	"encoding/json"
 	"time"
+
+	"google.golang.org/grpc/codes"
 )
 
 type RawConfig struct {
-	MainnetEndpoint  string `mapstructure:"MAINNET_ENDPOINT"`
-	TestnetEndpoint  string `mapstructure:"TESTNET_ENDPOINT"`
+	// YAML or TOML file with the networks
+	ConfigFile string `mapstructure:"CONFIG_FILE"`
+	// Comma-separated namespaces configured with env variables only
+	Networks string `mapstructure:"NETWORKS"`
+	// Networks from the config file and env, loaded by LoadConfig
+	RawNetworks []RawNetwork `mapstructure:"-"`
+
 	ResolverListener string `mapstructure:"RESOLVER_LISTENER"`
 	LogLevel         string `mapstructure:"LOG_LEVEL"`
+	LogFormat        string `mapstructure:"LOG_FORMAT"`
+	RequestTimeout   string `mapstructure:"REQUEST_TIMEOUT"`
+	LedgerPoolSize   int    `mapstructure:"LEDGER_POOL_SIZE"`
+	LedgerKeepAlive  string `mapstructure:"LEDGER_KEEPALIVE"`
+
+	LedgerHealthCheckInterval string `mapstructure:"LEDGER_HEALTH_CHECK_INTERVAL"`
+	LedgerEndpointStrategy    string `mapstructure:"LEDGER_ENDPOINT_STRATEGY"`
+	LedgerPageSize            int    `mapstructure:"LEDGER_PAGE_SIZE"`
+	LedgerMaxPages            int    `mapstructure:"LEDGER_MAX_PAGES"`
+
+	LedgerRetryAttempts       int    `mapstructure:"LEDGER_RETRY_ATTEMPTS"`
+	LedgerRetryBackoff        string `mapstructure:"LEDGER_RETRY_BACKOFF"`
+	LedgerRetryMaxBackoff     string `mapstructure:"LEDGER_RETRY_MAX_BACKOFF"`
+	LedgerRetryCodes          string `mapstructure:"LEDGER_RETRY_CODES"`
+	LedgerBreakerFailures     int    `mapstructure:"LEDGER_BREAKER_FAILURES"`
+	LedgerBreakerOpenDuration string `mapstructure:"LEDGER_BREAKER_OPEN_DURATION"`
+
+	CacheEnabled      bool   `mapstructure:"CACHE_ENABLED"`
+	CacheSize         int    `mapstructure:"CACHE_SIZE"`
+	CacheMutableTTL   string `mapstructure:"CACHE_MUTABLE_TTL"`
+	CacheImmutableTTL string `mapstructure:"CACHE_IMMUTABLE_TTL"`
+	CacheNotFoundTTL  string `mapstructure:"CACHE_NOT_FOUND_TTL"`
+
+	DidDocumentResponses bool `mapstructure:"DID_DOCUMENT_RESPONSES"`
+
+	HttpCacheMaxAge          string `mapstructure:"HTTP_CACHE_MAX_AGE"`
+	HttpCacheImmutableMaxAge string `mapstructure:"HTTP_CACHE_IMMUTABLE_MAX_AGE"`
+
+	ResourceMaxSize int `mapstructure:"RESOURCE_MAX_SIZE"`
+
+	HealthCacheTTL           string `mapstructure:"HEALTH_CACHE_TTL"`
+	HealthRequiredNamespaces string `mapstructure:"HEALTH_REQUIRED_NAMESPACES"`
+
+	BatchMaxItems    int `mapstructure:"BATCH_MAX_ITEMS"`
+	BatchConcurrency int `mapstructure:"BATCH_CONCURRENCY"`
+	BatchMaxBodySize int `mapstructure:"BATCH_MAX_BODY_SIZE"`
+
+	TracingEndpoint    string  `mapstructure:"TRACING_ENDPOINT"`
+	TracingInsecure    bool    `mapstructure:"TRACING_INSECURE"`
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
 }
 
 type Config struct {
 	Networks         []Network
 	ResolverListener string
 	LogLevel         string
+	LogFormat        LogFormat
+	// Overall deadline for handling a request, including all the ledger queries
+	RequestTimeout time.Duration
+	Cache          CacheConfig
+	// DID Document media types in Accept return the DID Document alone rather than the DID resolution result
+	DidDocumentResponses bool
+	HttpCache            HttpCacheConfig
+	// Resource data larger than this number of bytes is not served
+	ResourceMaxSize int
+	Batch           BatchConfig
+	Health          HealthConfig
+	Tracing         TracingConfig
+}
+
+type HttpCacheConfig struct {
+	// Cache-Control max-age of the latest data, which can change on the ledger
+	MaxAge time.Duration
+	// Cache-Control max-age of the data pinned by version, resource or block height, which is also marked immutable
+	ImmutableMaxAge time.Duration
+}
+
+type BatchConfig struct {
+	// Maximum number of DIDs and DID URLs in a batch request
+	MaxItems int
+	// Maximum number of them resolved at the same time
+	Concurrency int
+	// Maximum size of the body of a batch request in bytes, after decompression
+	MaxBodySize int
+}
+
+type HealthConfig struct {
+	// How long the result of probing the ledger of a network is reused by the readiness endpoint
+	CacheTTL time.Duration
+	// Namespaces which must be reachable for the resolver to be ready, all of them if empty
+	RequiredNamespaces []string
+}
+
+type TracingConfig struct {
+	// OTLP gRPC address of the collector receiving the spans, tracing is disabled if empty
+	Endpoint string
+	// Export the spans without TLS
+	Insecure bool
+	// Fraction of the traces started by the resolver which are sampled, traces of the clients keep their decision
+	SampleRatio float64
+}
+
+type CacheConfig struct {
+	Enabled bool
+	// Maximum number of cached ledger responses
+	Size int
+	// TTL of data which can change on the ledger: latest DIDDoc, versions and collection listings
+	MutableTTL time.Duration
+	// TTL of data addressed by version or id, which never changes once written
+	ImmutableTTL time.Duration
+	// TTL of notFound responses
+	NotFoundTTL time.Duration
 }
 
 type Network struct {
 	Namespace string
-	Endpoint  string
-	UseTls    bool
-	Timeout   time.Duration
+	// API of the nodes used to query the ledger
+	Api LedgerApi
+	// Directory or archive with exported ledger data, used instead of endpoints by SnapshotApi
+	SnapshotPath string
+	// Applies to the endpoints which use TLS
+	TLS TLSConfig
+	// Ledger data is verified with Merkle proofs if set, only the gRPC API supports it
+	Verification *VerificationConfig
+	// Number of endpoints which must return the same data for a query to succeed.
+	// Queries are sent to all the endpoints if it's above 1, only the gRPC API supports it.
+	Quorum int
+	// Endpoints are ordered by priority
+	Endpoints           []Endpoint
+	PoolSize            int
+	KeepAlive           time.Duration
+	HealthCheckInterval time.Duration
+	EndpointStrategy    EndpointStrategy
+	// Number of items requested per page of paginated queries
+	PageSize int
+	// Paginated queries fail instead of returning a truncated list when there are more pages
+	MaxPages int
+	// Largest resource data in bytes, the gRPC receive limit is raised to fit it
+	ResourceMaxSize int
+	// Retries of queries which failed on all the endpoints
+	Retry RetryPolicy
+	// Stops sending queries to the endpoints which keep failing
+	CircuitBreaker CircuitBreakerConfig
+}
+
+type RetryPolicy struct {
+	// Number of times all the endpoints are tried, including the first one. Queries aren't retried if it's below 2.
+	MaxAttempts int
+	// Delay before the first retry, doubled for every next one up to MaxBackoff.
+	// Up to half of the delay is randomly taken off, so the clients don't retry in lockstep.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// gRPC status codes of the failures which are retried
+	RetryableCodes []codes.Code
+}
+
+type CircuitBreakerConfig struct {
+	// Number of consecutive failures which open the breaker of an endpoint, the breaker is disabled if it's below 1
+	FailureThreshold int
+	// How long an open breaker rejects queries before a trial query is let through
+	OpenDuration time.Duration
+}
+
+type Endpoint struct {
+	Address string
+	UseTls  bool
+	Timeout time.Duration
+}
+
+type EndpointStrategy string
+
+const (
+	// FailoverStrategy always uses the first healthy endpoint in the configured order
+	FailoverStrategy EndpointStrategy = "failover"
+	// RoundRobinStrategy spreads the queries over all the healthy endpoints
+	RoundRobinStrategy EndpointStrategy = "round-robin"
+)
+
+func (s EndpointStrategy) IsSupported() bool {
+	return s == FailoverStrategy || s == RoundRobinStrategy
+}
+
+type LogFormat string
+
+const (
+	// JSONLogFormat writes a JSON object per line, for log collectors
+	JSONLogFormat LogFormat = "json"
+	// ConsoleLogFormat writes colored lines for humans
+	ConsoleLogFormat LogFormat = "console"
+)
+
+func (f LogFormat) IsSupported() bool {
+	return f == JSONLogFormat || f == ConsoleLogFormat
+}
+
+type LedgerApi string
+
+const (
+	// GRPCApi queries the gRPC API of the nodes, usually served on port 9090
+	GRPCApi LedgerApi = "grpc"
+	// RESTApi queries the REST (LCD) API of the nodes, usually served on port 1317
+	RESTApi LedgerApi = "rest"
+	// SnapshotApi serves the ledger data exported to local files, without any network access
+	SnapshotApi LedgerApi = "snapshot"
+)
+
+func (a LedgerApi) IsSupported() bool {
+	return a == GRPCApi || a == RESTApi || a == SnapshotApi
 }
 
 func (c *Config) MarshalJson() (string, error) {
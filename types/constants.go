@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type ContentType string
 
 const (
@@ -7,6 +9,18 @@ const (
 	DIDJSONLD ContentType = "application/did+ld+json"
 	JSONLD    ContentType = "application/ld+json"
 	JSON      ContentType = "application/json"
+	DIDCBOR   ContentType = "application/did+cbor"
+	CBOR      ContentType = "application/cbor"
+)
+
+// DIDResolutionProfile is the profile of application/ld+json which asks for the DID resolution result
+// rather than the DID Document alone
+const DIDResolutionProfile = "https://w3id.org/did-resolution"
+
+// Media types of the DID resolution result in JSON-LD and CBOR
+const (
+	DIDResolutionResult     = string(JSONLD) + `;profile="` + DIDResolutionProfile + `"`
+	DIDResolutionResultCBOR = string(CBOR) + `;profile="` + DIDResolutionProfile + `"`
 )
 
 func (cType ContentType) IsSupported() bool {
@@ -14,10 +28,16 @@ func (cType ContentType) IsSupported() bool {
 		DIDJSON:   true,
 		DIDJSONLD: true,
 		JSONLD:    true,
+		DIDCBOR:   true,
 	}
 	return supportedTypes[cType]
 }
 
+// IsCBOR reports whether the content type is represented in CBOR rather than JSON
+func (cType ContentType) IsCBOR() bool {
+	return cType == DIDCBOR || cType == CBOR
+}
+
 type TransformKeysType string
 
 const (
@@ -51,6 +71,17 @@ const (
 	DID_METADATA      = "/metadata"
 	RESOURCE_PATH     = "/resources/"
 	SWAGGER_PATH      = "/swagger/*"
+	STATUS_PATH       = "/status"
+	HEALTH_LIVE_PATH  = "/health/live"
+	HEALTH_READY_PATH = "/health/ready"
+	METRICS_PATH      = "/metrics"
+	BATCH_PATH        = "/1.0/identifiers"
+)
+
+// Headers with the metadata of a DID Document returned without the DID resolution result
+const (
+	DidResolutionMetadataHeader = "Did-Resolution-Metadata"
+	DidDocumentMetadataHeader   = "Did-Document-Metadata"
 )
 
 const (
@@ -68,4 +99,59 @@ const (
 	ResourceCollectionId string = "resourceCollectionId"
 	ResourceVersion      string = "resourceVersion"
 	ResourceChecksum     string = "checksum"
+	BlockHeight          string = "blockHeight"
+	Limit                string = "limit"
+	Offset               string = "offset"
+)
+
+const (
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+const (
+	DefaultLedgerPoolSize            = 1
+	DefaultLedgerKeepAlive           = 5 * time.Minute
+	DefaultLedgerHealthCheckInterval = 30 * time.Second
+	DefaultLedgerPageSize            = 1000
+	DefaultLedgerMaxPages            = 100
+)
+
+const (
+	DefaultLedgerRetryAttempts       = 3
+	DefaultLedgerRetryBackoff        = 100 * time.Millisecond
+	DefaultLedgerRetryMaxBackoff     = 2 * time.Second
+	DefaultLedgerRetryCodes          = "Unavailable,ResourceExhausted"
+	DefaultLedgerBreakerFailures     = 5
+	DefaultLedgerBreakerOpenDuration = 30 * time.Second
+)
+
+const (
+	DefaultCacheSize         = 10000
+	DefaultCacheMutableTTL   = 30 * time.Second
+	DefaultCacheImmutableTTL = 24 * time.Hour
+	DefaultCacheNotFoundTTL  = 10 * time.Second
+)
+
+const (
+	DefaultHttpCacheMaxAge          = 30 * time.Second
+	DefaultHttpCacheImmutableMaxAge = 365 * 24 * time.Hour
+)
+
+const (
+	DefaultHealthCacheTTL = 10 * time.Second
+)
+
+const (
+	DefaultTracingSampleRatio = 1.0
+)
+
+const (
+	// Maximum size of resource data in bytes
+	DefaultResourceMaxSize = 10 << 20
+)
+
+const (
+	DefaultBatchMaxItems    = 100
+	DefaultBatchConcurrency = 10
+	DefaultBatchMaxBodySize = 1 << 20
 )
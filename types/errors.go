@@ -3,17 +3,26 @@ package types
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
 	InvalidDidHttpCode                 = 400
 	InvalidDidUrlHttpCode              = 400
 	NotFoundHttpCode                   = 404
+	BlockHeightNotAvailableHttpCode    = 410
+	RequestTooLargeHttpCode            = 413
 	RepresentationNotSupportedHttpCode = 406
 	InternalErrorHttpCode              = 500
 	MethodNotSupportedHttpCode         = 501
+	ResourceTooLargeHttpCode           = 502
+	ServiceUnavailableHttpCode         = 503
+	GatewayTimeoutHttpCode             = 504
 )
 
+// DefaultRetryAfter is suggested to the clients when the ledger is temporarily unavailable
+var DefaultRetryAfter = 10 * time.Second
+
 type IdentityError struct {
 	Code            int
 	Message         string
@@ -21,6 +30,8 @@ type IdentityError struct {
 	Did             string
 	ContentType     ContentType
 	IsDereferencing bool
+	// RetryAfter is set for temporary errors, when the same request may succeed later
+	RetryAfter time.Duration
 }
 
 // Error makes it compatible with `error` interface.
@@ -81,6 +92,36 @@ func NewMethodNotSupportedError(did string, contentType ContentType, err error,
 	return NewIdentityError(MethodNotSupportedHttpCode, "methodNotSupported", isDereferencing, did, contentType, err)
 }
 
+// NewTemporarilyUnavailableError is returned when none of the ledger nodes could answer
+func NewTemporarilyUnavailableError(did string, contentType ContentType, err error, isDereferencing bool) *IdentityError {
+	e := NewIdentityError(ServiceUnavailableHttpCode, "temporarilyUnavailable", isDereferencing, did, contentType, err)
+	e.RetryAfter = DefaultRetryAfter
+	return e
+}
+
+// NewLedgerTimeoutError is returned when the ledger nodes didn't answer in time
+func NewLedgerTimeoutError(did string, contentType ContentType, err error, isDereferencing bool) *IdentityError {
+	e := NewIdentityError(GatewayTimeoutHttpCode, "temporarilyUnavailable", isDereferencing, did, contentType, err)
+	e.RetryAfter = DefaultRetryAfter
+	return e
+}
+
+// NewBlockHeightNotAvailableError is returned when none of the ledger nodes keeps the state at the requested height
+func NewBlockHeightNotAvailableError(did string, contentType ContentType, err error, isDereferencing bool) *IdentityError {
+	return NewIdentityError(BlockHeightNotAvailableHttpCode, "blockHeightNotAvailable", isDereferencing, did, contentType, err)
+}
+
+// NewResourceTooLargeError is returned when the resource data is larger than the configured maximum resource size.
+// The reply of the ledger is too large to be relayed, so it's reported as a bad gateway.
+func NewResourceTooLargeError(did string, contentType ContentType, err error, isDereferencing bool) *IdentityError {
+	return NewIdentityError(ResourceTooLargeHttpCode, "resourceTooLarge", isDereferencing, did, contentType, err)
+}
+
+// NewRequestTooLargeError is returned when the body of a request is larger than the configured maximum
+func NewRequestTooLargeError(did string, contentType ContentType, err error, isDereferencing bool) *IdentityError {
+	return NewIdentityError(RequestTooLargeHttpCode, "requestTooLarge", isDereferencing, did, contentType, err)
+}
+
 func NewInvalidIdentifierError() error {
 	return errors.New("unique id should be one of: 16 bytes of decoded base58 string or UUID")
 }
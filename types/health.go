@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+// LivenessStatus is the response of the liveness endpoint
+type LivenessStatus struct {
+	Status string `json:"status" example:"alive"`
+}
+
+// ReadinessStatus is the response of the readiness endpoint
+type ReadinessStatus struct {
+	// Whether all the required networks can be queried
+	Ready    bool               `json:"ready"`
+	Networks []NetworkReadiness `json:"networks"`
+}
+
+type NetworkReadiness struct {
+	Method    string `json:"method" example:"cheqd"`
+	Namespace string `json:"namespace" example:"mainnet"`
+	// The resolver is not ready while a required network is unreachable
+	Required  bool   `json:"required"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+	// When the ledger was probed, results are reused until they are older than the cache TTL
+	CheckedAt time.Time `json:"checkedAt"`
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
 )
 
 func AddElemToSet(set []string, newElement string) []string {
@@ -25,6 +26,12 @@ func AddElemToSet(set []string, newElement string) []string {
 }
 
 func SetupLogger(config Config) {
+	if config.LogFormat == ConsoleLogFormat {
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+	// Requests log with their own logger in the context, which adds the request ID, others with the global one
+	zerolog.DefaultContextLogger = &log.Logger
+
 	log.Info().Msgf("Setting log level: %s", config.LogLevel)
 	level, err := zerolog.ParseLevel(config.LogLevel)
 	if err != nil {
@@ -33,7 +40,53 @@ func SetupLogger(config Config) {
 	zerolog.SetGlobalLevel(level)
 }
 
+// ParseGRPCEndpoint parses a list of endpoints separated by ";" in priority order.
+// Each endpoint has the format <host:port>,<useTls>,<timeout>
 func ParseGRPCEndpoint(configEndpoint string, networkName string) (*Network, error) {
+	var endpoints []Endpoint
+	for _, endpointConfig := range strings.Split(configEndpoint, ";") {
+		endpoint, err := parseEndpoint(strings.TrimSpace(endpointConfig), networkName)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *endpoint)
+	}
+
+	return &Network{
+		Namespace: networkName,
+		Endpoints: endpoints,
+	}, nil
+}
+
+// parseNetwork parses the endpoint config according to the API of the network.
+// Snapshot networks are configured with the path of the snapshot instead of endpoints.
+func parseNetwork(configEndpoint string, configApi string, networkName string) (*Network, error) {
+	api := LedgerApi(configApi)
+	if !api.IsSupported() {
+		return nil, fmt.Errorf("ledger API %s for %s is not supported", configApi, networkName)
+	}
+
+	if api == SnapshotApi {
+		if configEndpoint == "" {
+			return nil, fmt.Errorf("snapshot path for %s cannot be empty", networkName)
+		}
+		return &Network{
+			Namespace:    networkName,
+			Api:          api,
+			SnapshotPath: configEndpoint,
+		}, nil
+	}
+
+	network, err := ParseGRPCEndpoint(configEndpoint, networkName)
+	if err != nil {
+		return nil, err
+	}
+	network.Api = api
+
+	return network, nil
+}
+
+func parseEndpoint(configEndpoint string, networkName string) (*Endpoint, error) {
 	config := strings.Split(configEndpoint, ",")
 	if len(config) != 3 {
 		return nil, fmt.Errorf(fmt.Sprintf("Endpoint config for %s is invalid: %s", networkName, configEndpoint))
@@ -47,11 +100,10 @@ func ParseGRPCEndpoint(configEndpoint string, networkName string) (*Network, err
 		return nil, fmt.Errorf(fmt.Sprintf("Timeout value %s for %s endpoint is invalid", configEndpoint, networkName))
 	}
 
-	return &Network{
-		Namespace: networkName,
-		Endpoint:  config[0],
-		UseTls:    useTls,
-		Timeout:   timeout,
+	return &Endpoint{
+		Address: config[0],
+		UseTls:  useTls,
+		Timeout: timeout,
 	}, nil
 }
 
@@ -65,10 +117,41 @@ func LoadConfig() (Config, error) {
 			return Config{}, fmt.Errorf("error reading config.env: %v", err)
 		}
 	}
-	viper.SetDefault("MAINNET_ENDPOINT", "")
-	viper.SetDefault("TESTNET_ENDPOINT", "")
+	viper.SetDefault("CONFIG_FILE", "")
+	viper.SetDefault("NETWORKS", "")
 	viper.SetDefault("LOG_LEVEL", "")
+	viper.SetDefault("LOG_FORMAT", string(JSONLogFormat))
 	viper.SetDefault("RESOLVER_LISTENER", "")
+	viper.SetDefault("REQUEST_TIMEOUT", DefaultRequestTimeout.String())
+	viper.SetDefault("LEDGER_POOL_SIZE", DefaultLedgerPoolSize)
+	viper.SetDefault("LEDGER_KEEPALIVE", DefaultLedgerKeepAlive.String())
+	viper.SetDefault("LEDGER_HEALTH_CHECK_INTERVAL", DefaultLedgerHealthCheckInterval.String())
+	viper.SetDefault("LEDGER_ENDPOINT_STRATEGY", string(FailoverStrategy))
+	viper.SetDefault("LEDGER_PAGE_SIZE", DefaultLedgerPageSize)
+	viper.SetDefault("LEDGER_MAX_PAGES", DefaultLedgerMaxPages)
+	viper.SetDefault("LEDGER_RETRY_ATTEMPTS", DefaultLedgerRetryAttempts)
+	viper.SetDefault("LEDGER_RETRY_BACKOFF", DefaultLedgerRetryBackoff.String())
+	viper.SetDefault("LEDGER_RETRY_MAX_BACKOFF", DefaultLedgerRetryMaxBackoff.String())
+	viper.SetDefault("LEDGER_RETRY_CODES", DefaultLedgerRetryCodes)
+	viper.SetDefault("LEDGER_BREAKER_FAILURES", DefaultLedgerBreakerFailures)
+	viper.SetDefault("LEDGER_BREAKER_OPEN_DURATION", DefaultLedgerBreakerOpenDuration.String())
+	viper.SetDefault("CACHE_ENABLED", false)
+	viper.SetDefault("CACHE_SIZE", DefaultCacheSize)
+	viper.SetDefault("CACHE_MUTABLE_TTL", DefaultCacheMutableTTL.String())
+	viper.SetDefault("CACHE_IMMUTABLE_TTL", DefaultCacheImmutableTTL.String())
+	viper.SetDefault("CACHE_NOT_FOUND_TTL", DefaultCacheNotFoundTTL.String())
+	viper.SetDefault("DID_DOCUMENT_RESPONSES", false)
+	viper.SetDefault("HTTP_CACHE_MAX_AGE", DefaultHttpCacheMaxAge.String())
+	viper.SetDefault("HTTP_CACHE_IMMUTABLE_MAX_AGE", DefaultHttpCacheImmutableMaxAge.String())
+	viper.SetDefault("RESOURCE_MAX_SIZE", DefaultResourceMaxSize)
+	viper.SetDefault("HEALTH_CACHE_TTL", DefaultHealthCacheTTL.String())
+	viper.SetDefault("HEALTH_REQUIRED_NAMESPACES", "")
+	viper.SetDefault("BATCH_MAX_ITEMS", DefaultBatchMaxItems)
+	viper.SetDefault("BATCH_CONCURRENCY", DefaultBatchConcurrency)
+	viper.SetDefault("BATCH_MAX_BODY_SIZE", DefaultBatchMaxBodySize)
+	viper.SetDefault("TRACING_ENDPOINT", "")
+	viper.SetDefault("TRACING_INSECURE", false)
+	viper.SetDefault("TRACING_SAMPLE_RATIO", DefaultTracingSampleRatio)
 	viper.AutomaticEnv()
 
 	rawConf := &RawConfig{}
@@ -76,6 +159,10 @@ func LoadConfig() (Config, error) {
 	if err != nil {
 		return Config{}, fmt.Errorf("unable to decode into config struct, %v", err)
 	}
+	rawConf.RawNetworks, err = loadRawNetworks(rawConf.ConfigFile, rawConf.Networks)
+	if err != nil {
+		return Config{}, err
+	}
 	conf, err := NewConfig(*rawConf)
 	if err != nil {
 		return Config{}, fmt.Errorf("invalid config parameter, %v", err)
@@ -93,18 +180,251 @@ func MustLoadConfig() Config {
 }
 
 func NewConfig(rawConfig RawConfig) (Config, error) {
-	mainnetEndpoint, err := ParseGRPCEndpoint(rawConfig.MainnetEndpoint, "mainnet")
+	networks, err := newNetworks(rawConfig.RawNetworks)
 	if err != nil {
 		return Config{}, err
 	}
-	testnetEndpoint, err := ParseGRPCEndpoint(rawConfig.TestnetEndpoint, "testnet")
+
+	requestTimeout, err := time.ParseDuration(rawConfig.RequestTimeout)
+	if err != nil {
+		return Config{}, fmt.Errorf("request timeout value %s is invalid", rawConfig.RequestTimeout)
+	}
+
+	if rawConfig.LedgerPoolSize < 1 {
+		return Config{}, fmt.Errorf("ledger connection pool size must be positive, got %d", rawConfig.LedgerPoolSize)
+	}
+	keepAlive, err := time.ParseDuration(rawConfig.LedgerKeepAlive)
+	if err != nil {
+		return Config{}, fmt.Errorf("ledger keepalive value %s is invalid", rawConfig.LedgerKeepAlive)
+	}
+
+	healthCheckInterval, err := time.ParseDuration(rawConfig.LedgerHealthCheckInterval)
+	if err != nil {
+		return Config{}, fmt.Errorf("ledger health check interval value %s is invalid", rawConfig.LedgerHealthCheckInterval)
+	}
+	logFormat := LogFormat(rawConfig.LogFormat)
+	if !logFormat.IsSupported() {
+		return Config{}, fmt.Errorf("log format %s is not supported", rawConfig.LogFormat)
+	}
+
+	strategy := EndpointStrategy(rawConfig.LedgerEndpointStrategy)
+	if !strategy.IsSupported() {
+		return Config{}, fmt.Errorf("ledger endpoint strategy %s is not supported", rawConfig.LedgerEndpointStrategy)
+	}
+
+	if rawConfig.LedgerPageSize < 1 {
+		return Config{}, fmt.Errorf("ledger page size must be positive, got %d", rawConfig.LedgerPageSize)
+	}
+	if rawConfig.LedgerMaxPages < 1 {
+		return Config{}, fmt.Errorf("ledger max pages must be positive, got %d", rawConfig.LedgerMaxPages)
+	}
+
+	retry, err := newRetryPolicy(rawConfig)
+	if err != nil {
+		return Config{}, err
+	}
+	circuitBreaker, err := newCircuitBreakerConfig(rawConfig)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cache, err := newCacheConfig(rawConfig)
+	if err != nil {
+		return Config{}, err
+	}
+
+	httpCache, err := newHttpCacheConfig(rawConfig)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if rawConfig.ResourceMaxSize < 1 {
+		return Config{}, fmt.Errorf("resource max size must be positive, got %d", rawConfig.ResourceMaxSize)
+	}
+
+	health, err := newHealthConfig(rawConfig, networks)
 	if err != nil {
 		return Config{}, err
 	}
+
+	if rawConfig.BatchMaxItems < 1 {
+		return Config{}, fmt.Errorf("batch max items must be positive, got %d", rawConfig.BatchMaxItems)
+	}
+	if rawConfig.BatchConcurrency < 1 {
+		return Config{}, fmt.Errorf("batch concurrency must be positive, got %d", rawConfig.BatchConcurrency)
+	}
+	if rawConfig.BatchMaxBodySize < 1 {
+		return Config{}, fmt.Errorf("batch max body size must be positive, got %d", rawConfig.BatchMaxBodySize)
+	}
+
+	if rawConfig.TracingSampleRatio < 0 || rawConfig.TracingSampleRatio > 1 {
+		return Config{}, fmt.Errorf("tracing sample ratio must be between 0 and 1, got %v", rawConfig.TracingSampleRatio)
+	}
+
+	for i := range networks {
+		networks[i].PoolSize = rawConfig.LedgerPoolSize
+		networks[i].KeepAlive = keepAlive
+		networks[i].HealthCheckInterval = healthCheckInterval
+		networks[i].EndpointStrategy = strategy
+		networks[i].PageSize = rawConfig.LedgerPageSize
+		networks[i].MaxPages = rawConfig.LedgerMaxPages
+		networks[i].ResourceMaxSize = rawConfig.ResourceMaxSize
+		networks[i].Retry = retry
+		networks[i].CircuitBreaker = circuitBreaker
+	}
+
 	return Config{
-		Networks:         []Network{*mainnetEndpoint, *testnetEndpoint},
+		Networks:         networks,
 		ResolverListener: rawConfig.ResolverListener,
 		LogLevel:         rawConfig.LogLevel,
+		LogFormat:        logFormat,
+		RequestTimeout:   requestTimeout,
+		Cache:            cache,
+
+		DidDocumentResponses: rawConfig.DidDocumentResponses,
+		HttpCache:            httpCache,
+		ResourceMaxSize:      rawConfig.ResourceMaxSize,
+		Batch: BatchConfig{
+			MaxItems:    rawConfig.BatchMaxItems,
+			Concurrency: rawConfig.BatchConcurrency,
+			MaxBodySize: rawConfig.BatchMaxBodySize,
+		},
+		Health: health,
+		Tracing: TracingConfig{
+			Endpoint:    rawConfig.TracingEndpoint,
+			Insecure:    rawConfig.TracingInsecure,
+			SampleRatio: rawConfig.TracingSampleRatio,
+		},
+	}, nil
+}
+
+func newRetryPolicy(rawConfig RawConfig) (RetryPolicy, error) {
+	if rawConfig.LedgerRetryAttempts < 1 {
+		return RetryPolicy{}, fmt.Errorf("ledger retry attempts must be positive, got %d", rawConfig.LedgerRetryAttempts)
+	}
+	initialBackoff, err := time.ParseDuration(rawConfig.LedgerRetryBackoff)
+	if err != nil || initialBackoff < 0 {
+		return RetryPolicy{}, fmt.Errorf("ledger retry backoff value %s is invalid", rawConfig.LedgerRetryBackoff)
+	}
+	maxBackoff, err := time.ParseDuration(rawConfig.LedgerRetryMaxBackoff)
+	if err != nil || maxBackoff < initialBackoff {
+		return RetryPolicy{}, fmt.Errorf("ledger retry max backoff value %s is invalid", rawConfig.LedgerRetryMaxBackoff)
+	}
+
+	var retryableCodes []codes.Code
+	for _, name := range strings.Split(rawConfig.LedgerRetryCodes, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		code, ok := parseStatusCode(name)
+		if !ok {
+			return RetryPolicy{}, fmt.Errorf("ledger retry code %s is not a gRPC status code", name)
+		}
+		retryableCodes = append(retryableCodes, code)
+	}
+
+	return RetryPolicy{
+		MaxAttempts:    rawConfig.LedgerRetryAttempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+		RetryableCodes: retryableCodes,
+	}, nil
+}
+
+// parseStatusCode accepts gRPC status code names in any case, e.g. Unavailable or UNAVAILABLE
+func parseStatusCode(name string) (codes.Code, bool) {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if strings.EqualFold(code.String(), name) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+func newCircuitBreakerConfig(rawConfig RawConfig) (CircuitBreakerConfig, error) {
+	if rawConfig.LedgerBreakerFailures < 0 {
+		return CircuitBreakerConfig{}, fmt.Errorf("ledger circuit breaker failures must not be negative, got %d", rawConfig.LedgerBreakerFailures)
+	}
+	openDuration, err := time.ParseDuration(rawConfig.LedgerBreakerOpenDuration)
+	if err != nil || openDuration <= 0 {
+		return CircuitBreakerConfig{}, fmt.Errorf("ledger circuit breaker open duration value %s is invalid", rawConfig.LedgerBreakerOpenDuration)
+	}
+
+	return CircuitBreakerConfig{
+		FailureThreshold: rawConfig.LedgerBreakerFailures,
+		OpenDuration:     openDuration,
+	}, nil
+}
+
+func newCacheConfig(rawConfig RawConfig) (CacheConfig, error) {
+	if rawConfig.CacheSize < 1 {
+		return CacheConfig{}, fmt.Errorf("cache size must be positive, got %d", rawConfig.CacheSize)
+	}
+	mutableTTL, err := time.ParseDuration(rawConfig.CacheMutableTTL)
+	if err != nil {
+		return CacheConfig{}, fmt.Errorf("cache mutable TTL value %s is invalid", rawConfig.CacheMutableTTL)
+	}
+	immutableTTL, err := time.ParseDuration(rawConfig.CacheImmutableTTL)
+	if err != nil {
+		return CacheConfig{}, fmt.Errorf("cache immutable TTL value %s is invalid", rawConfig.CacheImmutableTTL)
+	}
+	notFoundTTL, err := time.ParseDuration(rawConfig.CacheNotFoundTTL)
+	if err != nil {
+		return CacheConfig{}, fmt.Errorf("cache notFound TTL value %s is invalid", rawConfig.CacheNotFoundTTL)
+	}
+
+	return CacheConfig{
+		Enabled:      rawConfig.CacheEnabled,
+		Size:         rawConfig.CacheSize,
+		MutableTTL:   mutableTTL,
+		ImmutableTTL: immutableTTL,
+		NotFoundTTL:  notFoundTTL,
+	}, nil
+}
+
+func newHttpCacheConfig(rawConfig RawConfig) (HttpCacheConfig, error) {
+	maxAge, err := time.ParseDuration(rawConfig.HttpCacheMaxAge)
+	if err != nil || maxAge < 0 {
+		return HttpCacheConfig{}, fmt.Errorf("HTTP cache max age value %s is invalid", rawConfig.HttpCacheMaxAge)
+	}
+	immutableMaxAge, err := time.ParseDuration(rawConfig.HttpCacheImmutableMaxAge)
+	if err != nil || immutableMaxAge < 0 {
+		return HttpCacheConfig{}, fmt.Errorf("HTTP cache immutable max age value %s is invalid", rawConfig.HttpCacheImmutableMaxAge)
+	}
+
+	return HttpCacheConfig{
+		MaxAge:          maxAge,
+		ImmutableMaxAge: immutableMaxAge,
+	}, nil
+}
+
+// newHealthConfig requires the required namespaces to be among the configured networks
+func newHealthConfig(rawConfig RawConfig, networks []Network) (HealthConfig, error) {
+	cacheTTL, err := time.ParseDuration(rawConfig.HealthCacheTTL)
+	if err != nil || cacheTTL < 0 {
+		return HealthConfig{}, fmt.Errorf("health cache TTL value %s is invalid", rawConfig.HealthCacheTTL)
+	}
+
+	var required []string
+	for _, namespace := range strings.Split(rawConfig.HealthRequiredNamespaces, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			continue
+		}
+		configured := false
+		for _, network := range networks {
+			configured = configured || network.Namespace == namespace
+		}
+		if !configured {
+			return HealthConfig{}, fmt.Errorf("required namespace %s is not a configured network", namespace)
+		}
+		required = append(required, namespace)
+	}
+
+	return HealthConfig{
+		CacheTTL:           cacheTTL,
+		RequiredNamespaces: required,
 	}, nil
 }
 
@@ -0,0 +1,275 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheqd/did-resolver/utils"
+	"github.com/spf13/viper"
+)
+
+// Networks which are declared by setting their endpoints in env, without listing them in NETWORKS
+var DefaultNetworks = []string{"mainnet", "testnet"}
+
+// RawNetwork is a network declared in the config file. Its endpoints and API can be overridden
+// by the <NAMESPACE>_ENDPOINT and <NAMESPACE>_LEDGER_API env variables, its TLS options by <NAMESPACE>_TLS_*,
+// its verification options by <NAMESPACE>_VERIFY_* and its quorum by <NAMESPACE>_QUORUM.
+type RawNetwork struct {
+	Namespace    string          `mapstructure:"namespace"`
+	Api          string          `mapstructure:"api"`
+	Endpoints    []RawEndpoint   `mapstructure:"endpoints"`
+	SnapshotPath string          `mapstructure:"snapshotPath"`
+	TLS          TLSConfig       `mapstructure:"tls"`
+	Verification RawVerification `mapstructure:"verification"`
+	Quorum       string          `mapstructure:"quorum"`
+	// Endpoints in the <host:port>,<useTls>,<timeout>[;...] format. It takes precedence over Endpoints.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+type RawEndpoint struct {
+	Address string `mapstructure:"address"`
+	UseTls  bool   `mapstructure:"useTls"`
+	Timeout string `mapstructure:"timeout"`
+}
+
+type rawNetworksFile struct {
+	Networks []RawNetwork `mapstructure:"networks"`
+}
+
+// NetworkEnvPrefix returns the prefix of env variables overriding the config of the namespace
+func NetworkEnvPrefix(namespace string) string {
+	return strings.ToUpper(namespace)
+}
+
+// loadRawNetworks reads the networks from the YAML or TOML config file, if any, adds the ones
+// declared in env and applies env overrides to all of them
+func loadRawNetworks(configFile string, declared string) ([]RawNetwork, error) {
+	var networks []RawNetwork
+	if configFile != "" {
+		fileConfig := viper.New()
+		fileConfig.SetConfigFile(configFile)
+		if err := fileConfig.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", configFile, err)
+		}
+
+		var file rawNetworksFile
+		if err := fileConfig.Unmarshal(&file); err != nil {
+			return nil, fmt.Errorf("unable to decode networks from %s: %v", configFile, err)
+		}
+		networks = file.Networks
+	}
+
+	isDeclared := func(namespace string) bool {
+		for _, network := range networks {
+			if network.Namespace == namespace {
+				return true
+			}
+		}
+		return false
+	}
+	for _, namespace := range strings.Split(declared, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" && !isDeclared(namespace) {
+			networks = append(networks, RawNetwork{Namespace: namespace})
+		}
+	}
+	for _, namespace := range DefaultNetworks {
+		if viper.GetString(NetworkEnvPrefix(namespace)+"_ENDPOINT") != "" && !isDeclared(namespace) {
+			networks = append(networks, RawNetwork{Namespace: namespace})
+		}
+	}
+
+	for i := range networks {
+		prefix := NetworkEnvPrefix(networks[i].Namespace)
+		if endpoint := viper.GetString(prefix + "_ENDPOINT"); endpoint != "" {
+			networks[i].Endpoint = endpoint
+		}
+		if api := viper.GetString(prefix + "_LEDGER_API"); api != "" {
+			networks[i].Api = api
+		}
+		overrideTLSConfig(&networks[i].TLS, prefix)
+		overrideVerification(&networks[i].Verification, prefix)
+		if quorum := viper.GetString(prefix + "_QUORUM"); quorum != "" {
+			networks[i].Quorum = quorum
+		}
+	}
+
+	return networks, nil
+}
+
+// overrideTLSConfig applies <NAMESPACE>_TLS_* env variables
+func overrideTLSConfig(config *TLSConfig, prefix string) {
+	for key, value := range map[string]*string{
+		"_TLS_CA_FILE":     &config.CAFile,
+		"_TLS_CERT_FILE":   &config.CertFile,
+		"_TLS_KEY_FILE":    &config.KeyFile,
+		"_TLS_SERVER_NAME": &config.ServerName,
+		"_TLS_MIN_VERSION": &config.MinVersion,
+	} {
+		if override := viper.GetString(prefix + key); override != "" {
+			*value = override
+		}
+	}
+}
+
+// overrideVerification applies <NAMESPACE>_VERIFY_* env variables
+func overrideVerification(config *RawVerification, prefix string) {
+	for key, value := range map[string]*string{
+		"_VERIFY_CHAIN_ID":        &config.ChainId,
+		"_VERIFY_TRUSTED_HEIGHT":  &config.TrustedHeight,
+		"_VERIFY_TRUSTED_HASH":    &config.TrustedHash,
+		"_VERIFY_TRUSTING_PERIOD": &config.TrustingPeriod,
+	} {
+		if override := viper.GetString(prefix + key); override != "" {
+			*value = override
+		}
+	}
+}
+
+// newNetworks validates the networks and converts them to the runtime config
+func newNetworks(rawNetworks []RawNetwork) ([]Network, error) {
+	if len(rawNetworks) == 0 {
+		return nil, fmt.Errorf("at least one network must be configured")
+	}
+
+	networks := make([]Network, 0, len(rawNetworks))
+	seen := make(map[string]bool)
+	for _, rawNetwork := range rawNetworks {
+		if rawNetwork.Namespace == "" || !utils.DidNamespaceRegexp.MatchString(rawNetwork.Namespace) {
+			return nil, fmt.Errorf("network namespace %q is invalid", rawNetwork.Namespace)
+		}
+		if seen[rawNetwork.Namespace] {
+			return nil, fmt.Errorf("network %s is configured more than once", rawNetwork.Namespace)
+		}
+		seen[rawNetwork.Namespace] = true
+
+		network, err := newNetwork(rawNetwork)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateNetworkTLS(network, rawNetwork.TLS); err != nil {
+			return nil, err
+		}
+		if err := validateNetworkVerification(network, rawNetwork.Verification); err != nil {
+			return nil, err
+		}
+		if err := validateNetworkQuorum(network, rawNetwork.Quorum); err != nil {
+			return nil, err
+		}
+		networks = append(networks, *network)
+	}
+
+	return networks, nil
+}
+
+func newNetwork(rawNetwork RawNetwork) (*Network, error) {
+	api := rawNetwork.Api
+	if api == "" {
+		api = string(GRPCApi)
+	}
+
+	// Endpoints set in env, or snapshot path for snapshot networks
+	if rawNetwork.Endpoint != "" {
+		return parseNetwork(rawNetwork.Endpoint, api, rawNetwork.Namespace)
+	}
+	if LedgerApi(api) == SnapshotApi {
+		return parseNetwork(rawNetwork.SnapshotPath, api, rawNetwork.Namespace)
+	}
+
+	network := &Network{
+		Namespace: rawNetwork.Namespace,
+		Api:       LedgerApi(api),
+	}
+	if !network.Api.IsSupported() {
+		return nil, fmt.Errorf("ledger API %s for %s is not supported", api, rawNetwork.Namespace)
+	}
+	if len(rawNetwork.Endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints configured for %s", rawNetwork.Namespace)
+	}
+	for _, rawEndpoint := range rawNetwork.Endpoints {
+		if rawEndpoint.Address == "" {
+			return nil, fmt.Errorf("endpoint address for %s cannot be empty", rawNetwork.Namespace)
+		}
+		timeout, err := time.ParseDuration(rawEndpoint.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("timeout value %s for %s endpoint %s is invalid", rawEndpoint.Timeout, rawNetwork.Namespace, rawEndpoint.Address)
+		}
+
+		network.Endpoints = append(network.Endpoints, Endpoint{
+			Address: rawEndpoint.Address,
+			UseTls:  rawEndpoint.UseTls,
+			Timeout: timeout,
+		})
+	}
+
+	return network, nil
+}
+
+// validateNetworkTLS loads the certificates, so the resolver doesn't start with TLS options it can't use
+func validateNetworkTLS(network *Network, config TLSConfig) error {
+	if !config.IsSet() {
+		return nil
+	}
+
+	usesTls := false
+	for _, endpoint := range network.Endpoints {
+		usesTls = usesTls || endpoint.UseTls
+	}
+	if !usesTls {
+		return fmt.Errorf("TLS options are set for %s, but none of its endpoints uses TLS", network.Namespace)
+	}
+
+	if _, err := NewTLSConfig(config); err != nil {
+		return fmt.Errorf("TLS options for %s are invalid: %w", network.Namespace, err)
+	}
+	network.TLS = config
+
+	return nil
+}
+
+// validateNetworkVerification checks the options of the light client. Proofs are requested
+// with ABCI queries, which only the gRPC API serves.
+func validateNetworkVerification(network *Network, raw RawVerification) error {
+	if !raw.IsSet() {
+		return nil
+	}
+
+	if network.Api != GRPCApi {
+		return fmt.Errorf("verification of %s requires the %s API, not %s", network.Namespace, GRPCApi, network.Api)
+	}
+
+	config, err := NewVerificationConfig(raw)
+	if err != nil {
+		return fmt.Errorf("verification options for %s are invalid: %w", network.Namespace, err)
+	}
+	network.Verification = config
+
+	return nil
+}
+
+// validateNetworkQuorum checks that enough endpoints are configured to reach the quorum.
+// Proven data doesn't need to be compared, so a quorum can't be combined with verification.
+func validateNetworkQuorum(network *Network, raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	quorum, err := strconv.Atoi(raw)
+	if err != nil || quorum < 1 {
+		return fmt.Errorf("quorum %q for %s is invalid", raw, network.Namespace)
+	}
+	if quorum > len(network.Endpoints) {
+		return fmt.Errorf("quorum of %d for %s is larger than the number of its endpoints", quorum, network.Namespace)
+	}
+	if quorum > 1 && network.Api != GRPCApi {
+		return fmt.Errorf("quorum for %s requires the %s API, not %s", network.Namespace, GRPCApi, network.Api)
+	}
+	if quorum > 1 && network.Verification != nil {
+		return fmt.Errorf("quorum and verification for %s can't be used together", network.Namespace)
+	}
+	network.Quorum = quorum
+
+	return nil
+}
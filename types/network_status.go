@@ -0,0 +1,23 @@
+package types
+
+// ResolverStatus is the response of the status endpoint
+type ResolverStatus struct {
+	Networks []NetworkStatus `json:"networks"`
+}
+
+type NetworkStatus struct {
+	Method    string    `json:"method" example:"cheqd"`
+	Namespace string    `json:"namespace" example:"mainnet"`
+	Api       LedgerApi `json:"api" example:"grpc"`
+	// Number of endpoints which must agree on every response, if they are queried for consensus
+	Quorum    int              `json:"quorum,omitempty" example:"2"`
+	Endpoints []EndpointStatus `json:"endpoints"`
+}
+
+type EndpointStatus struct {
+	Address string `json:"address" example:"grpc.cheqd.net:443"`
+	Healthy bool   `json:"healthy"`
+	// closed, open or half-open
+	CircuitBreaker      string `json:"circuitBreaker" example:"closed"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+}
@@ -0,0 +1,81 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// MaxPageQuery is the greatest limit or offset accepted
+const MaxPageQuery = math.MaxInt32
+
+// Page selects a part of a list requested with limit and offset queries
+type Page struct {
+	Offset int
+	// Zero means all the items after the offset
+	Limit int
+}
+
+// NewPage parses limit and offset queries. It returns nil if neither of them is set.
+func NewPage(values url.Values) (*Page, error) {
+	if !values.Has(Limit) && !values.Has(Offset) {
+		return nil, nil
+	}
+
+	page := &Page{}
+	if values.Has(Limit) {
+		limit, err := parsePageQuery(values.Get(Limit))
+		if err != nil {
+			return nil, err
+		}
+		if limit <= 0 {
+			return nil, errors.New("limit must be positive")
+		}
+		page.Limit = limit
+	}
+	if values.Has(Offset) {
+		offset, err := parsePageQuery(values.Get(Offset))
+		if err != nil {
+			return nil, err
+		}
+		if offset < 0 {
+			return nil, errors.New("offset must not be negative")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// parsePageQuery parses a limit or offset. Values beyond MaxPageQuery are rejected,
+// since no list of the ledger is that long.
+func parsePageQuery(value string) (int, error) {
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if parsed > MaxPageQuery {
+		return 0, fmt.Errorf("limit and offset must not be greater than %d", MaxPageQuery)
+	}
+
+	return int(parsed), nil
+}
+
+// Bounds returns the indexes of the first item of the page and the one after the last
+// within a list of the given length
+func (p Page) Bounds(length int) (int, int) {
+	start := p.Offset
+	if start > length {
+		start = length
+	}
+
+	end := length
+	// Compared with the remaining length, so that a large limit doesn't overflow
+	if p.Limit > 0 && p.Limit < length-start {
+		end = start + p.Limit
+	}
+
+	return start, end
+}
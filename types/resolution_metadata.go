@@ -11,6 +11,10 @@ type ResolutionMetadata struct {
 	ResolutionError string        `json:"error,omitempty"`
 	Retrieved       string        `json:"retrieved,omitempty" example:"2021-09-01T12:00:00Z"`
 	DidProperties   DidProperties `json:"did,omitempty"`
+	// BlockHeight is set when the ledger was queried at a specific block height
+	BlockHeight int64 `json:"blockHeight,omitempty" example:"4109000"`
+	// Proven is set when the ledger data was verified with Merkle proofs against headers verified by a light client
+	Proven bool `json:"proven,omitempty" example:"true"`
 }
 
 type DidProperties struct {
@@ -1,3 +1,21 @@
 package types
 
-type ResolutionResourceMetadata struct{}
+import (
+	"time"
+
+	resourceTypes "github.com/cheqd/cheqd-node/api/v2/cheqd/resource/v2"
+)
+
+// ResolutionResourceMetadata describes the dereferenced resource data. It's filled for the resource data only,
+// which is returned as it is, so it's used for the HTTP caching headers rather than returned itself.
+type ResolutionResourceMetadata struct {
+	Created  *time.Time `json:"created,omitempty" example:"2021-09-01T12:00:00Z"`
+	Checksum string     `json:"checksum,omitempty" example:"a95380f460e63ad939541a57aecbfd795fcd37c6d78ee86c885340e33a91b559"`
+}
+
+func NewResolutionResourceMetadata(resource *resourceTypes.Metadata) ResolutionResourceMetadata {
+	return ResolutionResourceMetadata{
+		Created:  toTime(resource.Created),
+		Checksum: resource.Checksum,
+	}
+}
@@ -66,6 +66,11 @@ var ResourceSupportedQueries = SupportedQueriesT{
 
 var AllSupportedQueries = DidSupportedQueries.Plus(ResourceSupportedQueries)
 
+var PagingQueries = SupportedQueriesT{
+	Limit,
+	Offset,
+}
+
 var SupportedQueriesWithTransformKeys = []string{
 	VersionId,
 	VersionTime,
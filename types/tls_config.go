@@ -0,0 +1,76 @@
+package types
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig customizes TLS connections to the endpoints of a network which use TLS
+type TLSConfig struct {
+	// PEM file with CA certificates trusted instead of the system ones
+	CAFile string `mapstructure:"caFile"`
+	// PEM files with the client certificate and its key for mutual TLS
+	CertFile string `mapstructure:"certFile"`
+	KeyFile  string `mapstructure:"keyFile"`
+	// Name to verify the server certificate against instead of the endpoint host
+	ServerName string `mapstructure:"serverName"`
+	// Minimum TLS version: 1.0, 1.1, 1.2 or 1.3
+	MinVersion string `mapstructure:"minVersion"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// IsSet reports whether any of the options differs from the defaults
+func (c TLSConfig) IsSet() bool {
+	return c != TLSConfig{}
+}
+
+// NewTLSConfig loads the certificates and builds the client TLS config.
+// Files are read on every call, so renewed certificates are used for new connections.
+func NewTLSConfig(config TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: config.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if config.MinVersion != "" {
+		version, ok := tlsVersions[config.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("TLS version %s is not supported, use one of 1.0, 1.1, 1.2, 1.3", config.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, errors.New("client certificate and key files must be set together")
+	}
+	if config.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s with key %s: %w", config.CertFile, config.KeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Trusted headers older than this can't be used to verify new ones. It should be shorter than
+// the unbonding period of the chain, after which the validators who signed the header may misbehave unpunished.
+const DefaultTrustingPeriod = 14 * 24 * time.Hour
+
+// RawVerification seeds the light client which verifies the ledger data of a network
+type RawVerification struct {
+	ChainId string `mapstructure:"chainId"`
+	// Height and hash of a header obtained from a source trusted out of band, e.g. a block explorer
+	TrustedHeight  string `mapstructure:"trustedHeight"`
+	TrustedHash    string `mapstructure:"trustedHash"`
+	TrustingPeriod string `mapstructure:"trustingPeriod"`
+}
+
+// IsSet reports whether any of the options is set, which enables the verification
+func (v RawVerification) IsSet() bool {
+	return v != RawVerification{}
+}
+
+// VerificationConfig makes the ledger data be verified with Merkle proofs against
+// the app hashes of the headers verified by a light client
+type VerificationConfig struct {
+	ChainId        string
+	TrustedHeight  int64
+	TrustedHash    []byte
+	TrustingPeriod time.Duration
+}
+
+// NewVerificationConfig validates the options of the light client
+func NewVerificationConfig(raw RawVerification) (*VerificationConfig, error) {
+	if raw.ChainId == "" {
+		return nil, fmt.Errorf("chain ID must be set")
+	}
+
+	height, err := strconv.ParseInt(raw.TrustedHeight, 10, 64)
+	if err != nil || height <= 0 {
+		return nil, fmt.Errorf("trusted height %q is invalid", raw.TrustedHeight)
+	}
+
+	hash, err := hex.DecodeString(raw.TrustedHash)
+	if err != nil || len(hash) != 32 {
+		return nil, fmt.Errorf("trusted hash %q is not a hex encoded SHA-256 hash", raw.TrustedHash)
+	}
+
+	trustingPeriod := DefaultTrustingPeriod
+	if raw.TrustingPeriod != "" {
+		trustingPeriod, err = time.ParseDuration(raw.TrustingPeriod)
+		if err != nil || trustingPeriod <= 0 {
+			return nil, fmt.Errorf("trusting period %s is invalid", raw.TrustingPeriod)
+		}
+	}
+
+	return &VerificationConfig{
+		ChainId:        raw.ChainId,
+		TrustedHeight:  height,
+		TrustedHash:    hash,
+		TrustingPeriod: trustingPeriod,
+	}, nil
+}
@@ -4,7 +4,8 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-// If gzip is not accepted by the client, skip the middleware
+// If gzip is not accepted by the client, skip the middleware.
+// Byte ranges are served uncompressed, as they refer to the data as it's stored.
 func GzipSkipper(c echo.Context) bool {
-	return !IsGzipAccepted(c)
+	return !IsGzipAccepted(c) || c.Request().Header.Get("Range") != ""
 }